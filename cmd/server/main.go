@@ -86,6 +86,16 @@ func run(ctx context.Context, logger *slog.Logger) error {
 		return nil
 	})
 
+	// Materialize recurring todos in the background until shutdown
+	eg.Go(func() error {
+		return application.Scheduler.Run(egctx)
+	})
+
+	// Send due reminders in the background until shutdown
+	eg.Go(func() error {
+		return application.Reminders.Run(egctx)
+	})
+
 	eg.Go(func() error {
 		<-egctx.Done()
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -3,30 +3,48 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"path/filepath"
 	"time"
 
 	"github.com/gorilla/sessions"
 	embeddednats "github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 
 	"github.com/yacobolo/datastar-go-blueprint/internal/config"
 	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	authservices "github.com/yacobolo/datastar-go-blueprint/internal/features/auth/services"
 	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/services"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/clock"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/oidc"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/pubsub"
 	"github.com/yacobolo/datastar-go-blueprint/internal/store"
 )
 
 // Repositories holds all repository implementations (driven adapters).
 type Repositories struct {
-	Todos    domain.TodoRepository
-	Sessions domain.SessionRepository
+	Todos     domain.TodoRepository
+	Tags      domain.TagRepository
+	History   domain.HistoryRepository
+	Audit     domain.AuditRepository
+	Reminders domain.ReminderRepository
+	Calendars domain.CalendarRepository
+	Lists     domain.ListRepository
+	Sessions  domain.SessionRepository
+	Users     domain.UserRepository
 }
 
 // Services holds all service instances (application core).
 type Services struct {
-	Todo *services.TodoService
+	Todo     *services.TodoService
+	Lists    *services.ListService
+	Activity *services.ActivityService
+	Calendar *services.CalendarService
+	Auth     *authservices.AuthService
 }
 
 // App is the main application struct that holds all dependencies.
@@ -36,9 +54,17 @@ type App struct {
 	Store        *store.SQLiteStore
 	SessionStore sessions.Store
 	NATS         *nats.Conn
+	JetStream    jetstream.JetStream
+	EventBus     domain.EventBus
+	Presence     domain.Presence
+	OIDC         *oidc.Provider
 	NATSServer   *embeddednats.Server
 	Repositories *Repositories
 	Services     *Services
+	// Scheduler materializes recurring todos; it runs until its context is done.
+	Scheduler *services.RecurrenceScheduler
+	// Reminders sends the reminders that are due; it runs until its context is done.
+	Reminders *services.ReminderScheduler
 }
 
 // New creates a new App instance with all dependencies wired up.
@@ -46,7 +72,7 @@ type App struct {
 // and wires up the application following hexagonal architecture:
 // 1. Initialize infrastructure (SessionStore, NATS server, NATS client, Database)
 // 2. Create repositories (driven adapters) from the Store
-// 3. Create services (application layer) with repository dependencies
+// 3. Create the event bus and services (application layer) with repository dependencies
 func New(cfg *config.Config, logger *slog.Logger) (*App, error) {
 	// 1. Create SessionStore
 	sessionStore := sessions.NewCookieStore([]byte(cfg.SessionSecret))
@@ -55,12 +81,21 @@ func New(cfg *config.Config, logger *slog.Logger) (*App, error) {
 	sessionStore.Options.HttpOnly = true
 	sessionStore.Options.Secure = false
 	sessionStore.Options.SameSite = http.SameSiteLaxMode
+	if cfg.Environment == config.Prod {
+		// Production is served over HTTPS and is never embedded cross-site.
+		// Visitors following a link from another site arrive without the
+		// strict cookie, and session.Middleware loads the page again for them.
+		sessionStore.Options.Secure = true
+		sessionStore.Options.SameSite = http.SameSiteStrictMode
+	}
 
 	// 2. Start embedded NATS server
+	// JetStream data lives next to the database so updates survive restarts
 	natsOpts := &embeddednats.Options{
 		Host:      "localhost",
 		Port:      4222,
 		JetStream: true,
+		StoreDir:  filepath.Join(filepath.Dir(cfg.DBPath), "nats"),
 	}
 	ns, err := embeddednats.NewServer(natsOpts)
 	if err != nil {
@@ -80,6 +115,27 @@ func New(cfg *config.Config, logger *slog.Logger) (*App, error) {
 		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
 	}
 
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		ns.Shutdown()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	streamCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := pubsub.EnsureStream(streamCtx, js); err != nil {
+		nc.Close()
+		ns.Shutdown()
+		return nil, err
+	}
+	presenceKV, err := pubsub.EnsurePresenceBucket(streamCtx, js)
+	if err != nil {
+		nc.Close()
+		ns.Shutdown()
+		return nil, err
+	}
+
 	// 4. Open database
 	dbStore, err := store.Open(cfg.DBPath)
 	if err != nil {
@@ -92,14 +148,36 @@ func New(cfg *config.Config, logger *slog.Logger) (*App, error) {
 
 	// 5. Create repositories (driven adapters)
 	repos := &Repositories{
-		Todos:    store.NewTodoRepository(dbStore),
-		Sessions: store.NewSessionRepository(dbStore),
+		Todos:     store.NewTodoRepository(dbStore),
+		Tags:      store.NewTagRepository(dbStore),
+		History:   store.NewHistoryRepository(dbStore),
+		Audit:     store.NewAuditRepository(dbStore),
+		Reminders: store.NewReminderRepository(dbStore),
+		Calendars: store.NewCalendarRepository(dbStore),
+		Lists:     store.NewListRepository(dbStore),
+		Sessions:  store.NewSessionRepository(dbStore),
+		Users:     store.NewUserRepository(dbStore),
+	}
+
+	// 6. Create the event bus and presence tracker (driven adapters) feeding the real-time UI
+	eventBus := pubsub.NewNATSEventBus(js, logger)
+	presence := pubsub.NewNATSPresence(presenceKV, logger)
+
+	// Single sign-on is optional; the provider is only created when configured
+	var oidcProvider *oidc.Provider
+	if cfg.OIDC.Enabled() {
+		oidcProvider = oidc.NewProvider(cfg.OIDC, nil)
+		logger.Info("OIDC login enabled", "issuer", cfg.OIDC.IssuerURL)
 	}
 
-	// 6. Create services (application layer)
+	// 7. Create services (application layer)
 	// Services depend on domain interfaces, not concrete implementations
 	svc := &Services{
-		Todo: services.NewTodoService(repos.Todos, repos.Sessions, sessionStore),
+		Todo:     services.NewTodoService(dbStore, repos.Todos, repos.Tags, repos.History, repos.Audit, repos.Reminders, repos.Lists, repos.Sessions, eventBus, clock.System{}),
+		Lists:    services.NewListService(dbStore, repos.Lists, repos.Todos, repos.Tags, repos.History, repos.Audit, eventBus),
+		Activity: services.NewActivityService(repos.Audit, repos.Lists, repos.Users),
+		Calendar: services.NewCalendarService(repos.Calendars, repos.Todos),
+		Auth:     authservices.NewAuthService(dbStore, repos.Users, repos.Todos, repos.Lists, repos.Sessions, repos.History, repos.Reminders, repos.Calendars),
 	}
 
 	return &App{
@@ -107,9 +185,15 @@ func New(cfg *config.Config, logger *slog.Logger) (*App, error) {
 		Store:        dbStore,
 		SessionStore: sessionStore,
 		NATS:         nc,
+		JetStream:    js,
+		EventBus:     eventBus,
+		Presence:     presence,
+		OIDC:         oidcProvider,
 		NATSServer:   ns,
 		Repositories: repos,
 		Services:     svc,
+		Scheduler:    services.NewRecurrenceScheduler(svc.Todo, clock.System{}, logger),
+		Reminders:    services.NewReminderScheduler(svc.Todo, clock.System{}, logger),
 	}, nil
 }
 
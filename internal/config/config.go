@@ -27,6 +27,23 @@ type Config struct {
 	DBPath        string
 	LogLevel      slog.Level
 	SessionSecret string
+	OIDC          OIDCConfig
+}
+
+// OIDCConfig holds the OpenID Connect provider used for single sign-on.
+// SSO is disabled when IssuerURL is empty.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// ProviderName is shown on the login button.
+	ProviderName string
+}
+
+// Enabled reports whether an OIDC provider is configured.
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
 }
 
 var (
@@ -70,5 +87,12 @@ func loadBase() *Config {
 			}
 		}(),
 		SessionSecret: getEnv("SESSION_SECRET", "session-secret"),
+		OIDC: OIDCConfig{
+			IssuerURL:    getEnv("OIDC_ISSUER_URL", ""),
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/auth/oidc/callback"),
+			ProviderName: getEnv("OIDC_PROVIDER_NAME", "SSO"),
+		},
 	}
 }
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// Clock tells the time and waits for it to pass. Background jobs take their
+// time from a Clock rather than the time package, so that they can be driven
+// by a fake clock.
+// This is a port in hexagonal architecture, implemented by clock adapters.
+type Clock interface {
+	Now() time.Time
+	// After delivers the time on the returned channel once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
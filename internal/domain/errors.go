@@ -0,0 +1,15 @@
+package domain
+
+import "fmt"
+
+// ConflictError reports that an optimistic concurrency check failed:
+// the entity was changed by another writer since it was read.
+type ConflictError struct {
+	Entity string
+	ID     string
+}
+
+// Error implements the error interface.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %s was modified concurrently", e.Entity, e.ID)
+}
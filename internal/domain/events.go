@@ -0,0 +1,272 @@
+package domain
+
+import "context"
+
+// EventType names a kind of domain event. Adapters use it to encode events
+// and to pick the concrete type when decoding them.
+type EventType string
+
+const (
+	// EventTodoCreated is the type of TodoCreated.
+	EventTodoCreated EventType = "todo.created"
+	// EventTodoEdited is the type of TodoEdited.
+	EventTodoEdited EventType = "todo.edited"
+	// EventTodoToggled is the type of TodoToggled.
+	EventTodoToggled EventType = "todo.toggled"
+	// EventTodoMoved is the type of TodoMoved.
+	EventTodoMoved EventType = "todo.moved"
+	// EventTodoDeleted is the type of TodoDeleted.
+	EventTodoDeleted EventType = "todo.deleted"
+	// EventTodosCleared is the type of TodosCleared.
+	EventTodosCleared EventType = "todos.cleared"
+	// EventTodosReset is the type of TodosReset.
+	EventTodosReset EventType = "todos.reset"
+	// EventTodosImported is the type of TodosImported.
+	EventTodosImported EventType = "todos.imported"
+	// EventViewModeChanged is the type of ViewModeChanged.
+	EventViewModeChanged EventType = "view.mode_changed"
+	// EventTagFilterChanged is the type of TagFilterChanged.
+	EventTagFilterChanged EventType = "view.tag_filter_changed"
+	// EventEditingChanged is the type of EditingChanged.
+	EventEditingChanged EventType = "view.editing_changed"
+	// EventConflictDetected is the type of ConflictDetected.
+	EventConflictDetected EventType = "conflict.detected"
+	// EventListChanged is the type of ListChanged.
+	EventListChanged EventType = "list.changed"
+	// EventListDeleted is the type of ListDeleted.
+	EventListDeleted EventType = "list.deleted"
+	// EventHistoryRecorded is the type of HistoryRecorded.
+	EventHistoryRecorded EventType = "history.recorded"
+	// EventHistoryReplayed is the type of HistoryReplayed.
+	EventHistoryReplayed EventType = "history.replayed"
+	// EventReminderChanged is the type of ReminderChanged.
+	EventReminderChanged EventType = "reminder.changed"
+	// EventReminderDue is the type of ReminderDue.
+	EventReminderDue EventType = "reminder.due"
+	// EventNotificationsChanged is the type of NotificationsChanged.
+	EventNotificationsChanged EventType = "notifications.changed"
+)
+
+// Event is something that happened to the todos of a list or the UI state of a session.
+type Event interface {
+	Type() EventType
+}
+
+// TodoCreated is published when a todo is added.
+type TodoCreated struct {
+	TodoID string
+}
+
+// TodoEdited is published when the text, due date, priority or tags of a todo change.
+type TodoEdited struct {
+	TodoID string
+}
+
+// TodoToggled is published when todos are marked completed or active.
+// Toggling all todos at once lists every todo in one event.
+type TodoToggled struct {
+	TodoIDs   []string
+	Completed bool
+}
+
+// TodoMoved is published when a todo is moved to another place in the order of its list,
+// including when it becomes a subtask or a top-level todo.
+type TodoMoved struct {
+	TodoID string
+}
+
+// TodoDeleted is published when a single todo is removed.
+type TodoDeleted struct {
+	TodoID string
+}
+
+// TodosCleared is published when the completed todos are removed.
+type TodosCleared struct {
+	TodoIDs []string
+}
+
+// TodosReset is published when all todos are replaced by the defaults.
+type TodosReset struct{}
+
+// TodosImported is published when todos were imported from a file into a
+// list. Count is the number of todos created or updated.
+type TodosImported struct {
+	Count int
+}
+
+// ViewModeChanged is published when the view filter mode changes.
+type ViewModeChanged struct {
+	Mode int64
+}
+
+// TagFilterChanged is published when the tags a view is filtered by change.
+type TagFilterChanged struct {
+	Tags []string
+}
+
+// EditingChanged is published when a todo enters or leaves edit mode.
+// TodoID is empty when editing stopped.
+type EditingChanged struct {
+	TodoID string
+}
+
+// ConflictDetected is published when a write was rejected because the entity
+// was modified concurrently, so that open views can show the state that won.
+type ConflictDetected struct {
+	Entity string
+	ID     string
+}
+
+// ListChanged is published when a list is renamed, archived or restored.
+type ListChanged struct{}
+
+// ListDeleted is published when a list is deleted with all its todos.
+type ListDeleted struct{}
+
+// HistoryRecorded is published together with the events of a change that
+// the member who made it can undo.
+type HistoryRecorded struct {
+	MemberID string
+}
+
+// HistoryReplayed is published when a member undid or redid a change.
+// Label describes the change, as recorded.
+type HistoryReplayed struct {
+	MemberID string
+	Label    string
+	Redo     bool
+}
+
+// ReminderChanged is published when a member set or removed their reminder
+// on a todo. Set is false when the reminder was removed.
+type ReminderChanged struct {
+	TodoID string
+	Set    bool
+}
+
+// ReminderDue is published when the reminder a member set on a todo is due.
+// Notify is set if the member opted in to browser notifications.
+type ReminderDue struct {
+	ListID string
+	TodoID string
+	Text   string
+	Notify bool
+}
+
+// NotificationsChanged is published when a member opted in to or out of
+// browser notifications for their reminders.
+type NotificationsChanged struct {
+	Enabled bool
+}
+
+// Type implements Event.
+func (TodoCreated) Type() EventType { return EventTodoCreated }
+
+// Type implements Event.
+func (TodoEdited) Type() EventType { return EventTodoEdited }
+
+// Type implements Event.
+func (TodoToggled) Type() EventType { return EventTodoToggled }
+
+// Type implements Event.
+func (TodoMoved) Type() EventType { return EventTodoMoved }
+
+// Type implements Event.
+func (TodoDeleted) Type() EventType { return EventTodoDeleted }
+
+// Type implements Event.
+func (TodosCleared) Type() EventType { return EventTodosCleared }
+
+// Type implements Event.
+func (TodosReset) Type() EventType { return EventTodosReset }
+
+// Type implements Event.
+func (TodosImported) Type() EventType { return EventTodosImported }
+
+// Type implements Event.
+func (ViewModeChanged) Type() EventType { return EventViewModeChanged }
+
+// Type implements Event.
+func (TagFilterChanged) Type() EventType { return EventTagFilterChanged }
+
+// Type implements Event.
+func (EditingChanged) Type() EventType { return EventEditingChanged }
+
+// Type implements Event.
+func (ConflictDetected) Type() EventType { return EventConflictDetected }
+
+// Type implements Event.
+func (ListChanged) Type() EventType { return EventListChanged }
+
+// Type implements Event.
+func (ListDeleted) Type() EventType { return EventListDeleted }
+
+// Type implements Event.
+func (HistoryRecorded) Type() EventType { return EventHistoryRecorded }
+
+// Type implements Event.
+func (HistoryReplayed) Type() EventType { return EventHistoryReplayed }
+
+// Type implements Event.
+func (ReminderChanged) Type() EventType { return EventReminderChanged }
+
+// Type implements Event.
+func (ReminderDue) Type() EventType { return EventReminderDue }
+
+// Type implements Event.
+func (NotificationsChanged) Type() EventType { return EventNotificationsChanged }
+
+// ListTopic returns the topic carrying changes to the todos of a list, which
+// every member viewing the list follows.
+func ListTopic(listID string) string {
+	return "list." + listID
+}
+
+// ViewTopic returns the topic carrying changes to the UI state a session
+// keeps for a list, which only that session's own views of the list follow.
+func ViewTopic(listID, sessionID string) string {
+	return "view." + listID + "." + sessionID
+}
+
+// MemberTopic returns the topic carrying what concerns a member across all of
+// their lists, such as their reminders, which every view of the member
+// follows.
+func MemberTopic(memberID string) string {
+	return "member." + memberID
+}
+
+// EventBatch holds the events published together by one operation and the
+// position of the batch in the event log. Seq increases with every batch
+// published, across all topics.
+type EventBatch struct {
+	Seq    uint64
+	Events []Event
+}
+
+// EventPublisher defines the interface for publishing domain events.
+// This is a port in hexagonal architecture, implemented by pubsub adapters.
+type EventPublisher interface {
+	// Publish appends events to the log of the given topic as one batch.
+	Publish(ctx context.Context, topic string, events ...Event) error
+}
+
+// EventSubscriber defines the interface for following domain events.
+// This is a port in hexagonal architecture, implemented by pubsub adapters.
+type EventSubscriber interface {
+	// Subscribe delivers the batches published to any of the topics after
+	// sequence afterSeq, in log order: first the ones still stored, then new
+	// ones as they arrive. Delivery stops when ctx is done. A batch that could
+	// not be decoded is delivered without events.
+	Subscribe(ctx context.Context, topics []string, afterSeq uint64) (<-chan EventBatch, error)
+	// Position returns the last sequence in the log and the first one still
+	// stored for the topics. A subscriber that last saw a sequence before
+	// first-1 may have missed batches of the topics that are gone, even if
+	// older batches of other topics are still stored.
+	Position(ctx context.Context, topics []string) (first, last uint64, err error)
+}
+
+// EventBus combines publishing and subscribing.
+type EventBus interface {
+	EventPublisher
+	EventSubscriber
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "errors"
+
+// ErrForbidden is returned when a member's role does not allow an action on a list.
+var ErrForbidden = errors.New("not allowed for your role on this list")
+
+// ListRole is the role a member has on a list.
+type ListRole string
+
+const (
+	// RoleOwner created the list and may invite others.
+	RoleOwner ListRole = "owner"
+	// RoleEditor may change the todos of the list.
+	RoleEditor ListRole = "editor"
+	// RoleViewer may only look at the list.
+	RoleViewer ListRole = "viewer"
+)
+
+// CanEdit reports whether the role may change todos.
+func (r ListRole) CanEdit() bool {
+	return r == RoleOwner || r == RoleEditor
+}
+
+// CanInvite reports whether the role may create invite links.
+func (r ListRole) CanInvite() bool {
+	return r == RoleOwner
+}
+
+// CanManage reports whether the role may rename, archive and delete the list.
+func (r ListRole) CanManage() bool {
+	return r == RoleOwner
+}
+
+// Valid reports whether r is one of the known roles.
+func (r ListRole) Valid() bool {
+	switch r {
+	case RoleOwner, RoleEditor, RoleViewer:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "context"
+
+// Viewer is one open view of a list, such as a browser tab following its updates.
+type Viewer struct {
+	ConnectionID string `json:"connectionId"`
+	MemberID     string `json:"memberId"`
+	Name         string `json:"name"`
+	// EditingID is the todo the viewer is editing, or "" if none.
+	EditingID string `json:"editingId,omitempty"`
+}
+
+// Presence tracks who is viewing each list right now and what they are editing.
+// This is a port in hexagonal architecture, implemented by pubsub adapters.
+type Presence interface {
+	// Join marks viewer as present on the list until ctx is done.
+	Join(ctx context.Context, listID string, viewer Viewer) error
+	// SetEditing records the todo a joined viewer is editing, or "" for none.
+	SetEditing(ctx context.Context, listID, connectionID, editingID string) error
+	// Watch delivers the viewers of the list, first as they are and then
+	// again after every change, until ctx is done.
+	Watch(ctx context.Context, listID string) (<-chan []Viewer, error)
+}
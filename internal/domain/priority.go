@@ -0,0 +1,30 @@
+package domain
+
+// Priority ranks how urgent a todo is. The zero value means the todo has no priority.
+type Priority int64
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+// Valid reports whether p is one of the known priorities.
+func (p Priority) Valid() bool {
+	return p >= PriorityNone && p <= PriorityHigh
+}
+
+// String returns the name of the priority shown to members.
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "Low"
+	case PriorityMedium:
+		return "Medium"
+	case PriorityHigh:
+		return "High"
+	default:
+		return "None"
+	}
+}
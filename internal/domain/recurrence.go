@@ -0,0 +1,221 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRecurrence is returned for recurrence rules that cannot be parsed.
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+
+// Frequency is the unit a recurring todo repeats in.
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+	FrequencyYearly  Frequency = "YEARLY"
+)
+
+// weekdayCodes are the two-letter weekday names RRULEs use, indexed by time.Weekday.
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Recurrence is how a todo repeats, as a subset of an RFC 5545 RRULE: a
+// frequency, an interval, and for daily and weekly rules the weekdays the
+// todo falls on. The zero value means the todo does not repeat.
+type Recurrence struct {
+	Freq Frequency
+	// Interval is the number of days, weeks, months or years between occurrences.
+	Interval int
+	// ByDay limits daily and weekly rules to these weekdays, in week order.
+	ByDay []time.Weekday
+}
+
+// ParseRecurrence parses an RRULE such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH".
+// An optional "RRULE:" prefix is ignored, and an empty rule parses to the
+// zero Recurrence.
+func ParseRecurrence(rule string) (Recurrence, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	if rule == "" {
+		return Recurrence{}, nil
+	}
+
+	r := Recurrence{Interval: 1}
+	for part := range strings.SplitSeq(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Recurrence{}, fmt.Errorf("%w: %q", ErrInvalidRecurrence, part)
+		}
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if !slices.Contains([]Frequency{FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly}, r.Freq) {
+				return Recurrence{}, fmt.Errorf("%w: unsupported frequency %q", ErrInvalidRecurrence, value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return Recurrence{}, fmt.Errorf("%w: interval %q", ErrInvalidRecurrence, value)
+			}
+			r.Interval = interval
+		case "BYDAY":
+			for code := range strings.SplitSeq(value, ",") {
+				day := slices.Index(weekdayCodes, code)
+				if day < 0 {
+					return Recurrence{}, fmt.Errorf("%w: weekday %q", ErrInvalidRecurrence, code)
+				}
+				if !slices.Contains(r.ByDay, time.Weekday(day)) {
+					r.ByDay = append(r.ByDay, time.Weekday(day))
+				}
+			}
+		default:
+			return Recurrence{}, fmt.Errorf("%w: unsupported part %q", ErrInvalidRecurrence, name)
+		}
+	}
+
+	if r.Freq == "" {
+		return Recurrence{}, fmt.Errorf("%w: missing frequency", ErrInvalidRecurrence)
+	}
+	if len(r.ByDay) > 0 && r.Freq != FrequencyDaily && r.Freq != FrequencyWeekly {
+		return Recurrence{}, fmt.Errorf("%w: weekdays need a daily or weekly frequency", ErrInvalidRecurrence)
+	}
+	slices.SortFunc(r.ByDay, func(a, b time.Weekday) int { return weekIndex(a) - weekIndex(b) })
+	return r, nil
+}
+
+// IsZero reports whether r is the zero Recurrence, which does not repeat.
+func (r Recurrence) IsZero() bool {
+	return r.Freq == ""
+}
+
+// String returns r in its canonical RRULE form, or "" for the zero Recurrence.
+func (r Recurrence) String() string {
+	if r.IsZero() {
+		return ""
+	}
+	rule := "FREQ=" + string(r.Freq)
+	if r.Interval > 1 {
+		rule += ";INTERVAL=" + strconv.Itoa(r.Interval)
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = weekdayCodes[day]
+		}
+		rule += ";BYDAY=" + strings.Join(codes, ",")
+	}
+	return rule
+}
+
+// Label describes r to members, such as "Every 2 weeks on Mon, Thu".
+func (r Recurrence) Label() string {
+	if r.IsZero() {
+		return ""
+	}
+	units := map[Frequency]string{
+		FrequencyDaily:   "day",
+		FrequencyWeekly:  "week",
+		FrequencyMonthly: "month",
+		FrequencyYearly:  "year",
+	}
+	label := fmt.Sprintf("Every %d %ss", r.Interval, units[r.Freq])
+	if r.Interval == 1 {
+		label = "Every " + units[r.Freq]
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()[:3]
+		}
+		label += " on " + strings.Join(days, ", ")
+	}
+	return label
+}
+
+// Next returns the first occurrence after the one on the day of from, in the
+// series whose first occurrence is on the day of start. Monthly and yearly
+// rules fall on the day of the month of start, and yearly ones in its month,
+// moved back to the last day of shorter months; a zero start counts from
+// from. Days are calendar days in UTC, and occurrences fall at midnight UTC
+// like due dates do.
+func (r Recurrence) Next(from, start time.Time) time.Time {
+	from = from.UTC().Truncate(24 * time.Hour)
+	if start.IsZero() {
+		start = from
+	}
+	start = start.UTC()
+	switch r.Freq {
+	case FrequencyDaily, FrequencyWeekly:
+		if len(r.ByDay) == 0 {
+			days := r.Interval
+			if r.Freq == FrequencyWeekly {
+				days *= 7
+			}
+			return from.AddDate(0, 0, days)
+		}
+		// Walk forward day by day. Weekly rules skip the weeks in between
+		// occurrences, counting weeks from the one from is in.
+		for day := from.AddDate(0, 0, 1); ; day = day.AddDate(0, 0, 1) {
+			if !slices.Contains(r.ByDay, day.Weekday()) {
+				continue
+			}
+			if r.Freq == FrequencyDaily {
+				if daysBetween(from, day)%r.Interval == 0 {
+					return day
+				}
+				continue
+			}
+			if (daysBetween(weekStart(from), weekStart(day))/7)%r.Interval == 0 {
+				return day
+			}
+		}
+	case FrequencyMonthly:
+		return monthDay(from.Year(), from.Month()+time.Month(r.Interval), start.Day())
+	case FrequencyYearly:
+		return monthDay(from.Year()+r.Interval, start.Month(), start.Day())
+	default:
+		return from
+	}
+}
+
+// NextOnOrAfter returns the first occurrence after the one on the day of
+// from that falls on or after the day of notBefore, skipping the occurrences
+// that were missed in between. start is as for Next.
+func (r Recurrence) NextOnOrAfter(from, start, notBefore time.Time) time.Time {
+	notBefore = notBefore.UTC().Truncate(24 * time.Hour)
+	next := r.Next(from, start)
+	for next.Before(notBefore) {
+		next = r.Next(next, start)
+	}
+	return next
+}
+
+// weekIndex returns the position of day in a week starting on Monday.
+func weekIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// weekStart returns the Monday of the week t is in.
+func weekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -weekIndex(t.Weekday()))
+}
+
+// daysBetween returns the number of whole days from a to b, both at midnight UTC.
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// monthDay returns the given day of a month at midnight UTC, moved back to
+// the last day of shorter months, so that the 31st stays at the end of the
+// month instead of spilling into the next one. Months past December count
+// into the following years.
+func monthDay(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, lastDay)-1)
+}
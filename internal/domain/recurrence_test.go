@@ -0,0 +1,240 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// day returns the given day at midnight UTC, like due dates.
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{rule: "", want: ""},
+		{rule: "  ", want: ""},
+		{rule: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{rule: "RRULE:FREQ=DAILY", want: "FREQ=DAILY"},
+		{rule: "freq=weekly;interval=1", want: "FREQ=WEEKLY"},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TH,MO,TH", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"},
+		{rule: "FREQ=DAILY;BYDAY=SU,SA", want: "FREQ=DAILY;BYDAY=SA,SU"},
+		{rule: "INTERVAL=3;FREQ=MONTHLY", want: "FREQ=MONTHLY;INTERVAL=3"},
+		{rule: "FREQ=YEARLY", want: "FREQ=YEARLY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q): %v", tt.rule, err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("ParseRecurrence(%q) = %q, want %q", tt.rule, got, tt.want)
+			}
+			if again, err := ParseRecurrence(r.String()); err != nil || again.String() != tt.want {
+				t.Errorf("canonical form %q parsed back as %q, %v", r.String(), again.String(), err)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceRejectsInvalidRules(t *testing.T) {
+	for _, rule := range []string{
+		"DAILY",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=-1",
+		"FREQ=DAILY;INTERVAL=two",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=MO",
+		"FREQ=DAILY;COUNT=3",
+		"INTERVAL=2",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			if _, err := ParseRecurrence(rule); !errors.Is(err, ErrInvalidRecurrence) {
+				t.Errorf("ParseRecurrence(%q) = %v, want %v", rule, err, ErrInvalidRecurrence)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		from  time.Time
+		// want are the occurrences after from, each counted from the one
+		// before it.
+		want []time.Time
+	}{
+		{
+			name: "daily",
+			rule: "FREQ=DAILY",
+			from: day(2026, time.February, 27),
+			want: []time.Time{day(2026, time.February, 28), day(2026, time.March, 1)},
+		},
+		{
+			name: "every third day",
+			rule: "FREQ=DAILY;INTERVAL=3",
+			from: time.Date(2026, time.March, 2, 18, 30, 0, 0, time.UTC),
+			want: []time.Time{day(2026, time.March, 5), day(2026, time.March, 8)},
+		},
+		{
+			name: "daily on weekdays",
+			rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			from: day(2026, time.March, 5), // Thursday
+			want: []time.Time{day(2026, time.March, 6), day(2026, time.March, 9), day(2026, time.March, 10)},
+		},
+		{
+			name: "weekly",
+			rule: "FREQ=WEEKLY",
+			from: day(2026, time.March, 2),
+			want: []time.Time{day(2026, time.March, 9), day(2026, time.March, 16)},
+		},
+		{
+			name: "every other week on Monday and Thursday",
+			rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			from: day(2026, time.March, 2), // Monday
+			want: []time.Time{day(2026, time.March, 5), day(2026, time.March, 16), day(2026, time.March, 19), day(2026, time.March, 30)},
+		},
+		{
+			name:  "monthly on the 31st",
+			rule:  "FREQ=MONTHLY",
+			start: day(2026, time.January, 31),
+			from:  day(2026, time.January, 31),
+			want: []time.Time{
+				day(2026, time.February, 28),
+				day(2026, time.March, 31),
+				day(2026, time.April, 30),
+				day(2026, time.May, 31),
+			},
+		},
+		{
+			name:  "monthly on the 31st in a leap year",
+			rule:  "FREQ=MONTHLY",
+			start: day(2028, time.January, 31),
+			from:  day(2028, time.January, 31),
+			want:  []time.Time{day(2028, time.February, 29), day(2028, time.March, 31)},
+		},
+		{
+			name:  "every other month on the 30th",
+			rule:  "FREQ=MONTHLY;INTERVAL=2",
+			start: day(2025, time.December, 30),
+			from:  day(2025, time.December, 30),
+			want:  []time.Time{day(2026, time.February, 28), day(2026, time.April, 30)},
+		},
+		{
+			name: "monthly without a start",
+			rule: "FREQ=MONTHLY",
+			from: day(2026, time.January, 31),
+			want: []time.Time{day(2026, time.February, 28)},
+		},
+		{
+			name:  "yearly on the 29th of February",
+			rule:  "FREQ=YEARLY",
+			start: day(2024, time.February, 29),
+			from:  day(2024, time.February, 29),
+			want: []time.Time{
+				day(2025, time.February, 28),
+				day(2026, time.February, 28),
+				day(2027, time.February, 28),
+				day(2028, time.February, 29),
+			},
+		},
+		{
+			name:  "every fourth year on the 29th of February",
+			rule:  "FREQ=YEARLY;INTERVAL=4",
+			start: day(2024, time.February, 29),
+			from:  day(2024, time.February, 29),
+			want:  []time.Time{day(2028, time.February, 29), day(2032, time.February, 29)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q): %v", tt.rule, err)
+			}
+			from := tt.from
+			for _, want := range tt.want {
+				got := r.Next(from, tt.start)
+				if !got.Equal(want) {
+					t.Fatalf("Next(%s) = %s, want %s", from.Format(time.DateOnly), got.Format(time.DateOnly), want.Format(time.DateOnly))
+				}
+				from = got
+			}
+		})
+	}
+}
+
+func TestRecurrenceNextOnOrAfter(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      string
+		start     time.Time
+		from      time.Time
+		notBefore time.Time
+		want      time.Time
+	}{
+		{
+			name:      "next occurrence not missed",
+			rule:      "FREQ=DAILY",
+			from:      day(2026, time.March, 2),
+			notBefore: day(2026, time.March, 2),
+			want:      day(2026, time.March, 3),
+		},
+		{
+			name:      "next occurrence due today",
+			rule:      "FREQ=WEEKLY",
+			from:      day(2026, time.March, 2),
+			notBefore: day(2026, time.March, 9),
+			want:      day(2026, time.March, 9),
+		},
+		{
+			name:      "missed occurrences skipped",
+			rule:      "FREQ=WEEKLY",
+			from:      day(2026, time.March, 2),
+			notBefore: time.Date(2026, time.March, 25, 15, 0, 0, 0, time.UTC),
+			want:      day(2026, time.March, 30),
+		},
+		{
+			name:      "missed weekdays skipped",
+			rule:      "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			from:      day(2026, time.March, 2),
+			notBefore: day(2026, time.March, 17),
+			want:      day(2026, time.March, 19),
+		},
+		{
+			name:      "missed months keep the day of the start",
+			rule:      "FREQ=MONTHLY",
+			start:     day(2026, time.January, 31),
+			from:      day(2026, time.January, 31),
+			notBefore: day(2026, time.March, 1),
+			want:      day(2026, time.March, 31),
+		},
+		{
+			name:      "missed years keep the day of the start",
+			rule:      "FREQ=YEARLY",
+			start:     day(2024, time.February, 29),
+			from:      day(2024, time.February, 29),
+			notBefore: day(2027, time.March, 1),
+			want:      day(2028, time.February, 29),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q): %v", tt.rule, err)
+			}
+			if got := r.NextOnOrAfter(tt.from, tt.start, tt.notBefore); !got.Equal(tt.want) {
+				t.Errorf("NextOnOrAfter(%s, %s) = %s, want %s", tt.from.Format(time.DateOnly), tt.notBefore.Format(time.DateOnly), got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
@@ -4,21 +4,148 @@ package domain
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
 )
 
 // TodoRepository defines the interface for todo data access.
 // This is a port in hexagonal architecture, implemented by store adapters.
+// Single-row writes compare the stored version and return the number of
+// affected rows, which is zero when the version no longer matches.
 type TodoRepository interface {
-	GetTodosByUser(ctx context.Context, userID string) ([]queries.Todo, error)
+	GetTodosByList(ctx context.Context, listID sql.NullString) ([]queries.Todo, error)
+	GetTodoByID(ctx context.Context, id string) (queries.Todo, error)
 	CreateTodo(ctx context.Context, arg queries.CreateTodoParams) error
-	DeleteAllTodosByUser(ctx context.Context, userID string) error
+	UpdateTodoTask(ctx context.Context, arg queries.UpdateTodoTaskParams) (int64, error)
+	ToggleTodoCompleted(ctx context.Context, arg queries.ToggleTodoCompletedParams) (int64, error)
+	MoveTodo(ctx context.Context, arg queries.MoveTodoParams) (int64, error)
+	RestoreTodo(ctx context.Context, arg queries.RestoreTodoParams) (int64, error)
+	ClearTodoRecurrence(ctx context.Context, arg queries.ClearTodoRecurrenceParams) (int64, error)
+	GetRecurringTodosDueBefore(ctx context.Context, dueAt sql.NullTime) ([]queries.Todo, error)
+	SetTodoCompleted(ctx context.Context, arg queries.SetTodoCompletedParams) (int64, error)
+	SetTodoParent(ctx context.Context, arg queries.SetTodoParentParams) (int64, error)
+	DeleteTodo(ctx context.Context, arg queries.DeleteTodoParams) (int64, error)
+	DeleteTodosByParent(ctx context.Context, parentID sql.NullString) error
+	DeleteCompletedTodosByList(ctx context.Context, listID sql.NullString) error
+	DeleteAllTodosByList(ctx context.Context, listID sql.NullString) error
+	MoveTodosToUser(ctx context.Context, arg queries.MoveTodosToUserParams) error
+	SearchTodos(ctx context.Context, arg queries.SearchTodosParams) ([]queries.SearchTodosRow, error)
+	GetDueTodosByMember(ctx context.Context, memberID string) ([]queries.GetDueTodosByMemberRow, error)
 }
 
-// SessionRepository defines the interface for session data access.
+// ListRepository defines the interface for todo list, membership and invite data access.
+// This is a port in hexagonal architecture, implemented by store adapters.
+type ListRepository interface {
+	GetList(ctx context.Context, id string) (queries.List, error)
+	GetFirstListByOwner(ctx context.Context, ownerID string) (queries.List, error)
+	CreateList(ctx context.Context, arg queries.CreateListParams) error
+	CountListsByOwner(ctx context.Context, ownerID string) (int64, error)
+	MoveListsToOwner(ctx context.Context, arg queries.MoveListsToOwnerParams) error
+	GetListMember(ctx context.Context, arg queries.GetListMemberParams) (queries.ListMember, error)
+	AddListMember(ctx context.Context, arg queries.AddListMemberParams) error
+	MoveListMembers(ctx context.Context, arg queries.MoveListMembersParams) error
+	CreateListInvite(ctx context.Context, arg queries.CreateListInviteParams) error
+	GetListInvite(ctx context.Context, token string) (queries.ListInvite, error)
+	GetListsByMember(ctx context.Context, memberID string) ([]queries.List, error)
+	RenameList(ctx context.Context, arg queries.RenameListParams) error
+	SetListArchivedAt(ctx context.Context, arg queries.SetListArchivedAtParams) error
+	DeleteList(ctx context.Context, id string) error
+	DeleteListMembers(ctx context.Context, listID string) error
+	DeleteListInvites(ctx context.Context, listID string) error
+	DeleteListViews(ctx context.Context, listID string) error
+}
+
+// TagRepository defines the interface for tag data access. Tags belong to a
+// list and are attached to its todos.
+// This is a port in hexagonal architecture, implemented by store adapters.
+type TagRepository interface {
+	UpsertTag(ctx context.Context, arg queries.UpsertTagParams) (string, error)
+	AddTodoTag(ctx context.Context, arg queries.AddTodoTagParams) error
+	GetTodoTagsByList(ctx context.Context, listID string) ([]queries.GetTodoTagsByListRow, error)
+	DeleteTodoTags(ctx context.Context, todoID string) error
+	DeleteCompletedTodoTagsByList(ctx context.Context, listID sql.NullString) error
+	DeleteTodoTagsByList(ctx context.Context, listID string) error
+	DeleteTagsByList(ctx context.Context, listID string) error
+}
+
+// HistoryRepository defines the interface for the history of todo changes a
+// member made to a list. Commands newer than the last one still done have
+// been undone and can be redone.
+// This is a port in hexagonal architecture, implemented by store adapters.
+type HistoryRepository interface {
+	CreateTodoCommand(ctx context.Context, arg queries.CreateTodoCommandParams) error
+	GetLastTodoCommand(ctx context.Context, arg queries.GetLastTodoCommandParams) (queries.TodoCommand, error)
+	GetNextUndoneTodoCommand(ctx context.Context, arg queries.GetNextUndoneTodoCommandParams) (queries.TodoCommand, error)
+	SetTodoCommandUndone(ctx context.Context, arg queries.SetTodoCommandUndoneParams) error
+	DeleteTodoCommand(ctx context.Context, id int64) error
+	DeleteUndoneTodoCommands(ctx context.Context, arg queries.DeleteUndoneTodoCommandsParams) error
+	TrimTodoCommands(ctx context.Context, arg queries.TrimTodoCommandsParams) error
+	DeleteTodoCommandsByList(ctx context.Context, listID string) error
+	MoveTodoCommands(ctx context.Context, arg queries.MoveTodoCommandsParams) error
+}
+
+// AuditRepository defines the interface for the append-only log of changes
+// to the todos of a list. Events are only ever added, never changed.
+// This is a port in hexagonal architecture, implemented by store adapters.
+type AuditRepository interface {
+	CreateTodoEvent(ctx context.Context, arg queries.CreateTodoEventParams) error
+	GetTodoEventsByList(ctx context.Context, arg queries.GetTodoEventsByListParams) ([]queries.TodoEvent, error)
+	GetTodoEventsByTodo(ctx context.Context, arg queries.GetTodoEventsByTodoParams) ([]queries.TodoEvent, error)
+	GetTodoEventsByListUntil(ctx context.Context, arg queries.GetTodoEventsByListUntilParams) ([]queries.TodoEvent, error)
+}
+
+// ReminderRepository defines the interface for the reminders members set on
+// todos and whether they opted in to browser notifications for them. A
+// reminder is pending until it is marked sent.
+// This is a port in hexagonal architecture, implemented by store adapters.
+type ReminderRepository interface {
+	UpsertReminder(ctx context.Context, arg queries.UpsertReminderParams) error
+	DeleteReminder(ctx context.Context, arg queries.DeleteReminderParams) error
+	GetPendingRemindersByList(ctx context.Context, arg queries.GetPendingRemindersByListParams) ([]queries.Reminder, error)
+	GetDueReminders(ctx context.Context, arg queries.GetDueRemindersParams) ([]queries.GetDueRemindersRow, error)
+	MarkReminderSent(ctx context.Context, arg queries.MarkReminderSentParams) (int64, error)
+	GetNotificationSetting(ctx context.Context, memberID string) (queries.NotificationSetting, error)
+	UpsertNotificationSetting(ctx context.Context, arg queries.UpsertNotificationSettingParams) error
+	MoveReminders(ctx context.Context, arg queries.MoveRemindersParams) error
+	MoveNotificationSetting(ctx context.Context, arg queries.MoveNotificationSettingParams) error
+}
+
+// CalendarRepository defines the interface for the secret tokens of the
+// calendar feeds members subscribe to.
+// This is a port in hexagonal architecture, implemented by store adapters.
+type CalendarRepository interface {
+	GetCalendarFeedByMember(ctx context.Context, memberID string) (queries.CalendarFeed, error)
+	GetCalendarFeedByToken(ctx context.Context, token string) (queries.CalendarFeed, error)
+	UpsertCalendarFeed(ctx context.Context, arg queries.UpsertCalendarFeedParams) error
+	MoveCalendarFeed(ctx context.Context, arg queries.MoveCalendarFeedParams) error
+}
+
+// SessionRepository defines the interface for session data access, including
+// the UI state each session keeps per list.
 // This is a port in hexagonal architecture, implemented by store adapters.
 type SessionRepository interface {
 	GetSession(ctx context.Context, sessionID string) (queries.Session, error)
-	UpsertSession(ctx context.Context, arg queries.UpsertSessionParams) error
+	UpsertSession(ctx context.Context, arg queries.UpsertSessionParams) (int64, error)
+	GetListView(ctx context.Context, arg queries.GetListViewParams) (queries.ListView, error)
+	UpsertListView(ctx context.Context, arg queries.UpsertListViewParams) (int64, error)
+	MoveListViews(ctx context.Context, arg queries.MoveListViewsParams) error
+}
+
+// UserRepository defines the interface for user account data access.
+// This is a port in hexagonal architecture, implemented by store adapters.
+type UserRepository interface {
+	GetUserByID(ctx context.Context, id string) (queries.User, error)
+	GetUserByEmail(ctx context.Context, email string) (queries.User, error)
+	CreateUser(ctx context.Context, arg queries.CreateUserParams) error
+	GetUserByOIDCSubject(ctx context.Context, arg queries.GetUserByOIDCSubjectParams) (queries.User, error)
+	CreateOIDCUser(ctx context.Context, arg queries.CreateOIDCUserParams) error
+	LinkUserOIDCSubject(ctx context.Context, arg queries.LinkUserOIDCSubjectParams) error
+}
+
+// UnitOfWork runs a group of repository calls atomically.
+// Repository calls made with txCtx take part in the same transaction,
+// which is committed if fn returns nil and rolled back otherwise.
+type UnitOfWork interface {
+	WithinTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
 }
@@ -0,0 +1,141 @@
+// Package auth implements the user account handlers and routes.
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	authcomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/auth/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/auth/pages"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/auth/services"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/oidc"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/session"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+
+	"github.com/a-h/templ"
+	"github.com/gorilla/sessions"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// Handlers holds dependencies for auth HTTP handlers.
+type Handlers struct {
+	logger       *slog.Logger
+	authService  *services.AuthService
+	sessionStore sessions.Store
+	oidc         *oidc.Provider
+}
+
+// NewHandlers creates a new Handlers instance with the given dependencies.
+// provider is nil when single sign-on is not configured.
+func NewHandlers(logger *slog.Logger, authService *services.AuthService, sessionStore sessions.Store, provider *oidc.Provider) *Handlers {
+	return &Handlers{
+		logger:       logger,
+		authService:  authService,
+		sessionStore: sessionStore,
+		oidc:         provider,
+	}
+}
+
+// LoginPage renders the login form
+func (h *Handlers) LoginPage(w http.ResponseWriter, r *http.Request) {
+	h.renderPage(w, r, pages.LoginPage("Sign in", h.ssoName(), ""))
+}
+
+// SignupPage renders the signup form
+func (h *Handlers) SignupPage(w http.ResponseWriter, r *http.Request) {
+	h.renderPage(w, r, pages.SignupPage("Sign up", h.ssoName()))
+}
+
+// ssoName returns the OIDC provider name, or "" when SSO is off.
+func (h *Handlers) ssoName() string {
+	if h.oidc == nil {
+		return ""
+	}
+	return h.oidc.Name()
+}
+
+// renderPage renders an auth page, or sends visitors who are already signed in home.
+func (h *Handlers) renderPage(w http.ResponseWriter, r *http.Request, page templ.Component) {
+	identity, ok := session.Require(w, r)
+	if !ok {
+		return
+	}
+	if identity.User != nil {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	if err := page.Render(r.Context(), w); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// Login checks the submitted credentials and signs the session in
+func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
+	identity, ok := session.Require(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.authService.LogIn(r.Context(), r.FormValue("email"), r.FormValue("password"), identity.SessionID)
+	h.finishAuth(w, r, user, err)
+}
+
+// Signup creates an account and signs the session in
+func (h *Handlers) Signup(w http.ResponseWriter, r *http.Request) {
+	identity, ok := session.Require(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.authService.SignUp(r.Context(), r.FormValue("email"), r.FormValue("password"), identity.SessionID)
+	h.finishAuth(w, r, user, err)
+}
+
+// finishAuth signs the session in to user and redirects home, or shows the
+// error above the form. The cookie is saved before the SSE response starts.
+func (h *Handlers) finishAuth(w http.ResponseWriter, r *http.Request, user queries.User, err error) {
+	if err == nil {
+		err = session.LogIn(h.sessionStore, w, r, user.ID)
+	}
+
+	sse := datastar.NewSSE(w, r)
+	if err != nil {
+		message := err.Error()
+		if !isUserError(err) {
+			h.logger.Error("failed to sign in", "error", err)
+			message = "Something went wrong, please try again"
+		}
+		if err := sse.PatchElementTempl(authcomponents.AuthError(message)); err != nil {
+			h.logger.Error("failed to send auth error", "error", err)
+		}
+		return
+	}
+
+	if err := sse.Redirect("/"); err != nil {
+		h.logger.Error("failed to redirect", "error", err)
+	}
+}
+
+// Logout signs the session out and starts a new anonymous session
+func (h *Handlers) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := session.LogOut(h.sessionStore, w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sse := datastar.NewSSE(w, r)
+	if err := sse.Redirect("/"); err != nil {
+		h.logger.Error("failed to redirect", "error", err)
+	}
+}
+
+// isUserError reports whether err is meant to be shown to the user as is.
+func isUserError(err error) bool {
+	return errors.Is(err, services.ErrInvalidCredentials) ||
+		errors.Is(err, services.ErrEmailTaken) ||
+		errors.Is(err, services.ErrInvalidEmail) ||
+		errors.Is(err, services.ErrInvalidPassword) ||
+		errors.Is(err, services.ErrMissingEmail)
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/auth/pages"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/auth/services"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/oidc"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/session"
+)
+
+const (
+	// oidcCookieName is the short-lived session holding the pending login.
+	oidcCookieName = "oidc"
+	// oidcLoginTimeout is how long a user has to finish logging in at the provider.
+	oidcLoginTimeout = 10 * time.Minute
+)
+
+// OIDCLogin starts a login at the OIDC provider
+func (h *Handlers) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	identity, ok := session.Require(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := h.oidc.AuthCodeURL(r.Context(), req)
+	if err != nil {
+		h.logger.Error("failed to start oidc login", "error", err)
+		http.Error(w, "single sign-on is unavailable", http.StatusBadGateway)
+		return
+	}
+
+	// The pending login rides in its own cookie, which must survive the
+	// cross-site redirect back from the provider, hence SameSite=Lax. It also
+	// remembers the anonymous session, as a strict session cookie is not sent
+	// along with that redirect.
+	sess, _ := h.sessionStore.New(r, oidcCookieName)
+	sess.Options.MaxAge = int(oidcLoginTimeout.Seconds())
+	sess.Options.SameSite = http.SameSiteLaxMode
+	sess.Values["state"] = req.State
+	sess.Values["nonce"] = req.Nonce
+	sess.Values["verifier"] = req.Verifier
+	sess.Values["session_id"] = identity.SessionID
+	if err := sess.Save(r, w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback finishes a login: it checks the state, exchanges the code,
+// validates the ID token and signs the session in to the linked user.
+func (h *Handlers) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	req, anonymousID, ok := h.pendingLogin(w, r)
+	if !ok || r.URL.Query().Get("state") != req.State {
+		h.oidcFailed(w, r, "Your sign-in expired, please try again")
+		return
+	}
+	if providerErr := r.URL.Query().Get("error"); providerErr != "" {
+		h.logger.Warn("oidc provider returned an error", "error", providerErr, "description", r.URL.Query().Get("error_description"))
+		h.oidcFailed(w, r, "Sign-in was cancelled or denied")
+		return
+	}
+
+	claims, err := h.oidc.Exchange(r.Context(), r.URL.Query().Get("code"), req)
+	if err != nil {
+		h.logger.Error("failed to complete oidc login", "error", err)
+		h.oidcFailed(w, r, "Sign-in failed, please try again")
+		return
+	}
+
+	user, err := h.authService.LogInOIDC(r.Context(), services.OIDCIdentity{
+		Issuer:        h.oidc.Issuer(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}, anonymousID)
+	if err != nil {
+		message := err.Error()
+		if !isUserError(err) {
+			h.logger.Error("failed to sign in with oidc", "error", err)
+			message = "Something went wrong, please try again"
+		}
+		h.oidcFailed(w, r, message)
+		return
+	}
+
+	if err := session.LogIn(h.sessionStore, w, r, user.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// session.Middleware loads the callback again same-site for browsers that
+	// tell it came from the provider, but others reach it cross-site. There, a
+	// redirect would still count as part of the cross-site navigation from the
+	// provider, so the strict session cookie would not be sent. Moving on from
+	// a page of our own makes the next request same-site.
+	if err := pages.SignedInPage("/").Render(r.Context(), w); err != nil {
+		h.logger.Error("failed to render signed in page", "error", err)
+	}
+}
+
+// pendingLogin reads and clears the login started by OIDCLogin, returning it
+// with the anonymous session ID it was started from.
+func (h *Handlers) pendingLogin(w http.ResponseWriter, r *http.Request) (oidc.AuthRequest, string, bool) {
+	sess, err := h.sessionStore.Get(r, oidcCookieName)
+	if err != nil {
+		return oidc.AuthRequest{}, "", false
+	}
+
+	state, _ := sess.Values["state"].(string)
+	nonce, _ := sess.Values["nonce"].(string)
+	verifier, _ := sess.Values["verifier"].(string)
+	anonymousID, _ := sess.Values["session_id"].(string)
+
+	// A login can be completed only once.
+	sess.Options.MaxAge = -1
+	if err := sess.Save(r, w); err != nil {
+		h.logger.Error("failed to clear oidc login", "error", err)
+	}
+
+	if state == "" || nonce == "" || verifier == "" {
+		return oidc.AuthRequest{}, "", false
+	}
+	return oidc.AuthRequest{State: state, Nonce: nonce, Verifier: verifier}, anonymousID, true
+}
+
+// oidcFailed renders the login page with an error message.
+func (h *Handlers) oidcFailed(w http.ResponseWriter, r *http.Request, message string) {
+	w.WriteHeader(http.StatusUnauthorized)
+	if err := pages.LoginPage("Sign in", h.ssoName(), message).Render(r.Context(), w); err != nil {
+		h.logger.Error("failed to render login page", "error", err)
+	}
+}
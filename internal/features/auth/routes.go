@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"github.com/yacobolo/datastar-go-blueprint/internal/app"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SetupRoutes configures all auth-related HTTP routes.
+func SetupRoutes(router chi.Router, application *app.App) error {
+	// Extract specific dependencies from App and pass to handlers
+	handlers := NewHandlers(
+		application.Logger,
+		application.Services.Auth,
+		application.SessionStore,
+		application.OIDC,
+	)
+
+	router.Get("/login", handlers.LoginPage)
+	router.Get("/signup", handlers.SignupPage)
+
+	router.Route("/auth", func(authRouter chi.Router) {
+		authRouter.Post("/login", handlers.Login)
+		authRouter.Post("/signup", handlers.Signup)
+		authRouter.Post("/logout", handlers.Logout)
+
+		// Single sign-on is only routed when a provider is configured.
+		if application.OIDC != nil {
+			authRouter.Get("/oidc/login", handlers.OIDCLogin)
+			authRouter.Get("/oidc/callback", handlers.OIDCCallback)
+		}
+	})
+
+	return nil
+}
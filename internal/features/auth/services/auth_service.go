@@ -0,0 +1,280 @@
+// Package services contains business logic for the auth feature.
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// minPasswordLength is the shortest password accepted at signup.
+const minPasswordLength = 8
+
+var (
+	// ErrInvalidCredentials is returned when the email or password is wrong.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrEmailTaken is returned when signing up with an email that already has an account.
+	ErrEmailTaken = errors.New("an account with this email already exists")
+	// ErrInvalidEmail is returned when the email address cannot be parsed.
+	ErrInvalidEmail = errors.New("enter a valid email address")
+	// ErrInvalidPassword is returned when the password is too short or too long to hash.
+	ErrInvalidPassword = fmt.Errorf("passwords must be %d to 72 bytes long", minPasswordLength)
+	// ErrMissingEmail is returned when an OIDC provider does not share the user's email.
+	ErrMissingEmail = errors.New("your identity provider did not share an email address")
+)
+
+// dummyHash is compared against when no user has the given email, so that
+// logging in takes as long for unknown emails as for wrong passwords.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// AuthService provides business logic for user accounts.
+type AuthService struct {
+	uow          domain.UnitOfWork
+	userRepo     domain.UserRepository
+	todoRepo     domain.TodoRepository
+	listRepo     domain.ListRepository
+	sessionRepo  domain.SessionRepository
+	historyRepo  domain.HistoryRepository
+	reminderRepo domain.ReminderRepository
+	calendarRepo domain.CalendarRepository
+}
+
+// NewAuthService creates a new AuthService with the given repositories. The
+// other repositories hold what an anonymous session keeps besides its lists,
+// which moves to the account on its first login.
+func NewAuthService(uow domain.UnitOfWork, userRepo domain.UserRepository, todoRepo domain.TodoRepository, listRepo domain.ListRepository, sessionRepo domain.SessionRepository, historyRepo domain.HistoryRepository, reminderRepo domain.ReminderRepository, calendarRepo domain.CalendarRepository) *AuthService {
+	return &AuthService{
+		uow:          uow,
+		userRepo:     userRepo,
+		todoRepo:     todoRepo,
+		listRepo:     listRepo,
+		sessionRepo:  sessionRepo,
+		historyRepo:  historyRepo,
+		reminderRepo: reminderRepo,
+		calendarRepo: calendarRepo,
+	}
+}
+
+// SignUp creates an account and moves the lists of the anonymous session
+// with the given ID into it.
+func (s *AuthService) SignUp(ctx context.Context, email, password, anonymousID string) (queries.User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return queries.User{}, err
+	}
+	if len(password) < minPasswordLength || len(password) > 72 {
+		return queries.User{}, ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return queries.User{}, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := queries.User{ID: uuid.New().String(), Email: email, PasswordHash: string(hash)}
+	err = s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if _, err := s.userRepo.GetUserByEmail(txCtx, email); err == nil {
+			return ErrEmailTaken
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to look up user: %w", err)
+		}
+
+		if err := s.userRepo.CreateUser(txCtx, queries.CreateUserParams{
+			ID:           user.ID,
+			Email:        user.Email,
+			PasswordHash: user.PasswordHash,
+		}); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return s.claimTodos(txCtx, user.ID, anonymousID)
+	})
+	if err != nil {
+		return queries.User{}, err
+	}
+	return user, nil
+}
+
+// LogIn checks the email and password. On the first login of an account that
+// has no lists yet, the lists of the anonymous session move into it.
+func (s *AuthService) LogIn(ctx context.Context, email, password, anonymousID string) (queries.User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return queries.User{}, ErrInvalidCredentials
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return queries.User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return queries.User{}, fmt.Errorf("failed to look up user: %w", err)
+	}
+	// Accounts created through OIDC have no password hash and never match.
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return queries.User{}, ErrInvalidCredentials
+	}
+
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		return s.claimTodos(txCtx, user.ID, anonymousID)
+	}); err != nil {
+		return queries.User{}, err
+	}
+	return user, nil
+}
+
+// OIDCIdentity is a user as asserted by a validated OIDC ID token.
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// LogInOIDC signs in the user linked to the issuer and subject of id,
+// creating the account on first login. An existing password account with the
+// same email is linked instead, but only if the provider verified the email.
+// Lists of the anonymous session are claimed as in LogIn.
+func (s *AuthService) LogInOIDC(ctx context.Context, id OIDCIdentity, anonymousID string) (queries.User, error) {
+	link := queries.GetUserByOIDCSubjectParams{
+		OidcIssuer:  sql.NullString{String: id.Issuer, Valid: true},
+		OidcSubject: sql.NullString{String: id.Subject, Valid: true},
+	}
+
+	var user queries.User
+	err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		user, err = s.userRepo.GetUserByOIDCSubject(txCtx, link)
+		if err == nil {
+			return s.claimTodos(txCtx, user.ID, anonymousID)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to look up user: %w", err)
+		}
+
+		email, err := normalizeEmail(id.Email)
+		if err != nil {
+			return ErrMissingEmail
+		}
+
+		user, err = s.userRepo.GetUserByEmail(txCtx, email)
+		switch {
+		case err == nil:
+			if !id.EmailVerified || user.OidcSubject.Valid {
+				return ErrEmailTaken
+			}
+			if err := s.userRepo.LinkUserOIDCSubject(txCtx, queries.LinkUserOIDCSubjectParams{
+				OidcIssuer:  link.OidcIssuer,
+				OidcSubject: link.OidcSubject,
+				ID:          user.ID,
+			}); err != nil {
+				return fmt.Errorf("failed to link user: %w", err)
+			}
+			user.OidcIssuer, user.OidcSubject = link.OidcIssuer, link.OidcSubject
+		case errors.Is(err, sql.ErrNoRows):
+			user = queries.User{
+				ID:          uuid.New().String(),
+				Email:       email,
+				OidcIssuer:  link.OidcIssuer,
+				OidcSubject: link.OidcSubject,
+			}
+			if err := s.userRepo.CreateOIDCUser(txCtx, queries.CreateOIDCUserParams{
+				ID:          user.ID,
+				Email:       user.Email,
+				OidcIssuer:  user.OidcIssuer,
+				OidcSubject: user.OidcSubject,
+			}); err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+		default:
+			return fmt.Errorf("failed to look up user: %w", err)
+		}
+		return s.claimTodos(txCtx, user.ID, anonymousID)
+	})
+	if err != nil {
+		return queries.User{}, err
+	}
+	return user, nil
+}
+
+// claimTodos moves the lists of an anonymous session, its memberships of
+// lists shared with it and the todos it wrote to a user that has no lists,
+// together with its views of the lists, its undo history, its reminders, its
+// notification setting and its calendar feed. Users who already have lists
+// keep them, and the anonymous ones stay behind.
+func (s *AuthService) claimTodos(ctx context.Context, userID, anonymousID string) error {
+	count, err := s.listRepo.CountListsByOwner(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to count lists: %w", err)
+	}
+	if count > 0 || anonymousID == "" {
+		return nil
+	}
+
+	if err := s.listRepo.MoveListsToOwner(ctx, queries.MoveListsToOwnerParams{
+		ToOwnerID:   userID,
+		FromOwnerID: anonymousID,
+	}); err != nil {
+		return fmt.Errorf("failed to move anonymous lists: %w", err)
+	}
+	if err := s.listRepo.MoveListMembers(ctx, queries.MoveListMembersParams{
+		ToMemberID:   userID,
+		FromMemberID: anonymousID,
+	}); err != nil {
+		return fmt.Errorf("failed to move anonymous list memberships: %w", err)
+	}
+	if err := s.todoRepo.MoveTodosToUser(ctx, queries.MoveTodosToUserParams{
+		ToUserID:   userID,
+		FromUserID: anonymousID,
+	}); err != nil {
+		return fmt.Errorf("failed to move anonymous todos: %w", err)
+	}
+	if err := s.sessionRepo.MoveListViews(ctx, queries.MoveListViewsParams{
+		ToMemberID:   userID,
+		FromMemberID: anonymousID,
+	}); err != nil {
+		return fmt.Errorf("failed to move anonymous list views: %w", err)
+	}
+	if err := s.historyRepo.MoveTodoCommands(ctx, queries.MoveTodoCommandsParams{
+		ToMemberID:   userID,
+		FromMemberID: anonymousID,
+	}); err != nil {
+		return fmt.Errorf("failed to move anonymous history: %w", err)
+	}
+	if err := s.reminderRepo.MoveReminders(ctx, queries.MoveRemindersParams{
+		ToMemberID:   userID,
+		FromMemberID: anonymousID,
+	}); err != nil {
+		return fmt.Errorf("failed to move anonymous reminders: %w", err)
+	}
+	if err := s.reminderRepo.MoveNotificationSetting(ctx, queries.MoveNotificationSettingParams{
+		ToMemberID:   userID,
+		FromMemberID: anonymousID,
+	}); err != nil {
+		return fmt.Errorf("failed to move anonymous notification setting: %w", err)
+	}
+	if err := s.calendarRepo.MoveCalendarFeed(ctx, queries.MoveCalendarFeedParams{
+		ToMemberID:   userID,
+		FromMemberID: anonymousID,
+	}); err != nil {
+		return fmt.Errorf("failed to move anonymous calendar feed: %w", err)
+	}
+	return nil
+}
+
+func normalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return addr.Address, nil
+}
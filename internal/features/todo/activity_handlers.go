@@ -0,0 +1,61 @@
+package todo
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	commoncomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/common/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/pages"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/services"
+)
+
+// ActivityPage renders the activity feed of a list the visitor is a member
+// of. The optional "todo" query parameter narrows the feed down to a single
+// todo, and the optional "at" parameter, an RFC 3339 time, adds the list as
+// it was at that time.
+func (h *Handlers) ActivityPage(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+	listID, ok := h.RequireList(w, r, sessionID)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+
+	var at time.Time
+	if raw := query.Get("at"); raw != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	activity, err := h.activityService.Activity(r.Context(), sessionID, listID, query.Get("todo"))
+	if err == nil && !at.IsZero() {
+		activity.At = at
+		activity.Snapshot, err = h.activityService.ListAt(r.Context(), sessionID, listID, at)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrListNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	nav, err := h.nav(r.Context(), sessionID, listID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctx := commoncomponents.WithNav(r.Context(), nav)
+	if err := pages.ActivityPage("Activity · "+activity.ListName, activity).Render(ctx, w); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
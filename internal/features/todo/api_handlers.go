@@ -0,0 +1,441 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// defaultPageSize is the number of todos a page holds unless asked otherwise.
+	defaultPageSize = 50
+	// maxPageSize is the most todos a page holds.
+	maxPageSize = 200
+	// maxAPIBodySize is the largest request body the JSON API reads.
+	maxAPIBodySize = 1 << 20
+)
+
+// apiTodo is a todo as the JSON API represents it.
+type apiTodo struct {
+	ID         string          `json:"id"`
+	Text       string          `json:"text"`
+	Completed  bool            `json:"completed"`
+	DueDate    string          `json:"dueDate,omitempty" doc:"Day the todo is due, as YYYY-MM-DD."`
+	Priority   domain.Priority `json:"priority" doc:"0 for none, 1 for low, 2 for medium and 3 for high priority."`
+	Tags       []string        `json:"tags"`
+	ParentID   string          `json:"parentId,omitempty" doc:"ID of the todo this is a subtask of."`
+	Recurrence string          `json:"recurrence,omitempty" doc:"RRULE the todo repeats by, such as FREQ=WEEKLY;BYDAY=MO."`
+	Version    int64           `json:"version" doc:"Increases with every change; also sent as the ETag."`
+}
+
+// apiTodoPage is a page of the todos of a list.
+type apiTodoPage struct {
+	Items  []apiTodo `json:"items"`
+	Total  int       `json:"total" doc:"Number of todos matching the filters, across all pages."`
+	Offset int       `json:"offset"`
+	Limit  int       `json:"limit"`
+	Next   string    `json:"next,omitempty" doc:"URL of the next page, if there is one."`
+}
+
+// apiNewTodo is the body that creates a todo.
+type apiNewTodo struct {
+	Text       string          `json:"text" doc:"May contain #tags, which become tags of the todo."`
+	Completed  bool            `json:"completed,omitempty"`
+	DueDate    string          `json:"dueDate,omitempty" doc:"Day the todo is due, as YYYY-MM-DD."`
+	Priority   domain.Priority `json:"priority,omitempty" doc:"0 for none, 1 for low, 2 for medium and 3 for high priority."`
+	Tags       []string        `json:"tags,omitempty" doc:"Tag names of letters, digits, dashes and underscores."`
+	ParentID   string          `json:"parentId,omitempty" doc:"Creates the todo as the last subtask of this top-level todo."`
+	Recurrence string          `json:"recurrence,omitempty" doc:"RRULE the todo repeats by; recurring todos without a due date are due today."`
+}
+
+// apiTodoPatch is the body that changes a todo. Fields that are left out
+// keep their value; a patch sets at least one field.
+type apiTodoPatch struct {
+	Text       *string          `json:"text,omitempty"`
+	Completed  *bool            `json:"completed,omitempty"`
+	DueDate    *string          `json:"dueDate,omitempty" doc:"Day the todo is due, as YYYY-MM-DD, or empty to remove the due date."`
+	Priority   *domain.Priority `json:"priority,omitempty"`
+	Tags       *[]string        `json:"tags,omitempty" doc:"Replaces all tags of the todo; tag names consist of letters, digits, dashes and underscores."`
+	Recurrence *string          `json:"recurrence,omitempty" doc:"RRULE the todo repeats by, or empty to stop it repeating."`
+}
+
+// apiProblem is an RFC 7807 problem detail, the body of every error the JSON
+// API returns.
+type apiProblem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// WriteProblem replies to r with an RFC 7807 problem detail of the given
+// status.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(apiProblem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+// writeInternalProblem logs err and replies with a 500 problem detail that
+// leaves it out, as it may tell about the database.
+func (h *Handlers) writeInternalProblem(w http.ResponseWriter, r *http.Request, err error) {
+	h.logger.Error("api request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	WriteProblem(w, r, http.StatusInternalServerError, "the request could not be completed, try again later")
+}
+
+// writeServiceProblem replies with the problem detail matching an error of TodoService.
+func (h *Handlers) writeServiceProblem(w http.ResponseWriter, r *http.Request, err error) {
+	var conflict *domain.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		WriteProblem(w, r, http.StatusConflict, "the todo was changed concurrently, reload it and try again")
+	case errors.Is(err, domain.ErrForbidden):
+		WriteProblem(w, r, http.StatusForbidden, "the list is read-only for you")
+	case errors.Is(err, services.ErrNestedSubtask):
+		WriteProblem(w, r, http.StatusUnprocessableEntity, err.Error())
+	default:
+		h.writeInternalProblem(w, r, err)
+	}
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// readAPIJSON decodes the JSON body of r into v, rejecting unknown fields,
+// and replies with a problem detail if it cannot.
+func readAPIJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		WriteProblem(w, r, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %v", err))
+		return false
+	}
+	return true
+}
+
+func newAPITodo(todo *todocomponents.Todo) apiTodo {
+	tags := todo.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return apiTodo{
+		ID:         todo.ID,
+		Text:       todo.Text,
+		Completed:  todo.Completed,
+		DueDate:    todo.DueDate(),
+		Priority:   todo.Priority,
+		Tags:       tags,
+		ParentID:   todo.ParentID,
+		Recurrence: todo.Recurrence,
+		Version:    todo.Version,
+	}
+}
+
+// apiModeName returns the name the mode query parameter takes for mode,
+// such as "high-priority".
+func apiModeName(mode todocomponents.TodoViewMode) string {
+	return strings.ReplaceAll(strings.ToLower(todocomponents.TodoViewModeStrings[mode]), " ", "-")
+}
+
+// apiModeNames returns the names of all view modes.
+func apiModeNames() []string {
+	var names []string
+	for mode := todocomponents.TodoViewModeAll; mode < todocomponents.TodoViewModeLast; mode++ {
+		names = append(names, apiModeName(mode))
+	}
+	return names
+}
+
+// apiMVC loads the list addressed by the request like RequireMVC, replying
+// with problem details.
+func (h *Handlers) apiMVC(w http.ResponseWriter, r *http.Request) (string, *todocomponents.TodoMVC, bool) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return "", nil, false
+	}
+	listID := chi.URLParam(r, "listID")
+	if listID == "" {
+		list, err := h.listService.DefaultList(r.Context(), sessionID)
+		if err != nil {
+			h.writeInternalProblem(w, r, err)
+			return "", nil, false
+		}
+		listID = list.ID
+	}
+
+	mvc, err := h.todoService.GetMVC(r.Context(), sessionID, listID)
+	if errors.Is(err, services.ErrListNotFound) {
+		WriteProblem(w, r, http.StatusNotFound, err.Error())
+		return "", nil, false
+	}
+	if err != nil {
+		h.writeInternalProblem(w, r, err)
+		return "", nil, false
+	}
+	return sessionID, mvc, true
+}
+
+// apiTodoOf finds the todo with the {id} URL parameter in mvc. If the request
+// has an If-Match header, the todo must still have the version it names.
+func apiTodoOf(w http.ResponseWriter, r *http.Request, mvc *todocomponents.TodoMVC) (*todocomponents.Todo, bool) {
+	todo, _ := mvc.Find(chi.URLParam(r, "id"))
+	if todo == nil {
+		WriteProblem(w, r, http.StatusNotFound, "todo not found")
+		return nil, false
+	}
+	if match := r.Header.Get("If-Match"); match != "" && match != "*" && match != todoETag(todo) {
+		WriteProblem(w, r, http.StatusPreconditionFailed, fmt.Sprintf("the todo is at version %d", todo.Version))
+		return nil, false
+	}
+	return todo, true
+}
+
+func todoETag(todo *todocomponents.Todo) string {
+	return `"` + strconv.FormatInt(todo.Version, 10) + `"`
+}
+
+// queryInt reads a non-negative integer query parameter, or fallback if it is absent.
+func queryInt(w http.ResponseWriter, r *http.Request, name string, fallback int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		WriteProblem(w, r, http.StatusBadRequest, fmt.Sprintf("%s must be a non-negative integer", name))
+		return 0, false
+	}
+	return n, true
+}
+
+// todoDetails validates the details of a todo sent to the API. The tags are
+// appended to the text as #tags, as the UI does when editing a todo.
+func todoDetails(text string, tags []string, dueDate string, priority domain.Priority, recurrence string) (services.TodoDetails, error) {
+	if strings.TrimSpace(text) == "" {
+		return services.TodoDetails{}, errors.New("text is required")
+	}
+	if !priority.Valid() {
+		return services.TodoDetails{}, fmt.Errorf("invalid priority %d", priority)
+	}
+	details := services.TodoDetails{Text: text, Priority: priority}
+	for _, tag := range tags {
+		if !services.ValidTag(tag) {
+			return services.TodoDetails{}, fmt.Errorf("invalid tag %q: tags consist of letters, digits, dashes and underscores", tag)
+		}
+		details.Text += " #" + strings.TrimPrefix(tag, "#")
+	}
+	if dueDate != "" {
+		due, err := time.Parse(todocomponents.DueDateLayout, dueDate)
+		if err != nil {
+			return services.TodoDetails{}, fmt.Errorf("invalid due date %q", dueDate)
+		}
+		details.DueAt = &due
+	}
+	var err error
+	if details.Recurrence, err = domain.ParseRecurrence(recurrence); err != nil {
+		return services.TodoDetails{}, err
+	}
+	return details, nil
+}
+
+// APIListTodos returns a page of the todos of the list, each top-level todo
+// followed by its subtasks, filtered by the mode and tag query parameters.
+func (h *Handlers) APIListTodos(w http.ResponseWriter, r *http.Request) {
+	_, mvc, ok := h.apiMVC(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	mode := todocomponents.TodoViewModeAll
+	if name := query.Get("mode"); name != "" {
+		mode = todocomponents.TodoViewModeLast
+		for m := todocomponents.TodoViewModeAll; m < todocomponents.TodoViewModeLast; m++ {
+			if apiModeName(m) == name {
+				mode = m
+			}
+		}
+		if mode == todocomponents.TodoViewModeLast {
+			WriteProblem(w, r, http.StatusBadRequest, fmt.Sprintf("mode must be one of %s", strings.Join(apiModeNames(), ", ")))
+			return
+		}
+	}
+	limit, ok := queryInt(w, r, "limit", defaultPageSize)
+	if !ok {
+		return
+	}
+	if limit < 1 || limit > maxPageSize {
+		WriteProblem(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxPageSize))
+		return
+	}
+	offset, ok := queryInt(w, r, "offset", 0)
+	if !ok {
+		return
+	}
+
+	var todos []apiTodo
+	for _, todo := range mvc.Children("") {
+		for _, t := range append([]*todocomponents.Todo{todo}, mvc.Children(todo.ID)...) {
+			if mode.Shows(t) && t.HasTags(query["tag"]) {
+				todos = append(todos, newAPITodo(t))
+			}
+		}
+	}
+
+	page := apiTodoPage{
+		Items:  todos[min(offset, len(todos)):min(offset+limit, len(todos))],
+		Total:  len(todos),
+		Offset: offset,
+		Limit:  limit,
+	}
+	if page.Items == nil {
+		page.Items = []apiTodo{}
+	}
+	if offset+limit < len(todos) {
+		query.Set("offset", strconv.Itoa(offset+limit))
+		page.Next = r.URL.Path + "?" + query.Encode()
+	}
+	writeAPIJSON(w, http.StatusOK, page)
+}
+
+// APIGetTodo returns a todo of the list.
+func (h *Handlers) APIGetTodo(w http.ResponseWriter, r *http.Request) {
+	_, mvc, ok := h.apiMVC(w, r)
+	if !ok {
+		return
+	}
+	todo, ok := apiTodoOf(w, r, mvc)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("ETag", todoETag(todo))
+	writeAPIJSON(w, http.StatusOK, newAPITodo(todo))
+}
+
+// APICreateTodo creates a todo at the end of the list, or a subtask at the
+// end of its parent's subtasks.
+func (h *Handlers) APICreateTodo(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.apiMVC(w, r)
+	if !ok {
+		return
+	}
+	var body apiNewTodo
+	if !readAPIJSON(w, r, &body) {
+		return
+	}
+	details, err := todoDetails(body.Text, body.Tags, body.DueDate, body.Priority, body.Recurrence)
+	if err != nil {
+		WriteProblem(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	details.Completed = &body.Completed
+
+	var todo *todocomponents.Todo
+	if body.ParentID != "" {
+		todo, err = h.todoService.AddSubtask(r.Context(), sessionID, mvc, body.ParentID, details)
+	} else {
+		todo, err = h.todoService.EditTodo(r.Context(), sessionID, mvc, "", details)
+	}
+	if err != nil {
+		h.writeServiceProblem(w, r, err)
+		return
+	}
+	if todo == nil {
+		WriteProblem(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("unknown parent %q", body.ParentID))
+		return
+	}
+
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+todo.ID)
+	w.Header().Set("ETag", todoETag(todo))
+	writeAPIJSON(w, http.StatusCreated, newAPITodo(todo))
+}
+
+// APIUpdateTodo changes the fields of a todo that the body sets, all in one
+// change. A body that sets no field is rejected.
+func (h *Handlers) APIUpdateTodo(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.apiMVC(w, r)
+	if !ok {
+		return
+	}
+	todo, ok := apiTodoOf(w, r, mvc)
+	if !ok {
+		return
+	}
+	var patch apiTodoPatch
+	if !readAPIJSON(w, r, &patch) {
+		return
+	}
+	if patch == (apiTodoPatch{}) {
+		WriteProblem(w, r, http.StatusUnprocessableEntity, "the body sets no field to change")
+		return
+	}
+
+	text, tags, dueDate, priority, recurrence := todo.Text, todo.Tags, todo.DueDate(), todo.Priority, todo.Recurrence
+	if patch.Text != nil {
+		text = *patch.Text
+	}
+	if patch.Tags != nil {
+		tags = *patch.Tags
+	}
+	if patch.DueDate != nil {
+		dueDate = *patch.DueDate
+	}
+	if patch.Priority != nil {
+		priority = *patch.Priority
+	}
+	if patch.Recurrence != nil {
+		recurrence = *patch.Recurrence
+	}
+	details, err := todoDetails(text, tags, dueDate, priority, recurrence)
+	if err != nil {
+		WriteProblem(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	details.Completed = patch.Completed
+	if _, err := h.todoService.EditTodo(r.Context(), sessionID, mvc, todo.ID, details); err != nil {
+		h.writeServiceProblem(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", todoETag(todo))
+	writeAPIJSON(w, http.StatusOK, newAPITodo(todo))
+}
+
+// APIDeleteTodo deletes a todo with its subtasks.
+func (h *Handlers) APIDeleteTodo(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.apiMVC(w, r)
+	if !ok {
+		return
+	}
+	todo, ok := apiTodoOf(w, r, mvc)
+	if !ok {
+		return
+	}
+
+	if err := h.todoService.DeleteTodo(r.Context(), sessionID, mvc, todo.ID); err != nil {
+		h.writeServiceProblem(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
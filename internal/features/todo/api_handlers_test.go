@@ -0,0 +1,303 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/csrf"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newAPIClient serves the JSON API under /api/v1, like the todo routes do.
+func newAPIClient(t *testing.T) *testClient {
+	t.Helper()
+	c := newTestClient(t, func(h *Handlers, r chi.Router) error {
+		var err error
+		r.Route("/api/v1", func(v1Router chi.Router) {
+			err = h.setupAPIRoutes(v1Router)
+		})
+		return err
+	})
+	// The first request starts the session and hands out its CSRF token.
+	if resp, _ := c.do(http.MethodGet, "/api/v1/todos", nil, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/v1/todos = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	return c
+}
+
+// createTodo creates a todo in the default list through the API.
+func (c *testClient) createTodo(body apiNewTodo) apiTodo {
+	c.t.Helper()
+	resp, data := c.do(http.MethodPost, "/api/v1/todos", body, nil)
+	if resp.StatusCode != http.StatusCreated {
+		c.t.Fatalf("creating %+v = %d %s, want %d", body, resp.StatusCode, data, http.StatusCreated)
+	}
+	var todo apiTodo
+	decodeJSON(c.t, data, &todo)
+	return todo
+}
+
+// listTodos returns a page of the todos of the default list.
+func (c *testClient) listTodos(query string) apiTodoPage {
+	c.t.Helper()
+	resp, data := c.do(http.MethodGet, "/api/v1/todos?"+query, nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		c.t.Fatalf("listing todos with %q = %d %s, want %d", query, resp.StatusCode, data, http.StatusOK)
+	}
+	var page apiTodoPage
+	decodeJSON(c.t, data, &page)
+	return page
+}
+
+func decodeJSON(t *testing.T, data []byte, v any) {
+	t.Helper()
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+}
+
+// assertProblem checks that a response is an RFC 7807 problem detail of the
+// given status for path, whose detail contains detail.
+func assertProblem(t *testing.T, resp *http.Response, data []byte, status int, path, detail string) {
+	t.Helper()
+	if resp.StatusCode != status {
+		t.Fatalf("status %d %s, want %d", resp.StatusCode, data, status)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Content-Type %q, want application/problem+json", contentType)
+	}
+	var problem apiProblem
+	decodeJSON(t, data, &problem)
+	want := apiProblem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: problem.Detail, Instance: path}
+	if problem != want || !strings.Contains(problem.Detail, detail) {
+		t.Errorf("problem %+v, want %+v with a detail containing %q", problem, want, detail)
+	}
+}
+
+func TestAPIRequiresCSRFHeaderForWrites(t *testing.T) {
+	c := newAPIClient(t)
+	if c.token == "" {
+		t.Fatalf("GET did not hand out a %s header", csrf.HeaderName)
+	}
+	body := apiNewTodo{Text: "Wash up"}
+
+	for name, header := range map[string]http.Header{
+		"missing": {csrf.HeaderName: {""}},
+		"invalid": {csrf.HeaderName: {"not-the-token"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, data := c.do(http.MethodPost, "/api/v1/todos", body, header)
+			assertProblem(t, resp, data, http.StatusForbidden, "/api/v1/todos", csrf.HeaderName)
+		})
+	}
+	if page := c.listTodos("mode=all&limit=100"); slices.ContainsFunc(page.Items, func(todo apiTodo) bool { return todo.Text == body.Text }) {
+		t.Fatalf("a write without a valid token created a todo")
+	}
+
+	resp, data := c.do(http.MethodPost, "/api/v1/todos", body, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST with the token = %d %s, want %d", resp.StatusCode, data, http.StatusCreated)
+	}
+	var todo apiTodo
+	decodeJSON(t, data, &todo)
+	if location := resp.Header.Get("Location"); location != "/api/v1/todos/"+todo.ID {
+		t.Errorf("Location %q, want /api/v1/todos/%s", location, todo.ID)
+	}
+	if etag := resp.Header.Get("ETag"); etag != `"1"` {
+		t.Errorf("ETag %q, want \"1\"", etag)
+	}
+}
+
+func TestAPIListTodosPaginates(t *testing.T) {
+	c := newAPIClient(t)
+	for i := range 5 {
+		c.createTodo(apiNewTodo{Text: fmt.Sprintf("Todo %d", i)})
+	}
+	all := c.listTodos("limit=100")
+	if all.Total != len(all.Items) || all.Total < 5 || all.Next != "" {
+		t.Fatalf("one page of all todos %+v, want at least 5 todos and no next page", all)
+	}
+
+	var paged []apiTodo
+	next := "/api/v1/todos?limit=2"
+	for pages := 0; next != ""; pages++ {
+		if pages > all.Total {
+			t.Fatalf("pages do not end")
+		}
+		_, query, _ := strings.Cut(next, "?")
+		page := c.listTodos(query)
+		if page.Total != all.Total || page.Limit != 2 || page.Offset != len(paged) || len(page.Items) > 2 {
+			t.Fatalf("page %+v after %d todos, want up to 2 of %d todos", page, len(paged), all.Total)
+		}
+		paged = append(paged, page.Items...)
+		next = page.Next
+	}
+	if !reflect.DeepEqual(paged, all.Items) {
+		t.Errorf("pages hold %+v, want %+v", paged, all.Items)
+	}
+
+	if page := c.listTodos(fmt.Sprintf("offset=%d", all.Total+3)); len(page.Items) != 0 || page.Total != all.Total || page.Next != "" {
+		t.Errorf("page past the end %+v, want no todos", page)
+	}
+	for _, query := range []string{"limit=0", fmt.Sprintf("limit=%d", maxPageSize+1), "limit=two", "offset=-1"} {
+		resp, data := c.do(http.MethodGet, "/api/v1/todos?"+query, nil, nil)
+		assertProblem(t, resp, data, http.StatusBadRequest, "/api/v1/todos", "")
+	}
+}
+
+func TestAPIListTodosFiltersByMode(t *testing.T) {
+	c := newAPIClient(t)
+	open := c.createTodo(apiNewTodo{Text: "Open", Tags: []string{"home"}})
+	done := c.createTodo(apiNewTodo{Text: "Done", Completed: true, Tags: []string{"home"}})
+	urgent := c.createTodo(apiNewTodo{Text: "Urgent", Priority: 3})
+
+	tests := []struct {
+		query string
+		shows func(apiTodo) bool
+		want  []string
+		not   []string
+	}{
+		{query: "mode=all", shows: func(apiTodo) bool { return true }, want: []string{open.ID, done.ID, urgent.ID}},
+		{query: "mode=active", shows: func(todo apiTodo) bool { return !todo.Completed }, want: []string{open.ID, urgent.ID}, not: []string{done.ID}},
+		{query: "mode=completed", shows: func(todo apiTodo) bool { return todo.Completed }, want: []string{done.ID}, not: []string{open.ID, urgent.ID}},
+		{query: "mode=high-priority", shows: func(todo apiTodo) bool { return todo.Priority == 3 && !todo.Completed }, want: []string{urgent.ID}, not: []string{open.ID, done.ID}},
+		{query: "mode=active&tag=home", shows: func(todo apiTodo) bool { return !todo.Completed && slices.Contains(todo.Tags, "home") }, want: []string{open.ID}, not: []string{done.ID, urgent.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			page := c.listTodos(tt.query + "&limit=100")
+			var ids []string
+			for _, todo := range page.Items {
+				if !tt.shows(todo) {
+					t.Errorf("%+v listed, which the filter does not show", todo)
+				}
+				ids = append(ids, todo.ID)
+			}
+			for _, id := range tt.want {
+				if !slices.Contains(ids, id) {
+					t.Errorf("todo %s missing from %v", id, ids)
+				}
+			}
+			for _, id := range tt.not {
+				if slices.Contains(ids, id) {
+					t.Errorf("todo %s listed in %v", id, ids)
+				}
+			}
+		})
+	}
+
+	resp, data := c.do(http.MethodGet, "/api/v1/todos?mode=someday", nil, nil)
+	assertProblem(t, resp, data, http.StatusBadRequest, "/api/v1/todos", strings.Join(apiModeNames(), ", "))
+}
+
+func TestAPIProblems(t *testing.T) {
+	c := newAPIClient(t)
+	todo := c.createTodo(apiNewTodo{Text: "Wash up"})
+	path := "/api/v1/todos/" + todo.ID
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		header http.Header
+		status int
+		detail string
+	}{
+		{name: "unknown todo", method: http.MethodGet, path: "/api/v1/todos/nope", status: http.StatusNotFound, detail: "todo not found"},
+		{name: "unknown list", method: http.MethodGet, path: "/api/v1/lists/nope/todos", status: http.StatusNotFound},
+		{name: "unknown route", method: http.MethodGet, path: "/api/v1/nope", status: http.StatusNotFound},
+		{name: "unsupported method", method: http.MethodPut, path: path, body: apiTodoPatch{}, status: http.StatusMethodNotAllowed},
+		{name: "invalid JSON", method: http.MethodPost, path: "/api/v1/todos", body: json.RawMessage(`{"text": 1}`), status: http.StatusBadRequest, detail: "invalid JSON body"},
+		{name: "unknown field", method: http.MethodPost, path: "/api/v1/todos", body: map[string]any{"text": "Hoover", "owner": "me"}, status: http.StatusBadRequest, detail: "owner"},
+		{name: "empty text", method: http.MethodPost, path: "/api/v1/todos", body: apiNewTodo{Text: " "}, status: http.StatusUnprocessableEntity, detail: "text is required"},
+		{name: "invalid tag", method: http.MethodPost, path: "/api/v1/todos", body: apiNewTodo{Text: "Hoover", Tags: []string{"two words"}}, status: http.StatusUnprocessableEntity, detail: "invalid tag"},
+		{name: "invalid tag in patch", method: http.MethodPatch, path: path, body: map[string]any{"tags": []string{"#ok", "not#ok"}}, status: http.StatusUnprocessableEntity, detail: "invalid tag"},
+		{name: "invalid due date", method: http.MethodPatch, path: path, body: map[string]any{"dueDate": "tomorrow"}, status: http.StatusUnprocessableEntity, detail: "invalid due date"},
+		{name: "empty patch", method: http.MethodPatch, path: path, body: map[string]any{}, status: http.StatusUnprocessableEntity, detail: "no field"},
+		{name: "stale version", method: http.MethodPatch, path: path, body: map[string]any{"text": "Hoover"}, header: http.Header{"If-Match": {`"7"`}}, status: http.StatusPreconditionFailed, detail: "version 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, data := c.do(tt.method, tt.path, tt.body, tt.header)
+			assertProblem(t, resp, data, tt.status, tt.path, tt.detail)
+		})
+	}
+
+	resp, data := c.do(http.MethodGet, path, nil, nil)
+	var got apiTodo
+	decodeJSON(t, data, &got)
+	if resp.StatusCode != http.StatusOK || !reflect.DeepEqual(got, todo) {
+		t.Errorf("todo after rejected requests %d %+v, want it unchanged as %+v", resp.StatusCode, got, todo)
+	}
+}
+
+func TestAPIOpenAPIDocumentDescribesRoutes(t *testing.T) {
+	var routes *chi.Mux
+	c := newTestClient(t, func(h *Handlers, r chi.Router) error {
+		routes = chi.NewRouter()
+		if err := h.setupAPIRoutes(routes); err != nil {
+			return err
+		}
+		r.Mount("/api/v1", routes)
+		return nil
+	})
+	resp, data := c.do(http.MethodGet, "/api/v1/openapi.json", nil, nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("GET openapi.json = %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	var document struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	decodeJSON(t, data, &document)
+	if document.OpenAPI != "3.1.0" {
+		t.Errorf("openapi %q, want 3.1.0", document.OpenAPI)
+	}
+
+	// Every route but the document itself is described, and nothing else.
+	var served, described []string
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route = "/api/v1" + strings.TrimSuffix(route, "/"); !strings.HasSuffix(route, "/openapi.json") {
+			served = append(served, method+" "+route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("chi.Walk: %v", err)
+	}
+	operationIDs := make(map[string]bool)
+	for path, operations := range document.Paths {
+		for method, operation := range operations {
+			described = append(described, strings.ToUpper(method)+" "+path)
+			id, _ := operation["operationId"].(string)
+			if id == "" || operationIDs[id] {
+				t.Errorf("%s %s has operationId %q, want a unique one", method, path, id)
+			}
+			operationIDs[id] = true
+		}
+	}
+	slices.Sort(served)
+	slices.Sort(described)
+	if !slices.Equal(served, described) {
+		t.Errorf("document describes %v, want the served routes %v", described, served)
+	}
+
+	// Every schema referred to is defined.
+	for _, ref := range strings.Split(string(data), `"$ref":"`)[1:] {
+		ref, _, _ = strings.Cut(ref, `"`)
+		name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+		if _, defined := document.Components.Schemas[name]; !ok || !defined {
+			t.Errorf("reference %q to an undefined schema", ref)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package todo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// CalendarFeed serves the iCalendar feed with the {token} URL parameter. The
+// ETag is a hash of the feed, so that calendar apps polling with
+// If-None-Match get a 304 without the body while the todos are unchanged.
+func (h *Handlers) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	feed, err := h.calendarService.Feed(r.Context(), chi.URLParam(r, "token"))
+	if errors.Is(err, services.ErrFeedNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(feed)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if _, err := w.Write(feed); err != nil {
+		h.logger.Error("failed to send calendar feed", "error", err)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing
+// weakly as RFC 9110 asks for.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ShowCalendarFeed shows the visitor the URL of their calendar feed,
+// creating the feed the first time.
+func (h *Handlers) ShowCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := h.calendarService.FeedToken(r.Context(), sessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.sendCalendarFeed(w, r, token)
+}
+
+// ResetCalendarFeed gives the visitor's calendar feed a new URL, for when
+// the old one leaked; calendars subscribed to the old URL stop updating.
+func (h *Handlers) ResetCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := h.calendarService.ResetFeedToken(r.Context(), sessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.sendCalendarFeed(w, r, token)
+}
+
+func (h *Handlers) sendCalendarFeed(w http.ResponseWriter, r *http.Request, token string) {
+	sse := datastar.NewSSE(w, r)
+	if err := sse.PatchElementTempl(todocomponents.CalendarFeed(todocomponents.CalendarFeedPath(token))); err != nil {
+		h.logger.Error("failed to send calendar feed link", "error", err)
+	}
+}
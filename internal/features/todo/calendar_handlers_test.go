@@ -0,0 +1,74 @@
+package todo
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCalendarFeedAnswersUnchangedFeedsWithNotModified(t *testing.T) {
+	var h *Handlers
+	c := newTestClient(t, func(handlers *Handlers, r chi.Router) error {
+		h = handlers
+		r.Get("/calendar/{token}.ics", h.CalendarFeed)
+		return nil
+	})
+	ctx := context.Background()
+	const member = "member"
+	list, err := h.listService.CreateList(ctx, member, "Chores")
+	if err != nil {
+		t.Fatalf("CreateList: %v", err)
+	}
+	mvc, err := h.todoService.GetMVC(ctx, member, list.ID)
+	if err != nil {
+		t.Fatalf("GetMVC: %v", err)
+	}
+	due := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+	todo, err := h.todoService.EditTodo(ctx, member, mvc, "", services.TodoDetails{Text: "Wash up", DueAt: &due})
+	if err != nil {
+		t.Fatalf("EditTodo: %v", err)
+	}
+	token, err := h.calendarService.FeedToken(ctx, member)
+	if err != nil {
+		t.Fatalf("FeedToken: %v", err)
+	}
+	path := "/calendar/" + token + ".ics"
+
+	resp, body := c.do(http.MethodGet, path, nil, nil)
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" || !strings.HasPrefix(string(body), "BEGIN:VCALENDAR") {
+		t.Fatalf("GET = %d with ETag %q and body %q, want the feed with an ETag", resp.StatusCode, etag, body)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/calendar; charset=utf-8" {
+		t.Errorf("Content-Type %q, want text/calendar; charset=utf-8", contentType)
+	}
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		resp, body := c.do(http.MethodGet, path, nil, http.Header{"If-None-Match": {ifNoneMatch}})
+		if resp.StatusCode != http.StatusNotModified || len(body) != 0 || resp.Header.Get("ETag") != etag {
+			t.Errorf("If-None-Match %s = %d with ETag %q and %d bytes, want 304 with the same ETag and no body", ifNoneMatch, resp.StatusCode, resp.Header.Get("ETag"), len(body))
+		}
+	}
+	if resp, _ := c.do(http.MethodGet, path, nil, http.Header{"If-None-Match": {`"other"`}}); resp.StatusCode != http.StatusOK {
+		t.Errorf("If-None-Match another ETag = %d, want 200", resp.StatusCode)
+	}
+
+	// Once a todo changes, the old ETag no longer matches.
+	if _, err := h.todoService.EditTodo(ctx, member, mvc, todo.ID, services.TodoDetails{Text: "Hoover", DueAt: &due}); err != nil {
+		t.Fatalf("EditTodo: %v", err)
+	}
+	resp, body = c.do(http.MethodGet, path, nil, http.Header{"If-None-Match": {etag}})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag || !strings.Contains(string(body), "SUMMARY:Hoover") {
+		t.Errorf("changed feed = %d with ETag %q and body %q, want the new feed with a new ETag", resp.StatusCode, resp.Header.Get("ETag"), body)
+	}
+
+	if resp, _ := c.do(http.MethodGet, "/calendar/unknown.ics", nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown feed = %d, want 404", resp.StatusCode)
+	}
+}
@@ -3,51 +3,139 @@ package todo
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
 	commoncomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/common/components"
 	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
 	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/pages"
 	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/services"
-	"github.com/yacobolo/datastar-go-blueprint/internal/platform/pubsub"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/session"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/google/uuid"
-	"github.com/gorilla/sessions"
-	"github.com/nats-io/nats.go"
 	"github.com/starfederation/datastar-go/datastar"
 )
 
-// RequireSession retrieves or creates a session ID for the current request.
-func RequireSession(store sessions.Store, w http.ResponseWriter, r *http.Request) (string, bool) {
-	sess, err := store.Get(r, "connections")
+// RequireSession returns the ID the current visitor's todos are stored under,
+// as resolved by the session middleware.
+func RequireSession(w http.ResponseWriter, r *http.Request) (string, bool) {
+	identity, ok := session.Require(w, r)
+	if !ok {
+		return "", false
+	}
+	return identity.OwnerID(), true
+}
+
+// RequireIntParam extracts and parses an integer URL parameter.
+func RequireIntParam(w http.ResponseWriter, r *http.Request, param string) (int, bool) {
+	val, err := strconv.Atoi(chi.URLParam(r, param))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return 0, false
+	}
+	return val, true
+}
+
+// RequireList resolves the {listID} URL parameter to a list of the current
+// visitor. Routes without the parameter address the visitor's default list.
+func (h *Handlers) RequireList(w http.ResponseWriter, r *http.Request, sessionID string) (string, bool) {
+	if listID := chi.URLParam(r, "listID"); listID != "" {
+		return listID, true
+	}
+
+	list, err := h.listService.DefaultList(r.Context(), sessionID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return "", false
 	}
+	return list.ID, true
+}
 
-	id, ok := sess.Values["id"].(string)
+// RequireMVC loads the todo state of the list addressed by the request as
+// seen by the current visitor, replying 404 if the visitor is not a member.
+func (h *Handlers) RequireMVC(w http.ResponseWriter, r *http.Request) (string, *todocomponents.TodoMVC, bool) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return "", nil, false
+	}
+	listID, ok := h.RequireList(w, r, sessionID)
 	if !ok {
-		id = uuid.New().String()
-		sess.Values["id"] = id
-		if err := sess.Save(r, w); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		return "", nil, false
+	}
+
+	mvc, err := h.todoService.GetMVC(r.Context(), sessionID, listID)
+	if errors.Is(err, services.ErrListNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return "", nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return "", nil, false
+	}
+	return sessionID, mvc, true
+}
+
+// RequireTodoID resolves the {id} URL parameter to the ID of a todo in mvc.
+// todocomponents.NewTodoPath (or any other negative number) resolves to the
+// empty ID, which addresses all todos or a new one. Non-negative integers are
+// indexes for clients still using the index-based URLs, which listed the
+// top-level todos newest first.
+func RequireTodoID(w http.ResponseWriter, r *http.Request, mvc *todocomponents.TodoMVC) (string, bool) {
+	param := chi.URLParam(r, "id")
+	if idx, err := strconv.Atoi(param); err == nil {
+		if idx < 0 {
+			return "", true
+		}
+		todos := legacyOrder(mvc)
+		if idx >= len(todos) {
+			http.Error(w, "todo index out of range", http.StatusNotFound)
 			return "", false
 		}
+		return todos[idx].ID, true
 	}
-	return id, true
+
+	if todo, _ := mvc.Find(param); todo == nil {
+		http.Error(w, "todo not found", http.StatusNotFound)
+		return "", false
+	}
+	return param, true
 }
 
-// RequireIntParam extracts and parses an integer URL parameter.
-func RequireIntParam(w http.ResponseWriter, r *http.Request, param string) (int, bool) {
-	val, err := strconv.Atoi(chi.URLParam(r, param))
+// legacyOrder returns the top-level todos of mvc in the order clients using
+// index-based URLs listed them: newest first.
+func legacyOrder(mvc *todocomponents.TodoMVC) []*todocomponents.Todo {
+	todos := mvc.Children("")
+	slices.SortStableFunc(todos, func(a, b *todocomponents.Todo) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return todos
+}
+
+// RequireClientVersion applies the optional "version" query parameter to the
+// todo with the given ID, so that the service compares against the version the
+// client last rendered rather than the one just loaded from the database.
+func RequireClientVersion(w http.ResponseWriter, r *http.Request, mvc *todocomponents.TodoMVC, id string) bool {
+	raw := r.URL.Query().Get("version")
+	if raw == "" || id == "" {
+		return true
+	}
+
+	version, err := strconv.ParseInt(raw, 10, 64)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
-		return 0, false
+		return false
 	}
-	return val, true
+	if todo, _ := mvc.Find(id); todo != nil {
+		todo.Version = version
+	}
+	return true
 }
 
 // LogConsoleError sends an error to the browser console via SSE.
@@ -59,169 +147,427 @@ func (h *Handlers) LogConsoleError(sse *datastar.ServerSentEventGenerator, err e
 
 // Handlers holds dependencies for todo HTTP handlers.
 type Handlers struct {
-	logger       *slog.Logger
-	todoService  *services.TodoService
-	nats         *nats.Conn
-	sessionStore sessions.Store
+	logger          *slog.Logger
+	todoService     *services.TodoService
+	listService     *services.ListService
+	activityService *services.ActivityService
+	calendarService *services.CalendarService
+	events          domain.EventSubscriber
+	presence        domain.Presence
 }
 
 // NewHandlers creates a new Handlers instance with the given dependencies.
-func NewHandlers(logger *slog.Logger, todoService *services.TodoService, nats *nats.Conn, sessionStore sessions.Store) *Handlers {
+func NewHandlers(logger *slog.Logger, todoService *services.TodoService, listService *services.ListService, activityService *services.ActivityService, calendarService *services.CalendarService, events domain.EventSubscriber, presence domain.Presence) *Handlers {
 	return &Handlers{
-		logger:       logger,
-		todoService:  todoService,
-		nats:         nats,
-		sessionStore: sessionStore,
+		logger:          logger,
+		todoService:     todoService,
+		listService:     listService,
+		activityService: activityService,
+		calendarService: calendarService,
+		events:          events,
+		presence:        presence,
 	}
 }
 
-// subject returns the NATS subject for a session
-func subject(sessionID string) string {
-	return "todos.updates." + sessionID
+// IndexPage sends the visitor to their default list
+func (h *Handlers) IndexPage(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+	listID, ok := h.RequireList(w, r, sessionID)
+	if !ok {
+		return
+	}
+	http.Redirect(w, r, commoncomponents.ListPath(listID), http.StatusSeeOther)
 }
 
-// IndexPage renders the initial page
-func (h *Handlers) IndexPage(w http.ResponseWriter, r *http.Request) {
-	if err := pages.IndexPage("Datastar Go Blueprint").Render(r.Context(), w); err != nil {
+// ListPage renders the page of a list the visitor is a member of
+func (h *Handlers) ListPage(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+	listID, ok := h.RequireList(w, r, sessionID)
+	if !ok {
+		return
+	}
+
+	if _, err := h.listService.Role(r.Context(), listID, sessionID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrListNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	nav, err := h.nav(r.Context(), sessionID, listID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctx := commoncomponents.WithNav(r.Context(), nav)
+	if err := pages.IndexPage("Datastar Go Blueprint", listID).Render(ctx, w); err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 	}
 }
 
-// TodosUpdates is the long-running SSE endpoint that pushes real-time updates
+// TodosUpdates is the long-running SSE endpoint that pushes real-time updates.
+// It follows the domain events of the list, which every member viewing it
+// shares, of the session's own UI state, and of the member's reminders across
+// all lists, and patches the parts of the view they change. Each SSE event carries the sequence of its batch as the
+// ID. A reconnecting client sends it back as Last-Event-ID and gets the
+// updates it missed replayed before the endpoint goes live again.
+//
+// While the stream is open the connection is present on the list: the other
+// members see its avatar and which todo it is editing, and it sees theirs.
+// Presence patches carry no event ID, as they are not part of the event log.
 func (h *Handlers) TodosUpdates(w http.ResponseWriter, r *http.Request) {
-	sessionID, ok := RequireSession(h.sessionStore, w, r)
+	sessionID, mvc, ok := h.RequireMVC(w, r)
 	if !ok {
 		return
 	}
+	listID := mvc.ListID
+	identity, _ := session.FromContext(r.Context())
 
 	sse := datastar.NewSSE(w, r)
 	ctx := r.Context()
 
-	// Send initial state
-	if err := h.refreshTodos(ctx, sse, sessionID); err != nil {
+	topics := []string{domain.ListTopic(listID), domain.ViewTopic(listID, sessionID), domain.MemberTopic(sessionID)}
+	first, last, err := h.events.Position(ctx, topics)
+	if err != nil {
 		h.LogConsoleError(sse, err)
 		return
 	}
 
-	// Subscribe to NATS updates for this session
-	msgChan := make(chan *nats.Msg, 64)
-	sub, err := h.nats.ChanSubscribe(subject(sessionID), msgChan)
+	// Resume after the last event the client saw if everything published to
+	// its topics since then is still stored; otherwise start over with a full
+	// render.
+	afterSeq, resume := lastEventID(r)
+	if !resume || afterSeq+1 < first || afterSeq > last {
+		afterSeq, resume = last, false
+	}
+
+	// Subscribe before rendering so that no update is lost in between
+	batches, err := h.events.Subscribe(ctx, topics, afterSeq)
+	if err != nil {
+		h.LogConsoleError(sse, err)
+		return
+	}
+
+	// Stay present until the client disconnects and ctx is cancelled
+	connectionID := rand.Text()
+	if err := h.presence.Join(ctx, listID, domain.Viewer{
+		ConnectionID: connectionID,
+		MemberID:     sessionID,
+		Name:         identity.DisplayName(),
+		EditingID:    mvc.EditingID,
+	}); err != nil {
+		h.LogConsoleError(sse, err)
+		return
+	}
+	presence, err := h.presence.Watch(ctx, listID)
 	if err != nil {
 		h.LogConsoleError(sse, err)
 		return
 	}
-	defer func() { _ = sub.Unsubscribe() }()
+	var viewers []domain.Viewer
+
+	// Send initial state
+	if !resume {
+		if err := h.refreshTodos(ctx, sse, sessionID, listID, viewers, withEventID(afterSeq)); err != nil {
+			h.LogConsoleError(sse, err)
+			return
+		}
+	}
 
-	// Listen for updates
+	// Listen for updates, replaying missed ones first
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case natsMsg := <-msgChan:
-			updateMsg, err := pubsub.ParseUpdateMessage(natsMsg.Data)
-			if err != nil {
-				h.logger.Error("failed to parse update message", "error", err)
-				continue
+		case next := <-presence:
+			next = otherViewers(next, sessionID)
+			if err := h.patchPresence(ctx, sse, sessionID, listID, viewers, next); err != nil {
+				h.LogConsoleError(sse, err)
+				return
+			}
+			viewers = next
+		case batch := <-batches:
+			update := newViewUpdate(batch.Events, listID, sessionID)
+			eventID := withEventID(batch.Seq)
+
+			// A deleted list has nothing left to show, so send the client home
+			if update.deleted {
+				if err := sse.Redirect("/"); err != nil {
+					h.logger.Error("failed to redirect", "error", err)
+				}
+				return
+			}
+
+			if update.editing != nil {
+				if err := h.presence.SetEditing(ctx, listID, connectionID, *update.editing); err != nil {
+					h.logger.Warn("failed to update presence", "error", err)
+				}
 			}
 
-			// Refresh TODO list if requested
-			if updateMsg.RefreshTodos {
-				if err := h.refreshTodos(ctx, sse, sessionID); err != nil {
+			// Patch the parts of the TODO list that changed
+			if update.hasChanges() {
+				if err := h.patchTodos(ctx, sse, sessionID, listID, viewers, update, eventID); err != nil {
+					h.LogConsoleError(sse, err)
+					return
+				}
+			}
+
+			// Renaming or archiving the list changes its entry in the sidebar
+			if update.list {
+				if err := h.patchNav(ctx, sse, sessionID, listID, eventID); err != nil {
 					h.LogConsoleError(sse, err)
 					return
 				}
 			}
 
 			// Send toast if present
-			if updateMsg.Toast != nil {
-				toastComponent := commoncomponents.Toast(updateMsg.Toast.Message, updateMsg.Toast.Type)
+			if update.toast != nil {
+				var actions []commoncomponents.ToastAction
+				if update.toast.action != "" {
+					actions = append(actions, historyAction(listID, update.toast.action))
+				}
+				toastComponent := commoncomponents.Toast(update.toast.message, update.toast.kind, actions...)
 				if err := sse.PatchElementTempl(
 					toastComponent,
-					datastar.WithSelectorID("toast-container"),
+					datastar.WithSelectorID(commoncomponents.ToastContainerID),
 					datastar.WithModeAppend(),
+					eventID,
 				); err != nil {
 					h.logger.Error("failed to send toast", "error", err)
 				}
 			}
+
+			// Members who opted in also get a browser notification for reminders
+			if update.notification != nil {
+				if err := sse.ExecuteScript(notificationScript(*update.notification)); err != nil {
+					h.logger.Error("failed to send notification", "error", err)
+				}
+			}
 		}
 	}
 }
 
-// refreshTodos fetches current state and sends via SSE
-func (h *Handlers) refreshTodos(ctx context.Context, sse *datastar.ServerSentEventGenerator, sessionID string) error {
-	// Get MVC state from service
-	mvc, err := h.todoService.GetMVCBySessionID(ctx, sessionID)
+// historyAction returns the toast button that undoes or redoes the last
+// change of the session, for action "undo" or "redo".
+func historyAction(listID, action string) commoncomponents.ToastAction {
+	label := "Undo"
+	if action == "redo" {
+		label = "Redo"
+	}
+	return commoncomponents.ToastAction{Label: label, Path: todocomponents.HistoryPath(listID, action)}
+}
+
+// otherViewers returns the viewers that belong to members other than the
+// given one, whose own tabs are not worth pointing out to them.
+func otherViewers(viewers []domain.Viewer, memberID string) []domain.Viewer {
+	return slices.DeleteFunc(viewers, func(viewer domain.Viewer) bool {
+		return viewer.MemberID == memberID
+	})
+}
+
+// patchPresence replaces the avatars of the other viewers, and the list when
+// the todos they are editing changed.
+func (h *Handlers) patchPresence(ctx context.Context, sse *datastar.ServerSentEventGenerator, sessionID, listID string, prev, next []domain.Viewer) error {
+	if err := sse.PatchElementTempl(todocomponents.ListPresence(next)); err != nil {
+		return err
+	}
+	if slices.Equal(editingIDs(prev), editingIDs(next)) {
+		return nil
+	}
+
+	mvc, err := h.loadMVC(ctx, sessionID, listID, next)
 	if err != nil {
 		return err
 	}
+	if len(mvc.Todos) == 0 {
+		return nil
+	}
+	return sse.PatchElementTempl(todocomponents.TodoList(mvc))
+}
+
+// editingIDs returns what each viewer is editing, in viewer order.
+func editingIDs(viewers []domain.Viewer) []string {
+	ids := make([]string, len(viewers))
+	for i, viewer := range viewers {
+		ids[i] = viewer.MemberID + "/" + viewer.EditingID
+	}
+	return ids
+}
 
-	return sse.PatchElementTempl(todocomponents.TodosMVCView(mvc))
+// lastEventID returns the stream sequence sent by a reconnecting client.
+func lastEventID(r *http.Request) (uint64, bool) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+// withEventID tags a patch with the batch sequence it brings the client up to.
+func withEventID(seq uint64) datastar.PatchElementOption {
+	return datastar.WithPatchElementsEventID(strconv.FormatUint(seq, 10))
 }
 
-// notifyUpdate publishes a NATS message to trigger UI refresh
-func (h *Handlers) notifyUpdate(sessionID string, opts ...pubsub.NotifyOption) {
-	if err := pubsub.Notify(h.nats, subject(sessionID), opts...); err != nil {
-		h.logger.Error("failed to notify update", "error", err)
+// loadMVC fetches the current state of the list together with its viewers.
+func (h *Handlers) loadMVC(ctx context.Context, sessionID, listID string, viewers []domain.Viewer) (*todocomponents.TodoMVC, error) {
+	mvc, err := h.todoService.GetMVC(ctx, sessionID, listID)
+	if err != nil {
+		return nil, err
 	}
+	mvc.Viewers = viewers
+	return mvc, nil
 }
 
-// ResetTodos resets to default todos
-func (h *Handlers) ResetTodos(w http.ResponseWriter, r *http.Request) {
-	sessionID, ok := RequireSession(h.sessionStore, w, r)
-	if !ok {
-		return
+// refreshTodos fetches current state and sends via SSE
+func (h *Handlers) refreshTodos(ctx context.Context, sse *datastar.ServerSentEventGenerator, sessionID, listID string, viewers []domain.Viewer, opts ...datastar.PatchElementOption) error {
+	mvc, err := h.loadMVC(ctx, sessionID, listID, viewers)
+	if err != nil {
+		return err
 	}
 
-	_, mvc, err := h.todoService.GetSessionMVC(w, r)
+	return sse.PatchElementTempl(todocomponents.TodosMVCView(mvc), opts...)
+}
+
+// patchTodos sends the parts of the view named in u: single rows, the
+// footer and the filter buttons. It falls back to re-rendering the whole view
+// when asked to, or when the list itself appears or disappears.
+func (h *Handlers) patchTodos(ctx context.Context, sse *datastar.ServerSentEventGenerator, sessionID, listID string, viewers []domain.Viewer, u viewUpdate, opts ...datastar.PatchElementOption) error {
+	if u.refresh {
+		return h.refreshTodos(ctx, sse, sessionID, listID, viewers, opts...)
+	}
+
+	mvc, err := h.loadMVC(ctx, sessionID, listID, viewers)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
+	// The list, footer and toggle-all button are only rendered while there are todos.
+	if len(mvc.Todos) == 0 || len(mvc.Todos) == u.countChanges(todoAdded) {
+		return sse.PatchElementTempl(todocomponents.TodosMVCView(mvc), opts...)
+	}
+
+	if u.mode || u.order || len(u.todos) > 1 || slices.ContainsFunc(u.todos, func(change todoChange) bool {
+		return affectsNesting(mvc, change)
+	}) {
+		// Visibility or order of any row may have changed, so re-render the list as a whole.
+		if err := sse.PatchElementTempl(todocomponents.TodoList(mvc), opts...); err != nil {
+			return err
+		}
+	} else {
+		for _, change := range u.todos {
+			if err := patchTodoRow(sse, mvc, change, opts...); err != nil {
+				return err
+			}
+		}
+	}
+
+	if u.footer || u.mode {
+		return sse.PatchElementTempl(todocomponents.TodoFooter(mvc), opts...)
+	}
+	return nil
+}
+
+// affectsNesting reports whether a change touches more than the row of the
+// changed todo: a subtask also changes the progress shown by its parent, and
+// a new todo is followed by its own subtask input.
+func affectsNesting(mvc *todocomponents.TodoMVC, change todoChange) bool {
+	todo, _ := mvc.Find(change.id)
+	if todo == nil {
+		// A removed todo may have been a subtask.
+		return slices.ContainsFunc(mvc.Todos, func(t *todocomponents.Todo) bool { return t.ParentID != "" })
+	}
+	return todo.ParentID != "" || (change.kind == todoAdded && !mvc.ReadOnly())
+}
+
+// patchTodoRow adds, replaces or removes the row of a single changed todo.
+func patchTodoRow(sse *datastar.ServerSentEventGenerator, mvc *todocomponents.TodoMVC, change todoChange, opts ...datastar.PatchElementOption) error {
+	todo, _ := mvc.Find(change.id)
+	if todo == nil || !mvc.Visible(todo) {
+		if change.kind == todoAdded {
+			return nil
+		}
+		return sse.PatchElements("", append(opts,
+			datastar.WithSelectorID(todocomponents.TodoRowID(change.id)),
+			datastar.WithModeRemove(),
+		)...)
+	}
+
+	row := todocomponents.TodoRow(mvc, todo)
+	if change.kind == todoAdded {
+		return sse.PatchElementTempl(row, append(opts,
+			datastar.WithSelectorID(todocomponents.TodoListID),
+			datastar.WithModeAppend(),
+		)...)
+	}
+	if todo.ID == mvc.EditingID {
+		// The row is rendered as the edit input, which has a different element ID.
+		return sse.PatchElementTempl(todocomponents.TodoList(mvc), opts...)
+	}
+	return sse.PatchElementTempl(row, opts...)
+}
+
+// handleMutationError replies to a failed todo mutation. A version conflict
+// means another tab or member changed the data first: instead of overwriting
+// it, the service has published the conflict so the open views show a warning
+// toast and a fresh render of the current state.
+func (h *Handlers) handleMutationError(w http.ResponseWriter, err error) {
+	var conflict *domain.ConflictError
+	if errors.As(err, &conflict) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
 		return
 	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
 
-	h.todoService.ResetMVC(mvc)
-	if err := h.todoService.SaveMVC(r.Context(), sessionID, mvc); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// ResetTodos resets to default todos
+func (h *Handlers) ResetTodos(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
 		return
 	}
 
-	// Notify via NATS (triggers SSE push)
-	h.notifyUpdate(sessionID,
-		pubsub.WithRefresh(),
-		pubsub.WithToast("Todos reset", commoncomponents.ToastSuccess))
+	if err := h.todoService.ResetMVC(r.Context(), sessionID, mvc); err != nil {
+		h.handleMutationError(w, err)
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
 // CancelEdit cancels editing mode
 func (h *Handlers) CancelEdit(w http.ResponseWriter, r *http.Request) {
-	sessionID, ok := RequireSession(h.sessionStore, w, r)
+	sessionID, mvc, ok := h.RequireMVC(w, r)
 	if !ok {
 		return
 	}
 
-	_, mvc, err := h.todoService.GetSessionMVC(w, r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	h.todoService.CancelEditing(mvc)
-	if err := h.todoService.SaveMVC(r.Context(), sessionID, mvc); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.todoService.CancelEditing(r.Context(), sessionID, mvc); err != nil {
+		h.handleMutationError(w, err)
 		return
 	}
 
-	h.notifyUpdate(sessionID, pubsub.WithRefresh())
 	w.WriteHeader(http.StatusOK)
 }
 
 // SetMode changes the view filter mode
 func (h *Handlers) SetMode(w http.ResponseWriter, r *http.Request) {
-	sessionID, ok := RequireSession(h.sessionStore, w, r)
-	if !ok {
-		return
-	}
-
 	modeStr := chi.URLParam(r, "mode")
 	modeRaw, err := strconv.Atoi(modeStr)
 	if err != nil {
@@ -230,87 +576,263 @@ func (h *Handlers) SetMode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	mode := todocomponents.TodoViewMode(modeRaw)
-	if mode < todocomponents.TodoViewModeAll || mode > todocomponents.TodoViewModeCompleted {
+	if mode < todocomponents.TodoViewModeAll || mode >= todocomponents.TodoViewModeLast {
 		http.Error(w, "invalid mode", http.StatusBadRequest)
 		return
 	}
 
-	_, mvc, err := h.todoService.GetSessionMVC(w, r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
 		return
 	}
 
-	h.todoService.SetMode(mvc, mode)
-	if err := h.todoService.SaveMVC(r.Context(), sessionID, mvc); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.todoService.SetMode(r.Context(), sessionID, mvc, mode); err != nil {
+		h.handleMutationError(w, err)
 		return
 	}
 
-	h.notifyUpdate(sessionID, pubsub.WithRefresh())
 	w.WriteHeader(http.StatusOK)
 }
 
-// ToggleTodo toggles completion state
-func (h *Handlers) ToggleTodo(w http.ResponseWriter, r *http.Request) {
-	sessionID, ok := RequireSession(h.sessionStore, w, r)
+// SetTagFilter filters the todos by the tags in the tag query parameters,
+// on top of the view mode. Without tags the filter is cleared.
+func (h *Handlers) SetTagFilter(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.RequireMVC(w, r)
 	if !ok {
 		return
 	}
 
-	idx, ok := RequireIntParam(w, r, "idx")
+	if err := h.todoService.SetTagFilter(r.Context(), sessionID, mvc, r.URL.Query()["tag"]); err != nil {
+		h.handleMutationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SearchTodos streams the todos of the list matching the search signal,
+// replacing the previous results.
+func (h *Handlers) SearchTodos(w http.ResponseWriter, r *http.Request) {
+	type Store struct {
+		Search string `json:"search"`
+	}
+	store := &Store{}
+
+	if err := datastar.ReadSignals(r, store); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+	listID, ok := h.RequireList(w, r, sessionID)
 	if !ok {
 		return
 	}
 
-	_, mvc, err := h.todoService.GetSessionMVC(w, r)
+	results, err := h.todoService.Search(r.Context(), sessionID, listID, store.Search)
+	if errors.Is(err, services.ErrListNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	h.todoService.ToggleTodo(mvc, idx)
-	if err := h.todoService.SaveMVC(r.Context(), sessionID, mvc); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	sse := datastar.NewSSE(w, r)
+	if err := sse.PatchElementTempl(todocomponents.TodoSearchResults(store.Search, results)); err != nil {
+		h.logger.Error("failed to send search results", "error", err)
+	}
+}
+
+// ToggleTodo toggles completion state. With ?subtasks=true, completing a
+// todo also completes its open subtasks.
+func (h *Handlers) ToggleTodo(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := RequireTodoID(w, r, mvc)
+	if !ok {
+		return
+	}
+
+	if !RequireClientVersion(w, r, mvc, id) {
+		return
+	}
+
+	withSubtasks := r.URL.Query().Get("subtasks") == "true"
+	if err := h.todoService.ToggleTodo(r.Context(), sessionID, mvc, id, withSubtasks); err != nil {
+		h.handleMutationError(w, err)
 		return
 	}
 
-	h.notifyUpdate(sessionID, pubsub.WithRefresh())
 	w.WriteHeader(http.StatusOK)
 }
 
 // StartEdit enters edit mode for a todo
 func (h *Handlers) StartEdit(w http.ResponseWriter, r *http.Request) {
-	sessionID, ok := RequireSession(h.sessionStore, w, r)
+	sessionID, mvc, ok := h.RequireMVC(w, r)
 	if !ok {
 		return
 	}
 
-	idx, ok := RequireIntParam(w, r, "idx")
+	id, ok := RequireTodoID(w, r, mvc)
 	if !ok {
 		return
 	}
 
-	_, mvc, err := h.todoService.GetSessionMVC(w, r)
+	if err := h.todoService.StartEditing(r.Context(), sessionID, mvc, id); err != nil {
+		h.handleMutationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SaveEdit creates or updates a todo
+func (h *Handlers) SaveEdit(w http.ResponseWriter, r *http.Request) {
+	type Store struct {
+		Input      string `json:"input"`
+		DueAt      string `json:"dueAt"`
+		Priority   string `json:"priority"`
+		Recurrence string `json:"recurrence"`
+	}
+	store := &Store{}
+
+	if err := datastar.ReadSignals(r, store); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if store.Input == "" {
+		return
+	}
+
+	details, err := parseTodoDetails(store.Input, store.DueAt, store.Priority, store.Recurrence)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	h.todoService.StartEditing(mvc, idx)
-	if err := h.todoService.SaveMVC(r.Context(), sessionID, mvc); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := RequireTodoID(w, r, mvc)
+	if !ok {
+		return
+	}
+
+	if !RequireClientVersion(w, r, mvc, id) {
+		return
+	}
+
+	if _, err := h.todoService.EditTodo(r.Context(), sessionID, mvc, id, details); err != nil {
+		h.handleMutationError(w, err)
 		return
 	}
 
-	h.notifyUpdate(sessionID, pubsub.WithRefresh())
 	w.WriteHeader(http.StatusOK)
 }
 
-// SaveEdit creates or updates a todo
-func (h *Handlers) SaveEdit(w http.ResponseWriter, r *http.Request) {
+// parseTodoDetails reads the edit form signals. An empty due date, priority
+// or recurrence rule leaves the todo without one.
+func parseTodoDetails(text, dueAt, priority, recurrence string) (services.TodoDetails, error) {
+	details := services.TodoDetails{Text: text}
+	if dueAt != "" {
+		due, err := time.Parse(todocomponents.DueDateLayout, dueAt)
+		if err != nil {
+			return details, fmt.Errorf("invalid due date %q", dueAt)
+		}
+		details.DueAt = &due
+	}
+	if priority != "" {
+		p, err := strconv.Atoi(priority)
+		if err != nil || !domain.Priority(p).Valid() {
+			return details, fmt.Errorf("invalid priority %q", priority)
+		}
+		details.Priority = domain.Priority(p)
+	}
+	rule, err := domain.ParseRecurrence(recurrence)
+	if err != nil {
+		return details, err
+	}
+	details.Recurrence = rule
+	return details, nil
+}
+
+// MoveTodo moves a todo so that it follows the todo in the after query
+// parameter and precedes the one in the before query parameter. One of them
+// is enough; the other neighbour is taken from the current order.
+func (h *Handlers) MoveTodo(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := RequireTodoID(w, r, mvc)
+	if !ok {
+		return
+	}
+
+	if !RequireClientVersion(w, r, mvc, id) {
+		return
+	}
+
+	query := r.URL.Query()
+	err := h.todoService.MoveTodo(r.Context(), sessionID, mvc, id, query.Get("after"), query.Get("before"))
+	if errors.Is(err, services.ErrInvalidMove) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.handleMutationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// UndoTodos reverts the last change the session made to the todos of the list.
+func (h *Handlers) UndoTodos(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.todoService.Undo(r.Context(), sessionID, mvc); err != nil {
+		h.handleMutationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RedoTodos applies the change the session undid last again.
+func (h *Handlers) RedoTodos(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.todoService.Redo(r.Context(), sessionID, mvc); err != nil {
+		h.handleMutationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// AddSubtask creates a subtask of a todo from the subtask input signal.
+func (h *Handlers) AddSubtask(w http.ResponseWriter, r *http.Request) {
 	type Store struct {
-		Input string `json:"input"`
+		SubtaskInput string `json:"subtaskInput"`
 	}
 	store := &Store{}
 
@@ -319,71 +841,112 @@ func (h *Handlers) SaveEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if store.Input == "" {
+	if store.SubtaskInput == "" {
 		return
 	}
 
-	sessionID, ok := RequireSession(h.sessionStore, w, r)
+	details, err := parseTodoDetails(store.SubtaskInput, "", "", "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessionID, mvc, ok := h.RequireMVC(w, r)
 	if !ok {
 		return
 	}
 
-	idx, ok := RequireIntParam(w, r, "idx")
+	id, ok := RequireTodoID(w, r, mvc)
 	if !ok {
 		return
 	}
 
-	_, mvc, err := h.todoService.GetSessionMVC(w, r)
+	_, err = h.todoService.AddSubtask(r.Context(), sessionID, mvc, id, details)
+	if errors.Is(err, services.ErrNestedSubtask) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.handleMutationError(w, err)
 		return
 	}
 
-	h.todoService.EditTodo(mvc, idx, store.Input)
-	if err := h.todoService.SaveMVC(r.Context(), sessionID, mvc); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	w.WriteHeader(http.StatusOK)
+}
+
+// PromoteTodo turns a subtask into a top-level todo.
+func (h *Handlers) PromoteTodo(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := RequireTodoID(w, r, mvc)
+	if !ok {
 		return
 	}
 
-	// Notify via NATS
-	toastMsg := "Todo updated"
-	if idx < 0 {
-		toastMsg = "Todo created"
+	if !RequireClientVersion(w, r, mvc, id) {
+		return
+	}
+
+	if err := h.todoService.PromoteTodo(r.Context(), sessionID, mvc, id); err != nil {
+		h.handleMutationError(w, err)
+		return
 	}
-	h.notifyUpdate(sessionID,
-		pubsub.WithRefresh(),
-		pubsub.WithToast(toastMsg, commoncomponents.ToastSuccess))
 
 	w.WriteHeader(http.StatusOK)
 }
 
-// DeleteTodo removes a todo
-func (h *Handlers) DeleteTodo(w http.ResponseWriter, r *http.Request) {
-	sessionID, ok := RequireSession(h.sessionStore, w, r)
+// DemoteTodo turns a todo into a subtask of the todo before it.
+func (h *Handlers) DemoteTodo(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.RequireMVC(w, r)
 	if !ok {
 		return
 	}
 
-	idx, ok := RequireIntParam(w, r, "idx")
+	id, ok := RequireTodoID(w, r, mvc)
 	if !ok {
 		return
 	}
 
-	_, mvc, err := h.todoService.GetSessionMVC(w, r)
+	if !RequireClientVersion(w, r, mvc, id) {
+		return
+	}
+
+	err := h.todoService.DemoteTodo(r.Context(), sessionID, mvc, id)
+	if errors.Is(err, services.ErrNestedSubtask) || errors.Is(err, services.ErrInvalidMove) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.handleMutationError(w, err)
 		return
 	}
 
-	h.todoService.DeleteTodo(mvc, idx)
-	if err := h.todoService.SaveMVC(r.Context(), sessionID, mvc); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteTodo removes a todo
+func (h *Handlers) DeleteTodo(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := RequireTodoID(w, r, mvc)
+	if !ok {
 		return
 	}
 
-	h.notifyUpdate(sessionID,
-		pubsub.WithRefresh(),
-		pubsub.WithToast("Todo deleted", commoncomponents.ToastSuccess))
+	if !RequireClientVersion(w, r, mvc, id) {
+		return
+	}
+
+	if err := h.todoService.DeleteTodo(r.Context(), sessionID, mvc, id); err != nil {
+		h.handleMutationError(w, err)
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 }
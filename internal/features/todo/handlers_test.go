@@ -0,0 +1,164 @@
+package todo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/services"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/clock"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/csrf"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/pubsub"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/session"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/sessions"
+)
+
+// testClient is a browser-like client of a test server: it keeps the
+// session cookie, and sends back the CSRF token of the last response.
+type testClient struct {
+	t      *testing.T
+	server *httptest.Server
+	client *http.Client
+	token  string
+}
+
+// newTestClient serves the routes that setup adds for handlers wired to an
+// in-memory database, behind the session and CSRF middleware like the
+// router does, and returns a client of them.
+func newTestClient(t *testing.T, setup func(h *Handlers, r chi.Router) error) *testClient {
+	t.Helper()
+	st, err := store.Open(":memory:")
+	if err != nil {
+		t.Fatalf("store.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	todoRepo := store.NewTodoRepository(st)
+	tagRepo := store.NewTagRepository(st)
+	historyRepo := store.NewHistoryRepository(st)
+	auditRepo := store.NewAuditRepository(st)
+	listRepo := store.NewListRepository(st)
+	userRepo := store.NewUserRepository(st)
+	bus := pubsub.NewMemoryEventBus()
+	logger := slog.New(slog.DiscardHandler)
+	h := NewHandlers(logger,
+		services.NewTodoService(st, todoRepo, tagRepo, historyRepo, auditRepo, store.NewReminderRepository(st), listRepo, store.NewSessionRepository(st), bus, clock.System{}),
+		services.NewListService(st, listRepo, todoRepo, tagRepo, historyRepo, auditRepo, bus),
+		services.NewActivityService(auditRepo, listRepo, userRepo),
+		services.NewCalendarService(store.NewCalendarRepository(st), todoRepo),
+		bus, nil)
+
+	router := chi.NewRouter()
+	router.Use(session.Middleware(sessions.NewCookieStore([]byte("test-session-secret")), userRepo, logger))
+	router.Use(csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteProblem(w, r, http.StatusForbidden, "missing or invalid "+csrf.HeaderName+" header")
+	})))
+	if err := setup(h, router); err != nil {
+		t.Fatalf("setup routes: %v", err)
+	}
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New: %v", err)
+	}
+	return &testClient{t: t, server: server, client: &http.Client{Jar: jar}}
+}
+
+// do sends a request with body encoded as JSON, if it is not nil, and
+// returns the response with its body read. The request carries the CSRF
+// token unless header sets the CSRF header itself.
+func (c *testClient) do(method, path string, body any, header http.Header) (*http.Response, []byte) {
+	c.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, c.server.URL+path, reader)
+	if err != nil {
+		c.t.Fatalf("NewRequest: %v", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if _, ok := req.Header[csrf.HeaderName]; !ok && c.token != "" {
+		req.Header.Set(csrf.HeaderName, c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("read %s %s: %v", method, path, err)
+	}
+	if token := resp.Header.Get(csrf.HeaderName); token != "" {
+		c.token = token
+	}
+	return resp, data
+}
+
+func TestRequireTodoIDResolvesLegacyIndexes(t *testing.T) {
+	created := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	mvc := &todocomponents.TodoMVC{Todos: []*todocomponents.Todo{
+		{ID: "oldest", Position: 1, CreatedAt: created},
+		{ID: "subtask", Position: 1, ParentID: "oldest", CreatedAt: created.Add(3 * time.Hour)},
+		{ID: "newest", Position: 2, CreatedAt: created.Add(2 * time.Hour)},
+		{ID: "middle", Position: 3, CreatedAt: created.Add(time.Hour)},
+	}}
+
+	tests := []struct {
+		param  string
+		want   string
+		status int
+	}{
+		{param: "0", want: "newest"},
+		{param: "1", want: "middle"},
+		{param: "2", want: "oldest"},
+		{param: "3", status: http.StatusNotFound},
+		{param: "-1", want: ""},
+		{param: "subtask", want: "subtask"},
+		{param: "unknown", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("id", tt.param)
+			r := httptest.NewRequest(http.MethodPost, "/api/todos/"+tt.param+"/toggle", nil)
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeContext))
+			w := httptest.NewRecorder()
+
+			id, ok := RequireTodoID(w, r, mvc)
+			if tt.status != 0 {
+				if ok || w.Code != tt.status {
+					t.Errorf("RequireTodoID = %q, %v with status %d, want status %d", id, ok, w.Code, tt.status)
+				}
+				return
+			}
+			if !ok || id != tt.want {
+				t.Errorf("RequireTodoID = %q, %v, want %q", id, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	commoncomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/common/components"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/pages"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// invitePath returns the path of the invite link with the given token.
+func invitePath(token string) string {
+	return "/invites/" + token
+}
+
+// CreateInvite creates an invite link for the list and shows it to the owner
+func (h *Handlers) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	type Store struct {
+		InviteRole string `json:"inviteRole"`
+	}
+	store := &Store{}
+
+	if err := datastar.ReadSignals(r, store); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+	listID, ok := h.RequireList(w, r, sessionID)
+	if !ok {
+		return
+	}
+
+	invite, err := h.listService.CreateInvite(r.Context(), listID, sessionID, domain.ListRole(store.InviteRole))
+	switch {
+	case errors.Is(err, services.ErrListNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sse := datastar.NewSSE(w, r)
+	if err := sse.PatchElementTempl(todocomponents.ListInvite(listID, invitePath(invite.Token))); err != nil {
+		h.logger.Error("failed to send invite link", "error", err)
+	}
+}
+
+// InvitePage shows the list an invite link is for and offers to join it
+func (h *Handlers) InvitePage(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	invite, list, err := h.listService.GetInvite(r.Context(), token)
+	page := pages.InvitePage("Join list", list.Name, invite.Role, token, "")
+	if errors.Is(err, services.ErrInvalidInvite) {
+		w.WriteHeader(http.StatusNotFound)
+		page = pages.InvitePage("Join list", "", "", token, err.Error())
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := page.Render(r.Context(), w); err != nil {
+		h.logger.Error("failed to render invite page", "error", err)
+	}
+}
+
+// JoinList adds the visitor to the list of an invite and opens it
+func (h *Handlers) JoinList(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+
+	listID, err := h.listService.JoinList(r.Context(), chi.URLParam(r, "token"), sessionID)
+	if errors.Is(err, services.ErrInvalidInvite) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sse := datastar.NewSSE(w, r)
+	if err := sse.Redirect(commoncomponents.ListPath(listID)); err != nil {
+		h.logger.Error("failed to redirect", "error", err)
+	}
+}
+
+// nav returns the sidebar navigation for a visitor looking at a list.
+func (h *Handlers) nav(ctx context.Context, sessionID, listID string) (commoncomponents.Nav, error) {
+	lists, err := h.listService.Lists(ctx, sessionID)
+	if err != nil {
+		return commoncomponents.Nav{}, err
+	}
+
+	nav := commoncomponents.Nav{Page: commoncomponents.PageList, ListID: listID}
+	for _, list := range lists {
+		nav.Lists = append(nav.Lists, commoncomponents.NavList{
+			ID:       list.ID,
+			Name:     list.Name,
+			Archived: list.ArchivedAt.Valid,
+		})
+	}
+	return nav, nil
+}
+
+// patchNav re-renders the list links of the desktop and mobile sidebars.
+func (h *Handlers) patchNav(ctx context.Context, sse *datastar.ServerSentEventGenerator, sessionID, listID string, opts ...datastar.PatchElementOption) error {
+	nav, err := h.nav(ctx, sessionID, listID)
+	if err != nil {
+		return err
+	}
+	for _, mobile := range []bool{false, true} {
+		if err := sse.PatchElementTempl(commoncomponents.SidebarLists(nav, mobile), opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleListError replies to a failed change to a list.
+func (h *Handlers) handleListError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrListNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidListName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// CreateList creates a named list and opens it
+func (h *Handlers) CreateList(w http.ResponseWriter, r *http.Request) {
+	type Store struct {
+		NewListName string `json:"newListName"`
+	}
+	store := &Store{}
+
+	if err := datastar.ReadSignals(r, store); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.listService.CreateList(r.Context(), sessionID, store.NewListName)
+	sse := datastar.NewSSE(w, r)
+	if errors.Is(err, services.ErrInvalidListName) {
+		if err := sse.PatchElementTempl(
+			commoncomponents.Toast(err.Error(), commoncomponents.ToastError),
+			datastar.WithSelectorID(commoncomponents.ToastContainerID),
+			datastar.WithModeAppend(),
+		); err != nil {
+			h.logger.Error("failed to send toast", "error", err)
+		}
+		return
+	}
+	if err != nil {
+		h.LogConsoleError(sse, err)
+		return
+	}
+
+	if err := sse.Redirect(commoncomponents.ListPath(list.ID)); err != nil {
+		h.logger.Error("failed to redirect", "error", err)
+	}
+}
+
+// RenameList renames the list; open views update through the event stream
+func (h *Handlers) RenameList(w http.ResponseWriter, r *http.Request) {
+	type Store struct {
+		ListName string `json:"listName"`
+	}
+	store := &Store{}
+
+	if err := datastar.ReadSignals(r, store); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.listService.RenameList(r.Context(), chi.URLParam(r, "listID"), sessionID, store.ListName); err != nil {
+		h.handleListError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ArchiveList archives the list
+func (h *Handlers) ArchiveList(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+// RestoreList brings an archived list back
+func (h *Handlers) RestoreList(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+func (h *Handlers) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.listService.ArchiveList(r.Context(), chi.URLParam(r, "listID"), sessionID, archived); err != nil {
+		h.handleListError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteList deletes the list with all its todos and sends the owner home
+func (h *Handlers) DeleteList(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.listService.DeleteList(r.Context(), chi.URLParam(r, "listID"), sessionID); err != nil {
+		h.handleListError(w, err)
+		return
+	}
+
+	sse := datastar.NewSSE(w, r)
+	if err := sse.Redirect("/"); err != nil {
+		h.logger.Error("failed to redirect", "error", err)
+	}
+}
@@ -0,0 +1,299 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/csrf"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// apiOperation is an endpoint of the JSON API. The routes and the OpenAPI
+// document are both built from the operations, so the document describes
+// exactly what the handlers serve.
+type apiOperation struct {
+	method  string
+	path    string // relative to the todos of a list
+	id      string
+	summary string
+	query   []apiParameter
+	// request and response are values of the types of the bodies, or nil
+	// if there is none.
+	request  any
+	response any
+	status   int
+	handler  http.HandlerFunc
+}
+
+// apiParameter is a query parameter of an operation.
+type apiParameter struct {
+	name        string
+	description string
+	schema      map[string]any
+}
+
+// apiOperations returns the operations of the JSON API.
+func (h *Handlers) apiOperations() []apiOperation {
+	return []apiOperation{
+		{
+			method:  http.MethodGet,
+			path:    "",
+			id:      "listTodos",
+			summary: "List the todos, each top-level todo followed by its subtasks",
+			query: []apiParameter{
+				{"mode", "Only the todos shown in this view mode.", map[string]any{"type": "string", "enum": apiModeNames(), "default": apiModeName(todocomponents.TodoViewModeAll)}},
+				{"tag", "Only the todos with this tag; may be repeated.", map[string]any{"type": "string"}},
+				{"limit", "Number of todos per page.", map[string]any{"type": "integer", "minimum": 1, "maximum": maxPageSize, "default": defaultPageSize}},
+				{"offset", "Number of todos to skip.", map[string]any{"type": "integer", "minimum": 0, "default": 0}},
+			},
+			response: apiTodoPage{},
+			status:   http.StatusOK,
+			handler:  h.APIListTodos,
+		},
+		{
+			method:   http.MethodPost,
+			path:     "",
+			id:       "createTodo",
+			summary:  "Create a todo",
+			request:  apiNewTodo{},
+			response: apiTodo{},
+			status:   http.StatusCreated,
+			handler:  h.APICreateTodo,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/{id}",
+			id:       "getTodo",
+			summary:  "Get a todo",
+			response: apiTodo{},
+			status:   http.StatusOK,
+			handler:  h.APIGetTodo,
+		},
+		{
+			method:   http.MethodPatch,
+			path:     "/{id}",
+			id:       "updateTodo",
+			summary:  "Change a todo",
+			request:  apiTodoPatch{},
+			response: apiTodo{},
+			status:   http.StatusOK,
+			handler:  h.APIUpdateTodo,
+		},
+		{
+			method:  http.MethodDelete,
+			path:    "/{id}",
+			id:      "deleteTodo",
+			summary: "Delete a todo with its subtasks",
+			status:  http.StatusNoContent,
+			handler: h.APIDeleteTodo,
+		},
+	}
+}
+
+// setupAPIRoutes configures the JSON API under router. Every response
+// carries the session's CSRF token, which writes must send back in the
+// X-CSRF-Token header.
+func (h *Handlers) setupAPIRoutes(router chi.Router) error {
+	operations := h.apiOperations()
+	document, err := json.Marshal(openAPIDocument(operations))
+	if err != nil {
+		return fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(csrf.HeaderName, csrf.Token(r.Context()))
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		WriteProblem(w, r, http.StatusNotFound, "")
+	})
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	})
+
+	todoRoutes := func(todosRouter chi.Router) {
+		for _, operation := range operations {
+			todosRouter.Method(operation.method, "/"+strings.TrimPrefix(operation.path, "/"), operation.handler)
+		}
+	}
+	router.Route("/todos", todoRoutes)
+	router.Route("/lists/{listID}/todos", todoRoutes)
+	router.Get("/openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(document)
+	})
+	return nil
+}
+
+// openAPIDocument builds the OpenAPI 3.1 document of the operations, which
+// are served for the visitor's default list and for any list by ID.
+func openAPIDocument(operations []apiOperation) map[string]any {
+	schemas := make(map[string]any)
+	problem := map[string]any{
+		"description": "Problem",
+		"content": map[string]any{
+			"application/problem+json": map[string]any{"schema": schemaOf(reflect.TypeOf(apiProblem{}), schemas)},
+		},
+	}
+
+	paths := make(map[string]map[string]any)
+	for _, prefix := range []string{"/api/v1/todos", "/api/v1/lists/{listID}/todos"} {
+		for _, operation := range operations {
+			path := prefix + operation.path
+			var parameters []any
+			if strings.Contains(path, "{listID}") {
+				parameters = append(parameters, pathParameter("listID", "ID of the list."))
+			}
+			if strings.Contains(path, "{id}") {
+				parameters = append(parameters, pathParameter("id", "ID of the todo."))
+			}
+			for _, parameter := range operation.query {
+				parameters = append(parameters, map[string]any{
+					"name":        parameter.name,
+					"in":          "query",
+					"description": parameter.description,
+					"schema":      parameter.schema,
+				})
+			}
+			if operation.method == http.MethodPatch || operation.method == http.MethodDelete {
+				parameters = append(parameters, map[string]any{
+					"name":        "If-Match",
+					"in":          "header",
+					"description": "Only change the todo if it is still at the version of this ETag.",
+					"schema":      map[string]any{"type": "string"},
+				})
+			}
+
+			id := operation.id
+			summary := operation.summary + " of the default list"
+			if strings.Contains(prefix, "{listID}") {
+				id += "InList"
+				summary = operation.summary + " of a list"
+			}
+			response := map[string]any{"description": http.StatusText(operation.status)}
+			if operation.response != nil {
+				response["content"] = map[string]any{
+					"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(operation.response), schemas)},
+				}
+			}
+			spec := map[string]any{
+				"operationId": id,
+				"summary":     summary,
+				"responses": map[string]any{
+					strconv.Itoa(operation.status): response,
+					"default":                      problem,
+				},
+			}
+			if len(parameters) > 0 {
+				spec["parameters"] = parameters
+			}
+			if operation.request != nil {
+				spec["requestBody"] = map[string]any{
+					"required": true,
+					"content": map[string]any{
+						"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(operation.request), schemas)},
+					},
+				}
+			}
+
+			if paths[path] == nil {
+				paths[path] = make(map[string]any)
+			}
+			paths[path][strings.ToLower(operation.method)] = spec
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Todos API",
+			"version": "1.0.0",
+			"description": "JSON API for the todos of the lists a visitor is a member of. Requests are " +
+				"authenticated by the session cookie. Writes must send the CSRF token, which every " +
+				"response carries in the " + csrf.HeaderName + " header, back in that header. " +
+				"Changes reach open browser tabs like changes made in the UI.",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+}
+
+func pathParameter(name, description string) map[string]any {
+	return map[string]any{
+		"name":        name,
+		"in":          "path",
+		"required":    true,
+		"description": description,
+		"schema":      map[string]any{"type": "string"},
+	}
+}
+
+// schemaOf returns the JSON schema of values of type t as encoding/json
+// encodes them. Structs are added to schemas, named after their type without
+// the "api" prefix, and referred to. Fields are described by their doc tag.
+// Structs whose fields are all optional, such as patches, need at least one
+// of them, as a body without any of them would change nothing.
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), schemas)
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Struct:
+	default:
+		return map[string]any{}
+	}
+
+	name := strings.TrimPrefix(t.Name(), "api")
+	ref := map[string]any{"$ref": "#/components/schemas/" + name}
+	if _, ok := schemas[name]; ok {
+		return ref
+	}
+	schemas[name] = nil
+	properties := make(map[string]any)
+	required := []string{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		jsonName, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || jsonName == "-" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		property := schemaOf(field.Type, schemas)
+		if doc := field.Tag.Get("doc"); doc != "" {
+			property["description"] = doc
+		}
+		properties[jsonName] = property
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			required = append(required, jsonName)
+		}
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+	if len(required) == 0 {
+		schema["minProperties"] = 1
+	}
+	schemas[name] = schema
+	return ref
+}
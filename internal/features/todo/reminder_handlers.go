@@ -0,0 +1,99 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// SetReminder sets the visitor's reminder on a todo to the time in the
+// "remindAt" signal, an RFC 3339 time, or removes it if the signal is empty.
+func (h *Handlers) SetReminder(w http.ResponseWriter, r *http.Request) {
+	type Store struct {
+		RemindAt string `json:"remindAt"`
+	}
+	store := &Store{}
+
+	if err := datastar.ReadSignals(r, store); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var remindAt time.Time
+	if store.RemindAt != "" {
+		var err error
+		if remindAt, err = time.Parse(time.RFC3339Nano, store.RemindAt); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := RequireTodoID(w, r, mvc)
+	if !ok {
+		return
+	}
+
+	err := h.todoService.SetReminder(r.Context(), sessionID, mvc, id, remindAt)
+	if errors.Is(err, services.ErrReminderInPast) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.handleMutationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SetNotifications opts the visitor in to browser notifications for their
+// reminders, for state "on", or out of them, for state "off".
+func (h *Handlers) SetNotifications(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := RequireSession(w, r)
+	if !ok {
+		return
+	}
+
+	var enabled bool
+	switch state := chi.URLParam(r, "state"); state {
+	case "on":
+		enabled = true
+	case "off":
+	default:
+		http.Error(w, fmt.Sprintf("invalid notification state %q", state), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.todoService.SetBrowserNotifications(r.Context(), sessionID, enabled); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// notificationScript returns the script that shows a browser notification
+// for a due reminder, if the member granted the permission in this browser.
+// The notification is tagged with the todo, so that the member's other tabs
+// replace it instead of showing it again.
+func notificationScript(reminder domain.ReminderDue) string {
+	body, _ := json.Marshal(reminder.Text)
+	tag, _ := json.Marshal("reminder-" + reminder.TodoID)
+	return fmt.Sprintf(
+		"if ('Notification' in window && Notification.permission === 'granted') { new Notification('Reminder', { body: %s, tag: %s }) }",
+		body, tag,
+	)
+}
@@ -6,35 +6,86 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-// SetupRoutes configures all todo-related HTTP routes.
-func SetupRoutes(router chi.Router, application *app.App) error {
-	// Extract specific dependencies from App and pass to handlers
-	handlers := NewHandlers(
+// newHandlers extracts the dependencies of the handlers from App.
+func newHandlers(application *app.App) *Handlers {
+	return NewHandlers(
 		application.Logger,
 		application.Services.Todo,
-		application.NATS,
-		application.SessionStore,
+		application.Services.Lists,
+		application.Services.Activity,
+		application.Services.Calendar,
+		application.EventBus,
+		application.Presence,
 	)
+}
+
+// SetupFeedRoutes configures the routes calendar apps poll. They are
+// authorized by the secret token in the URL instead of a session.
+func SetupFeedRoutes(router chi.Router, application *app.App) {
+	router.Get("/calendar/{token}.ics", newHandlers(application).CalendarFeed)
+}
+
+// SetupRoutes configures all todo-related HTTP routes.
+func SetupRoutes(router chi.Router, application *app.App) error {
+	handlers := newHandlers(application)
 
 	router.Get("/", handlers.IndexPage)
+	router.Get("/lists/{listID}", handlers.ListPage)
+	router.Get("/lists/{listID}/activity", handlers.ActivityPage)
+	router.Get("/invites/{token}", handlers.InvitePage)
+	router.Post("/invites/{token}", handlers.JoinList)
 
-	router.Route("/api", func(apiRouter chi.Router) {
-		apiRouter.Route("/todos", func(todosRouter chi.Router) {
-			todosRouter.Get("/updates", handlers.TodosUpdates)
-			todosRouter.Put("/reset", handlers.ResetTodos)
-			todosRouter.Put("/cancel", handlers.CancelEdit)
-			todosRouter.Put("/mode/{mode}", handlers.SetMode)
-
-			todosRouter.Route("/{idx}", func(todoRouter chi.Router) {
-				todoRouter.Post("/toggle", handlers.ToggleTodo)
-				todoRouter.Route("/edit", func(editRouter chi.Router) {
-					editRouter.Get("/", handlers.StartEdit)
-					editRouter.Put("/", handlers.SaveEdit)
-				})
-				todoRouter.Delete("/", handlers.DeleteTodo)
+	todoRoutes := func(todosRouter chi.Router) {
+		todosRouter.Get("/updates", handlers.TodosUpdates)
+		todosRouter.Put("/reset", handlers.ResetTodos)
+		todosRouter.Post("/undo", handlers.UndoTodos)
+		todosRouter.Post("/redo", handlers.RedoTodos)
+		todosRouter.Put("/cancel", handlers.CancelEdit)
+		todosRouter.Put("/mode/{mode}", handlers.SetMode)
+		todosRouter.Put("/tags", handlers.SetTagFilter)
+		todosRouter.Get("/search", handlers.SearchTodos)
+		todosRouter.Get("/export", handlers.ExportTodos)
+		todosRouter.Post("/import", handlers.ImportTodos)
+
+		// {id} is a todo ID; legacy slice indexes are still accepted (see RequireTodoID).
+		todosRouter.Route("/{id}", func(todoRouter chi.Router) {
+			todoRouter.Post("/toggle", handlers.ToggleTodo)
+			todoRouter.Put("/move", handlers.MoveTodo)
+			todoRouter.Post("/subtasks", handlers.AddSubtask)
+			todoRouter.Put("/promote", handlers.PromoteTodo)
+			todoRouter.Put("/demote", handlers.DemoteTodo)
+			todoRouter.Put("/reminder", handlers.SetReminder)
+			todoRouter.Route("/edit", func(editRouter chi.Router) {
+				editRouter.Get("/", handlers.StartEdit)
+				editRouter.Put("/", handlers.SaveEdit)
 			})
+			todoRouter.Delete("/", handlers.DeleteTodo)
+		})
+	}
+
+	var err error
+	router.Route("/api", func(apiRouter chi.Router) {
+		apiRouter.Post("/lists", handlers.CreateList)
+		apiRouter.Put("/notifications/{state}", handlers.SetNotifications)
+		apiRouter.Post("/calendar", handlers.ShowCalendarFeed)
+		apiRouter.Put("/calendar", handlers.ResetCalendarFeed)
+		apiRouter.Route("/lists/{listID}", func(listRouter chi.Router) {
+			listRouter.Put("/", handlers.RenameList)
+			listRouter.Delete("/", handlers.DeleteList)
+			listRouter.Put("/archive", handlers.ArchiveList)
+			listRouter.Put("/restore", handlers.RestoreList)
+			listRouter.Route("/todos", todoRoutes)
+			listRouter.Post("/invites", handlers.CreateInvite)
+		})
+
+		// Without a list ID, the todo routes address the visitor's default list.
+		apiRouter.Route("/todos", todoRoutes)
+
+		// The JSON API for scripts and apps, next to the Datastar endpoints.
+		apiRouter.Route("/v1", func(v1Router chi.Router) {
+			err = handlers.setupAPIRoutes(v1Router)
 		})
 	})
 
-	return nil
+	return err
 }
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// maxActivityEntries is the most changes the activity feed shows.
+const maxActivityEntries = 200
+
+// ActivityService reads the audit log that TodoService writes: who changed
+// which todo of a list and when, and what the list looked like at any point
+// in time.
+type ActivityService struct {
+	auditRepo domain.AuditRepository
+	listRepo  domain.ListRepository
+	userRepo  domain.UserRepository
+}
+
+// NewActivityService creates a new ActivityService with the given repositories.
+func NewActivityService(auditRepo domain.AuditRepository, listRepo domain.ListRepository, userRepo domain.UserRepository) *ActivityService {
+	return &ActivityService{
+		auditRepo: auditRepo,
+		listRepo:  listRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// Activity gets the newest changes to the todos of a list, or to the todo
+// with ID todoID unless it is empty. It returns ErrListNotFound unless the
+// member is a member of the list.
+func (s *ActivityService) Activity(ctx context.Context, memberID, listID, todoID string) (todocomponents.Activity, error) {
+	if _, err := memberRole(ctx, s.listRepo, listID, memberID); err != nil {
+		return todocomponents.Activity{}, err
+	}
+	list, err := s.listRepo.GetList(ctx, listID)
+	if err != nil {
+		return todocomponents.Activity{}, fmt.Errorf("failed to get list: %w", err)
+	}
+
+	var events []queries.TodoEvent
+	if todoID == "" {
+		events, err = s.auditRepo.GetTodoEventsByList(ctx, queries.GetTodoEventsByListParams{
+			ListID: listID,
+			Limit:  maxActivityEntries,
+		})
+	} else {
+		events, err = s.auditRepo.GetTodoEventsByTodo(ctx, queries.GetTodoEventsByTodoParams{
+			ListID: listID,
+			TodoID: todoID,
+			Limit:  maxActivityEntries,
+		})
+	}
+	if err != nil {
+		return todocomponents.Activity{}, fmt.Errorf("failed to get activity: %w", err)
+	}
+
+	activity := todocomponents.Activity{
+		ListID:   list.ID,
+		ListName: list.Name,
+		TodoID:   todoID,
+	}
+	actors := make(map[string]string)
+	for _, event := range events {
+		state, err := eventState(event)
+		if err != nil {
+			return todocomponents.Activity{}, err
+		}
+		actor, ok := actors[event.ActorID]
+		if !ok {
+			if actor, err = s.actorName(ctx, event.ActorID); err != nil {
+				return todocomponents.Activity{}, err
+			}
+			actors[event.ActorID] = actor
+		}
+		activity.Entries = append(activity.Entries, todocomponents.ActivityEntry{
+			ID:      event.ID,
+			TodoID:  event.TodoID,
+			Text:    state.Text,
+			Actor:   actor,
+			Action:  event.Action,
+			Deleted: event.Deleted == 1,
+			At:      event.CreatedAt,
+		})
+	}
+	return activity, nil
+}
+
+// ListAt rebuilds the todos of a list as they were at the given point in time
+// by replaying its audit log up to then. The result is read-only. It returns
+// ErrListNotFound unless the member is a member of the list.
+func (s *ActivityService) ListAt(ctx context.Context, memberID, listID string, at time.Time) (*todocomponents.TodoMVC, error) {
+	if _, err := memberRole(ctx, s.listRepo, listID, memberID); err != nil {
+		return nil, err
+	}
+	events, err := s.auditRepo.GetTodoEventsByListUntil(ctx, queries.GetTodoEventsByListUntilParams{
+		ListID:    listID,
+		CreatedAt: at.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity: %w", err)
+	}
+
+	states := make(map[string]*todocomponents.Todo)
+	for _, event := range events {
+		if event.Deleted == 1 {
+			delete(states, event.TodoID)
+			continue
+		}
+		state, err := eventState(event)
+		if err != nil {
+			return nil, err
+		}
+		states[event.TodoID] = &state
+	}
+
+	mvc := &todocomponents.TodoMVC{
+		ListID: listID,
+		Role:   domain.RoleViewer,
+	}
+	for _, todo := range states {
+		mvc.Todos = append(mvc.Todos, todo)
+	}
+	sortByPosition(mvc.Todos)
+	return mvc, nil
+}
+
+// actorName returns how the member with the given ID is shown in the feed:
+// the name part of the user's email, and "Guest" for anonymous sessions.
+// Occurrences of recurring todos created in the background are shown as
+// created by the "Scheduler".
+func (s *ActivityService) actorName(ctx context.Context, memberID string) (string, error) {
+	if memberID == schedulerActor {
+		return "Scheduler", nil
+	}
+	user, err := s.userRepo.GetUserByID(ctx, memberID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "Guest", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	name, _, _ := strings.Cut(user.Email, "@")
+	return name, nil
+}
+
+// eventState decodes the state of the todo an event recorded.
+func eventState(event queries.TodoEvent) (todocomponents.Todo, error) {
+	var state todocomponents.Todo
+	if err := json.Unmarshal([]byte(event.State), &state); err != nil {
+		return todocomponents.Todo{}, fmt.Errorf("failed to decode todo event: %w", err)
+	}
+	return state, nil
+}
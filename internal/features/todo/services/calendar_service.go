@@ -0,0 +1,191 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// ErrFeedNotFound is returned for unknown calendar feed tokens.
+var ErrFeedNotFound = errors.New("calendar feed not found")
+
+const (
+	// calendarDateLayout is the format of DATE values in iCalendar.
+	calendarDateLayout = "20060102"
+	// calendarTimeLayout is the format of UTC DATE-TIME values in iCalendar.
+	calendarTimeLayout = "20060102T150405Z"
+	// calendarLineLength is the most octets of a content line before it is folded.
+	calendarLineLength = 75
+)
+
+// CalendarService serves the todos with a due date of every list a member
+// belongs to as an iCalendar feed, which calendar apps subscribe to by a
+// secret URL.
+type CalendarService struct {
+	calendarRepo domain.CalendarRepository
+	todoRepo     domain.TodoRepository
+}
+
+// NewCalendarService creates a new CalendarService with the given repositories.
+func NewCalendarService(calendarRepo domain.CalendarRepository, todoRepo domain.TodoRepository) *CalendarService {
+	return &CalendarService{
+		calendarRepo: calendarRepo,
+		todoRepo:     todoRepo,
+	}
+}
+
+// FeedToken returns the secret token of the member's calendar feed, creating
+// the feed the first time.
+func (s *CalendarService) FeedToken(ctx context.Context, memberID string) (string, error) {
+	feed, err := s.calendarRepo.GetCalendarFeedByMember(ctx, memberID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.ResetFeedToken(ctx, memberID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	return feed.Token, nil
+}
+
+// ResetFeedToken gives the member's calendar feed a new secret token, so that
+// the URL with the old one stops working, and returns it.
+func (s *CalendarService) ResetFeedToken(ctx context.Context, memberID string) (string, error) {
+	token := rand.Text()
+	if err := s.calendarRepo.UpsertCalendarFeed(ctx, queries.UpsertCalendarFeedParams{
+		MemberID: memberID,
+		Token:    token,
+	}); err != nil {
+		return "", fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+	return token, nil
+}
+
+// Feed returns the iCalendar feed with the given token. Each todo with a due
+// date becomes a VTODO, for task apps, and an all-day VEVENT on the day it is
+// due, for calendars that do not show tasks. The feed only changes when the
+// todos do, so that it can be compared between polls. It returns
+// ErrFeedNotFound for unknown tokens.
+func (s *CalendarService) Feed(ctx context.Context, token string) ([]byte, error) {
+	feed, err := s.calendarRepo.GetCalendarFeedByToken(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrFeedNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	todos, err := s.todoRepo.GetDueTodosByMember(ctx, feed.MemberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	var c calendar
+	c.line("BEGIN", "VCALENDAR")
+	c.line("VERSION", "2.0")
+	c.line("PRODID", "-//datastar-go-blueprint//todos//EN")
+	c.line("CALSCALE", "GREGORIAN")
+	c.line("METHOD", "PUBLISH")
+	c.line("X-WR-CALNAME", "Todos")
+	for _, todo := range todos {
+		due := todo.DueAt.Time.UTC()
+		stamp := due
+		if todo.UpdatedAt.Valid {
+			stamp = todo.UpdatedAt.Time.UTC()
+		}
+		completed := todo.Completed.Int64 == 1
+
+		c.line("BEGIN", "VTODO")
+		c.line("UID", todo.ID)
+		c.line("DTSTAMP", stamp.Format(calendarTimeLayout))
+		c.line("SUMMARY", escapeCalendarText(todo.Task))
+		c.line("CATEGORIES", escapeCalendarText(todo.ListName))
+		c.line("DUE;VALUE=DATE", due.Format(calendarDateLayout))
+		if priority := calendarPriority(domain.Priority(todo.Priority.Int64)); priority != "" {
+			c.line("PRIORITY", priority)
+		}
+		if completed {
+			c.line("STATUS", "COMPLETED")
+		} else {
+			c.line("STATUS", "NEEDS-ACTION")
+		}
+		if todo.Recurrence != "" {
+			c.line("RRULE", todo.Recurrence)
+		}
+		c.line("END", "VTODO")
+
+		summary := todo.Task
+		if completed {
+			summary = "✓ " + summary
+		}
+		c.line("BEGIN", "VEVENT")
+		c.line("UID", todo.ID+"-due")
+		c.line("DTSTAMP", stamp.Format(calendarTimeLayout))
+		c.line("SUMMARY", escapeCalendarText(summary))
+		c.line("CATEGORIES", escapeCalendarText(todo.ListName))
+		c.line("DTSTART;VALUE=DATE", due.Format(calendarDateLayout))
+		c.line("DTEND;VALUE=DATE", due.AddDate(0, 0, 1).Format(calendarDateLayout))
+		c.line("TRANSP", "TRANSPARENT")
+		if todo.Recurrence != "" {
+			c.line("RRULE", todo.Recurrence)
+		}
+		c.line("END", "VEVENT")
+	}
+	c.line("END", "VCALENDAR")
+	return c.Bytes(), nil
+}
+
+// calendar builds an iCalendar object line by line.
+type calendar struct {
+	bytes.Buffer
+}
+
+// line writes a content line, folding it after every calendarLineLength
+// octets without splitting a character.
+func (c *calendar) line(name, value string) {
+	line := name + ":" + value
+	limit := calendarLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		c.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with the space.
+		limit = calendarLineLength - 1
+	}
+	c.WriteString(line + "\r\n")
+}
+
+// escapeCalendarText escapes a TEXT value.
+func escapeCalendarText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+// calendarPriority returns the iCalendar priority of p, on a scale from 1,
+// the highest, to 9, or "" if the todo has no priority.
+func calendarPriority(p domain.Priority) string {
+	switch p {
+	case domain.PriorityHigh:
+		return "1"
+	case domain.PriorityMedium:
+		return "5"
+	case domain.PriorityLow:
+		return "9"
+	default:
+		return ""
+	}
+}
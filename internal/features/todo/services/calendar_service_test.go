@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/store"
+)
+
+// calendarComponent is a component of an iCalendar feed, such as a VTODO,
+// with its content lines unfolded.
+type calendarComponent struct {
+	name  string
+	lines []string
+}
+
+// property returns the value of the first property with the given name and
+// parameters, such as "DUE;VALUE=DATE".
+func (c calendarComponent) property(name string) string {
+	for _, line := range c.lines {
+		if value, ok := strings.CutPrefix(line, name+":"); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseCalendar checks the folding of an iCalendar feed and returns the
+// VTODO and VEVENT components in it, unfolded.
+func parseCalendar(t *testing.T, feed []byte) []calendarComponent {
+	t.Helper()
+	text := string(feed)
+	if !strings.HasSuffix(text, "\r\n") {
+		t.Fatalf("feed does not end with CRLF")
+	}
+	for i, line := range strings.Split(strings.TrimSuffix(text, "\r\n"), "\r\n") {
+		if len(line) > calendarLineLength {
+			t.Errorf("line %d is %d octets long: %q", i, len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a character: %q", i, line)
+		}
+		if strings.ContainsAny(line, "\r\n") {
+			t.Errorf("line %d holds a bare line break: %q", i, line)
+		}
+	}
+
+	var components []calendarComponent
+	var current *calendarComponent
+	unfolded := strings.ReplaceAll(text, "\r\n ", "")
+	for _, line := range strings.Split(strings.TrimSuffix(unfolded, "\r\n"), "\r\n") {
+		switch {
+		case line == "BEGIN:VTODO" || line == "BEGIN:VEVENT":
+			current = &calendarComponent{name: strings.TrimPrefix(line, "BEGIN:")}
+		case current != nil && line == "END:"+current.name:
+			components = append(components, *current)
+			current = nil
+		case current != nil:
+			current.lines = append(current.lines, line)
+		}
+	}
+	return components
+}
+
+func TestCalendarFeed(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	mvc := env.newList(t)
+	due := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+	long := "Grüße an Zoë: 日本語のメモを書く, then call; back \\ soon — " + strings.Repeat("ü", 40)
+	open, err := env.todos.EditTodo(ctx, testOwner, mvc, "", TodoDetails{Text: long, DueAt: &due})
+	if err != nil {
+		t.Fatalf("EditTodo: %v", err)
+	}
+	done, err := env.todos.EditTodo(ctx, testOwner, mvc, "", TodoDetails{Text: "Hoover", DueAt: &due})
+	if err != nil {
+		t.Fatalf("EditTodo: %v", err)
+	}
+	if err := env.todos.ToggleTodo(ctx, testOwner, mvc, done.ID, false); err != nil {
+		t.Fatalf("ToggleTodo: %v", err)
+	}
+	if _, err := env.todos.EditTodo(ctx, testOwner, mvc, "", TodoDetails{Text: "Someday"}); err != nil {
+		t.Fatalf("EditTodo: %v", err)
+	}
+
+	calendars := NewCalendarService(store.NewCalendarRepository(env.store), store.NewTodoRepository(env.store))
+	token, err := calendars.FeedToken(ctx, testOwner)
+	if err != nil {
+		t.Fatalf("FeedToken: %v", err)
+	}
+	feed, err := calendars.Feed(ctx, token)
+	if err != nil {
+		t.Fatalf("Feed: %v", err)
+	}
+	if !strings.HasPrefix(string(feed), "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(string(feed), "END:VCALENDAR\r\n") {
+		t.Errorf("feed %q is not a VCALENDAR", feed)
+	}
+
+	if !strings.Contains(string(feed), "\r\n ") {
+		t.Errorf("feed %q folds no line, though the summary is too long for one", feed)
+	}
+	components := parseCalendar(t, feed)
+	find := func(name, uid string) calendarComponent {
+		t.Helper()
+		i := slices.IndexFunc(components, func(c calendarComponent) bool { return c.name == name && c.property("UID") == uid })
+		if i < 0 {
+			t.Fatalf("no %s with UID %s in %q", name, uid, feed)
+		}
+		return components[i]
+	}
+	if len(components) != 4 {
+		t.Errorf("feed holds %d components, want a VTODO and a VEVENT for each of the 2 todos due", len(components))
+	}
+
+	escaped := `Grüße an Zoë: 日本語のメモを書く\, then call\; back \\ soon — ` + strings.Repeat("ü", 40)
+	tests := []struct {
+		component calendarComponent
+		want      map[string]string
+	}{
+		{
+			component: find("VTODO", open.ID),
+			want:      map[string]string{"SUMMARY": escaped, "STATUS": "NEEDS-ACTION", "DUE;VALUE=DATE": "20260302", "CATEGORIES": "Chores"},
+		},
+		{
+			component: find("VTODO", done.ID),
+			want:      map[string]string{"SUMMARY": "Hoover", "STATUS": "COMPLETED"},
+		},
+		{
+			component: find("VEVENT", open.ID+"-due"),
+			want:      map[string]string{"SUMMARY": escaped, "DTSTART;VALUE=DATE": "20260302", "DTEND;VALUE=DATE": "20260303"},
+		},
+		{
+			component: find("VEVENT", done.ID+"-due"),
+			want:      map[string]string{"SUMMARY": "✓ Hoover"},
+		},
+	}
+	for _, tt := range tests {
+		for name, want := range tt.want {
+			if got := tt.component.property(name); got != want {
+				t.Errorf("%s %s has %s %q, want %q", tt.component.name, tt.component.property("UID"), name, got, want)
+			}
+		}
+	}
+
+	if _, err := calendars.Feed(ctx, "unknown"); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("Feed of an unknown token = %v, want %v", err, ErrFeedNotFound)
+	}
+}
+
+func TestEscapeCalendarText(t *testing.T) {
+	for text, want := range map[string]string{
+		"Plain":             "Plain",
+		"a, b; c":           `a\, b\; c`,
+		`back\slash`:        `back\\slash`,
+		"two\nlines\r\nend": `two\nlines\nend`,
+		`\,`:                `\\\,`,
+	} {
+		if got := escapeCalendarText(text); got != want {
+			t.Errorf("escapeCalendarText(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// maxHistory is the number of changes to a list each session can undo.
+const maxHistory = 50
+
+// command is a recorded change: the todos it touched as they were before and
+// after it. A todo missing from Before was created by the change, one missing
+// from After was deleted. Undoing the change restores Before, redoing it
+// restores After.
+type command struct {
+	Before []todocomponents.Todo `json:"before"`
+	After  []todocomponents.Todo `json:"after"`
+}
+
+// snapshot copies the current state of todos, to be compared with their
+// state after a change.
+func snapshot(todos []*todocomponents.Todo) []todocomponents.Todo {
+	states := make([]todocomponents.Todo, len(todos))
+	for i, todo := range todos {
+		states[i] = *todo
+	}
+	return states
+}
+
+// revertTodos puts the todos of mvc back into the state recorded in before,
+// after the transaction that changed them was rolled back, so that mvc keeps
+// the versions that are stored.
+func revertTodos(mvc *todocomponents.TodoMVC, before []todocomponents.Todo) {
+	mvc.Todos = make([]*todocomponents.Todo, len(before))
+	for i := range before {
+		todo := before[i]
+		mvc.Todos[i] = &todo
+	}
+}
+
+// diff returns the command that turns the todos in before into the ones in
+// after, leaving out the todos it does not change.
+func diff(before, after []todocomponents.Todo) command {
+	var cmd command
+	for _, b := range before {
+		i := slices.IndexFunc(after, func(a todocomponents.Todo) bool { return a.ID == b.ID })
+		switch {
+		case i < 0:
+			cmd.Before = append(cmd.Before, b)
+		case !sameTodo(b, after[i]):
+			cmd.Before = append(cmd.Before, b)
+			cmd.After = append(cmd.After, after[i])
+		}
+	}
+	for _, a := range after {
+		if !slices.ContainsFunc(before, func(b todocomponents.Todo) bool { return b.ID == a.ID }) {
+			cmd.After = append(cmd.After, a)
+		}
+	}
+	return cmd
+}
+
+// sameTodo reports whether a and b hold the same state, regardless of version.
+func sameTodo(a, b todocomponents.Todo) bool {
+	sameDue := a.DueAt == nil && b.DueAt == nil ||
+		a.DueAt != nil && b.DueAt != nil && a.DueAt.Equal(*b.DueAt)
+	return a.Text == b.Text &&
+		a.Completed == b.Completed &&
+		sameDue &&
+		a.Priority == b.Priority &&
+		slices.Equal(a.Tags, b.Tags) &&
+		a.Position == b.Position &&
+		a.ParentID == b.ParentID &&
+		a.Recurrence == b.Recurrence
+}
+
+// record adds the change from the todos in before to the current todos of
+// mvc to the session's history of the list, under label, and forgets the
+// changes the session could redo. The change is logged to the audit log as
+// well. It returns the event announcing that the change can be undone, or
+// nothing if the todos did not change.
+func (s *TodoService) record(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, label string, before []todocomponents.Todo) ([]domain.Event, error) {
+	cmd := diff(before, snapshot(mvc.Todos))
+	if len(cmd.Before) == 0 && len(cmd.After) == 0 {
+		return nil, nil
+	}
+	if err := s.audit(ctx, sessionID, mvc.ListID, label, cmd); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode history: %w", err)
+	}
+
+	if err := s.historyRepo.DeleteUndoneTodoCommands(ctx, queries.DeleteUndoneTodoCommandsParams{
+		MemberID: sessionID,
+		ListID:   mvc.ListID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to clear redo history: %w", err)
+	}
+	if err := s.historyRepo.CreateTodoCommand(ctx, queries.CreateTodoCommandParams{
+		MemberID: sessionID,
+		ListID:   mvc.ListID,
+		Label:    label,
+		Payload:  string(payload),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record history: %w", err)
+	}
+	if err := s.historyRepo.TrimTodoCommands(ctx, queries.TrimTodoCommandsParams{
+		MemberID: sessionID,
+		ListID:   mvc.ListID,
+		Keep:     maxHistory,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to trim history: %w", err)
+	}
+	return []domain.Event{domain.HistoryRecorded{MemberID: sessionID}}, nil
+}
+
+// audit appends the todos changed by cmd to the audit log of the list, as
+// changed by the session under action.
+func (s *TodoService) audit(ctx context.Context, sessionID, listID, action string, cmd command) error {
+	return logTodoEvents(ctx, s.auditRepo, s.clock.Now().UTC(), sessionID, listID, action, cmd)
+}
+
+// logTodoEvents appends the todos changed by cmd to the audit log of the
+// list, as changed by the actor under action at the given time: the todos in
+// cmd.After with their new state, and the ones only in cmd.Before as deleted
+// in their last state.
+func logTodoEvents(ctx context.Context, auditRepo domain.AuditRepository, at time.Time, actorID, listID, action string, cmd command) error {
+	write := func(todo todocomponents.Todo, deleted int64) error {
+		state, err := json.Marshal(todo)
+		if err != nil {
+			return fmt.Errorf("failed to encode todo event: %w", err)
+		}
+		if err := auditRepo.CreateTodoEvent(ctx, queries.CreateTodoEventParams{
+			ListID:    listID,
+			TodoID:    todo.ID,
+			ActorID:   actorID,
+			Action:    action,
+			State:     string(state),
+			Deleted:   deleted,
+			CreatedAt: at,
+		}); err != nil {
+			return fmt.Errorf("failed to log todo event: %w", err)
+		}
+		return nil
+	}
+
+	for _, todo := range cmd.After {
+		if err := write(todo, 0); err != nil {
+			return err
+		}
+	}
+	for _, todo := range cmd.Before {
+		if !slices.ContainsFunc(cmd.After, func(a todocomponents.Todo) bool { return a.ID == todo.ID }) {
+			if err := write(todo, 1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Undo reverts the last change the session made to the todos of the list that
+// it has not undone yet. It does nothing if there is no such change.
+func (s *TodoService) Undo(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC) error {
+	return s.replay(ctx, sessionID, mvc, false)
+}
+
+// Redo applies the change the session undid last again. It does nothing if
+// there is no such change, or if the session changed the todos since.
+func (s *TodoService) Redo(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC) error {
+	return s.replay(ctx, sessionID, mvc, true)
+}
+
+// replay undoes or redoes a command of the session's history. If a todo it
+// touches was changed in the meantime, the command can no longer be replayed
+// safely: it is dropped from the history and the conflict is published.
+func (s *TodoService) replay(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, redo bool) error {
+	if mvc.ReadOnly() {
+		return domain.ErrForbidden
+	}
+
+	var replayed *queries.TodoCommand
+	err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var dbCmd queries.TodoCommand
+		var err error
+		if redo {
+			dbCmd, err = s.historyRepo.GetNextUndoneTodoCommand(txCtx, queries.GetNextUndoneTodoCommandParams{
+				MemberID: sessionID,
+				ListID:   mvc.ListID,
+			})
+		} else {
+			dbCmd, err = s.historyRepo.GetLastTodoCommand(txCtx, queries.GetLastTodoCommandParams{
+				MemberID: sessionID,
+				ListID:   mvc.ListID,
+			})
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get history: %w", err)
+		}
+		replayed = &dbCmd
+
+		var cmd command
+		if err := json.Unmarshal([]byte(dbCmd.Payload), &cmd); err != nil {
+			return fmt.Errorf("failed to decode history: %w", err)
+		}
+		from, to := cmd.After, cmd.Before
+		if redo {
+			from, to = cmd.Before, cmd.After
+		}
+		if err := s.restore(txCtx, sessionID, mvc, from, to); err != nil {
+			return err
+		}
+		action := "Undone: " + dbCmd.Label
+		if redo {
+			action = "Redone: " + dbCmd.Label
+		}
+		if err := s.audit(txCtx, sessionID, mvc.ListID, action, command{Before: from, After: to}); err != nil {
+			return err
+		}
+
+		// The restored todos got new versions, which replaying the command
+		// the other way round will expect.
+		payload, err := json.Marshal(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to encode history: %w", err)
+		}
+		undone := int64(1)
+		if redo {
+			undone = 0
+		}
+		return s.historyRepo.SetTodoCommandUndone(txCtx, queries.SetTodoCommandUndoneParams{
+			Undone:  undone,
+			Payload: string(payload),
+			ID:      dbCmd.ID,
+		})
+	})
+	var conflict *domain.ConflictError
+	if errors.As(err, &conflict) && replayed != nil {
+		if dropErr := s.historyRepo.DeleteTodoCommand(ctx, replayed.ID); dropErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to drop history: %w", dropErr))
+		}
+	}
+	if err != nil {
+		return s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	if replayed == nil {
+		return nil
+	}
+	return s.publish(ctx, sessionID, mvc, domain.HistoryReplayed{
+		MemberID: sessionID,
+		Label:    replayed.Label,
+		Redo:     redo,
+	})
+}
+
+// restore turns the todos of mvc that are in the state recorded in from into
+// the state recorded in to, updating the versions in to to the ones stored.
+// It fails with a *domain.ConflictError if a todo is not in the state from
+// expects.
+func (s *TodoService) restore(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, from, to []todocomponents.Todo) error {
+	inTo := func(id string) bool {
+		return slices.ContainsFunc(to, func(todo todocomponents.Todo) bool { return todo.ID == id })
+	}
+	for _, state := range from {
+		if todo, _ := mvc.Find(state.ID); todo == nil || todo.Version != state.Version {
+			return &domain.ConflictError{Entity: "todo", ID: state.ID}
+		}
+	}
+	for _, state := range to {
+		if todo, _ := mvc.Find(state.ID); todo != nil && !slices.ContainsFunc(from, func(f todocomponents.Todo) bool { return f.ID == state.ID }) {
+			return &domain.ConflictError{Entity: "todo", ID: state.ID}
+		}
+	}
+
+	// Subtasks are deleted before their parents, and parents restored first.
+	slices.SortStableFunc(from, func(a, b todocomponents.Todo) int {
+		return cmp.Compare(depth(b), depth(a))
+	})
+	slices.SortStableFunc(to, func(a, b todocomponents.Todo) int {
+		return cmp.Compare(depth(a), depth(b))
+	})
+
+	for _, state := range from {
+		if inTo(state.ID) {
+			continue
+		}
+		if err := s.tagRepo.DeleteTodoTags(ctx, state.ID); err != nil {
+			return fmt.Errorf("failed to delete todo tags: %w", err)
+		}
+		rows, err := s.todoRepo.DeleteTodo(ctx, queries.DeleteTodoParams{
+			ID:      state.ID,
+			Version: state.Version,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete todo: %w", err)
+		}
+		if rows == 0 {
+			return &domain.ConflictError{Entity: "todo", ID: state.ID}
+		}
+		mvc.Todos = slices.DeleteFunc(mvc.Todos, func(todo *todocomponents.Todo) bool { return todo.ID == state.ID })
+	}
+
+	for i, state := range to {
+		todo, _ := mvc.Find(state.ID)
+		if todo == nil {
+			todo = &todocomponents.Todo{}
+			*todo = state
+			if err := insertTodos(ctx, s.todoRepo, sessionID, mvc.ListID, []*todocomponents.Todo{todo}); err != nil {
+				return err
+			}
+			mvc.Todos = append(mvc.Todos, todo)
+		} else {
+			rows, err := s.todoRepo.RestoreTodo(ctx, queries.RestoreTodoParams{
+				Task:            state.Text,
+				Completed:       completedValue(state.Completed),
+				DueAt:           dueAtValue(state.DueAt),
+				Priority:        priorityValue(state.Priority),
+				Position:        state.Position,
+				ParentID:        parentKey(state.ParentID),
+				Recurrence:      state.Recurrence,
+				RecurrenceStart: dueAtValue(state.RecurrenceStart),
+				ID:              state.ID,
+				Version:         todo.Version,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to restore todo: %w", err)
+			}
+			if rows == 0 {
+				return &domain.ConflictError{Entity: "todo", ID: state.ID}
+			}
+			version := todo.Version + 1
+			*todo = state
+			todo.Version = version
+		}
+		if err := s.setTags(ctx, mvc.ListID, todo, state.Tags); err != nil {
+			return err
+		}
+		to[i].Version = todo.Version
+	}
+	sortByPosition(mvc.Todos)
+	return nil
+}
+
+// depth returns how deeply a todo is nested: 0 for top-level todos, 1 for subtasks.
+func depth(todo todocomponents.Todo) int {
+	if todo.ParentID == "" {
+		return 0
+	}
+	return 1
+}
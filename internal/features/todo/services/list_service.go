@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultListName is the name of the list every owner starts with.
+	defaultListName = "Todos"
+	// inviteTTL is how long an invite link can be used.
+	inviteTTL = 7 * 24 * time.Hour
+	// maxListNameLength is the longest list name accepted, in characters.
+	maxListNameLength = 100
+)
+
+var (
+	// ErrListNotFound is returned for lists that do not exist or that the
+	// member has not joined, so that non-members cannot probe for list IDs.
+	ErrListNotFound = errors.New("list not found")
+	// ErrInvalidInvite is returned for unknown or expired invite tokens.
+	ErrInvalidInvite = errors.New("this invite link is invalid or has expired")
+	// ErrInvalidListName is returned for empty or overly long list names.
+	ErrInvalidListName = fmt.Errorf("list names must be 1 to %d characters long", maxListNameLength)
+)
+
+// ListService provides business logic for todo lists and their members.
+type ListService struct {
+	uow         domain.UnitOfWork
+	listRepo    domain.ListRepository
+	todoRepo    domain.TodoRepository
+	tagRepo     domain.TagRepository
+	historyRepo domain.HistoryRepository
+	auditRepo   domain.AuditRepository
+	events      domain.EventPublisher
+	now         func() time.Time
+}
+
+// NewListService creates a new ListService with the given repositories.
+// Renaming, archiving and deleting a list is published through events to
+// everyone viewing it.
+func NewListService(uow domain.UnitOfWork, listRepo domain.ListRepository, todoRepo domain.TodoRepository, tagRepo domain.TagRepository, historyRepo domain.HistoryRepository, auditRepo domain.AuditRepository, events domain.EventPublisher) *ListService {
+	return &ListService{
+		uow:         uow,
+		listRepo:    listRepo,
+		todoRepo:    todoRepo,
+		tagRepo:     tagRepo,
+		historyRepo: historyRepo,
+		auditRepo:   auditRepo,
+		events:      events,
+		now:         time.Now,
+	}
+}
+
+// Lists returns every list the member has joined, oldest first.
+func (s *ListService) Lists(ctx context.Context, memberID string) ([]queries.List, error) {
+	lists, err := s.listRepo.GetListsByMember(ctx, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lists: %w", err)
+	}
+	return lists, nil
+}
+
+// CreateList creates a named list owned by ownerID.
+func (s *ListService) CreateList(ctx context.Context, ownerID, name string) (queries.List, error) {
+	name, err := listName(name)
+	if err != nil {
+		return queries.List{}, err
+	}
+
+	list := queries.List{ID: uuid.New().String(), Name: name, OwnerID: ownerID}
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		return s.createList(txCtx, list)
+	}); err != nil {
+		return queries.List{}, err
+	}
+	return list, nil
+}
+
+// RenameList changes the name of a list. Only members allowed to manage the
+// list can rename it.
+func (s *ListService) RenameList(ctx context.Context, listID, memberID, name string) error {
+	name, err := listName(name)
+	if err != nil {
+		return err
+	}
+	if err := s.requireManager(ctx, listID, memberID); err != nil {
+		return err
+	}
+
+	if err := s.listRepo.RenameList(ctx, queries.RenameListParams{Name: name, ID: listID}); err != nil {
+		return fmt.Errorf("failed to rename list: %w", err)
+	}
+	return s.publish(ctx, listID, domain.ListChanged{})
+}
+
+// ArchiveList archives a list, or restores it if archived is false. Archived
+// lists keep their todos and members but can no longer be changed.
+func (s *ListService) ArchiveList(ctx context.Context, listID, memberID string, archived bool) error {
+	if err := s.requireManager(ctx, listID, memberID); err != nil {
+		return err
+	}
+
+	archivedAt := sql.NullTime{Time: s.now().UTC(), Valid: archived}
+	if err := s.listRepo.SetListArchivedAt(ctx, queries.SetListArchivedAtParams{
+		ArchivedAt: archivedAt,
+		ID:         listID,
+	}); err != nil {
+		return fmt.Errorf("failed to archive list: %w", err)
+	}
+	return s.publish(ctx, listID, domain.ListChanged{})
+}
+
+// DeleteList deletes a list together with its todos, members, invites and
+// the UI state and history kept for it. The todos are logged to the audit
+// log as deleted by the member.
+func (s *ListService) DeleteList(ctx context.Context, listID, memberID string) error {
+	if err := s.requireManager(ctx, listID, memberID); err != nil {
+		return err
+	}
+
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		dbTodos, err := s.todoRepo.GetTodosByList(txCtx, listKey(listID))
+		if err != nil {
+			return fmt.Errorf("failed to get todos: %w", err)
+		}
+		todos, err := todosFromDB(txCtx, s.tagRepo, listID, dbTodos)
+		if err != nil {
+			return err
+		}
+		if err := logTodoEvents(txCtx, s.auditRepo, s.now().UTC(), memberID, listID, "List deleted", command{Before: snapshot(todos)}); err != nil {
+			return err
+		}
+
+		if err := s.tagRepo.DeleteTodoTagsByList(txCtx, listID); err != nil {
+			return fmt.Errorf("failed to delete todo tags: %w", err)
+		}
+		if err := s.tagRepo.DeleteTagsByList(txCtx, listID); err != nil {
+			return fmt.Errorf("failed to delete tags: %w", err)
+		}
+		if err := s.todoRepo.DeleteAllTodosByList(txCtx, listKey(listID)); err != nil {
+			return fmt.Errorf("failed to delete todos: %w", err)
+		}
+		if err := s.historyRepo.DeleteTodoCommandsByList(txCtx, listID); err != nil {
+			return fmt.Errorf("failed to delete history: %w", err)
+		}
+		if err := s.listRepo.DeleteListViews(txCtx, listID); err != nil {
+			return fmt.Errorf("failed to delete list views: %w", err)
+		}
+		if err := s.listRepo.DeleteListInvites(txCtx, listID); err != nil {
+			return fmt.Errorf("failed to delete invites: %w", err)
+		}
+		if err := s.listRepo.DeleteListMembers(txCtx, listID); err != nil {
+			return fmt.Errorf("failed to delete members: %w", err)
+		}
+		if err := s.listRepo.DeleteList(txCtx, listID); err != nil {
+			return fmt.Errorf("failed to delete list: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return s.publish(ctx, listID, domain.ListDeleted{})
+}
+
+// DefaultList returns the oldest list of the owner that is not archived,
+// creating one with the default todos when there is none.
+func (s *ListService) DefaultList(ctx context.Context, ownerID string) (queries.List, error) {
+	var list queries.List
+	err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		list, err = s.listRepo.GetFirstListByOwner(txCtx, ownerID)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get list: %w", err)
+		}
+
+		list = queries.List{ID: uuid.New().String(), Name: defaultListName, OwnerID: ownerID}
+		if err := s.createList(txCtx, list); err != nil {
+			return err
+		}
+		todos := defaultTodos()
+		if err := insertTodos(txCtx, s.todoRepo, ownerID, list.ID, todos); err != nil {
+			return err
+		}
+		return logTodoEvents(txCtx, s.auditRepo, s.now().UTC(), ownerID, list.ID, "Todos created", command{After: snapshot(todos)})
+	})
+	if err != nil {
+		return queries.List{}, err
+	}
+	return list, nil
+}
+
+// Role returns the role of a member on a list, or ErrListNotFound if the
+// member has not joined it.
+func (s *ListService) Role(ctx context.Context, listID, memberID string) (domain.ListRole, error) {
+	return memberRole(ctx, s.listRepo, listID, memberID)
+}
+
+// CreateInvite creates an invite link that lets others join a list with the
+// given role. Only members allowed to invite can create one.
+func (s *ListService) CreateInvite(ctx context.Context, listID, memberID string, role domain.ListRole) (queries.ListInvite, error) {
+	if role != domain.RoleEditor && role != domain.RoleViewer {
+		return queries.ListInvite{}, fmt.Errorf("invalid invite role %q", role)
+	}
+
+	inviterRole, err := s.Role(ctx, listID, memberID)
+	if err != nil {
+		return queries.ListInvite{}, err
+	}
+	if !inviterRole.CanInvite() {
+		return queries.ListInvite{}, domain.ErrForbidden
+	}
+
+	invite := queries.ListInvite{
+		Token:     rand.Text(),
+		ListID:    listID,
+		Role:      string(role),
+		CreatedBy: memberID,
+		ExpiresAt: s.now().Add(inviteTTL).UTC(),
+	}
+	if err := s.listRepo.CreateListInvite(ctx, queries.CreateListInviteParams{
+		Token:     invite.Token,
+		ListID:    invite.ListID,
+		Role:      invite.Role,
+		CreatedBy: invite.CreatedBy,
+		ExpiresAt: invite.ExpiresAt,
+	}); err != nil {
+		return queries.ListInvite{}, fmt.Errorf("failed to create invite: %w", err)
+	}
+	return invite, nil
+}
+
+// GetInvite returns a usable invite and the list it is for.
+func (s *ListService) GetInvite(ctx context.Context, token string) (queries.ListInvite, queries.List, error) {
+	invite, err := s.listRepo.GetListInvite(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return queries.ListInvite{}, queries.List{}, ErrInvalidInvite
+	}
+	if err != nil {
+		return queries.ListInvite{}, queries.List{}, fmt.Errorf("failed to get invite: %w", err)
+	}
+	if s.now().After(invite.ExpiresAt) {
+		return queries.ListInvite{}, queries.List{}, ErrInvalidInvite
+	}
+
+	list, err := s.listRepo.GetList(ctx, invite.ListID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return queries.ListInvite{}, queries.List{}, ErrInvalidInvite
+	}
+	if err != nil {
+		return queries.ListInvite{}, queries.List{}, fmt.Errorf("failed to get list: %w", err)
+	}
+	return invite, list, nil
+}
+
+// JoinList makes the member a member of the list an invite is for and
+// returns the list ID. Members who already joined keep their role, except
+// that viewers joining through an editor invite become editors.
+func (s *ListService) JoinList(ctx context.Context, token, memberID string) (string, error) {
+	invite, _, err := s.GetInvite(ctx, token)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.listRepo.AddListMember(ctx, queries.AddListMemberParams{
+		ListID:   invite.ListID,
+		MemberID: memberID,
+		Role:     invite.Role,
+	}); err != nil {
+		return "", fmt.Errorf("failed to join list: %w", err)
+	}
+	return invite.ListID, nil
+}
+
+// createList stores a list and makes its owner a member.
+func (s *ListService) createList(ctx context.Context, list queries.List) error {
+	if err := s.listRepo.CreateList(ctx, queries.CreateListParams{
+		ID:      list.ID,
+		Name:    list.Name,
+		OwnerID: list.OwnerID,
+	}); err != nil {
+		return fmt.Errorf("failed to create list: %w", err)
+	}
+	if err := s.listRepo.AddListMember(ctx, queries.AddListMemberParams{
+		ListID:   list.ID,
+		MemberID: list.OwnerID,
+		Role:     string(domain.RoleOwner),
+	}); err != nil {
+		return fmt.Errorf("failed to add list owner: %w", err)
+	}
+	return nil
+}
+
+// requireManager checks that the member may manage the list.
+func (s *ListService) requireManager(ctx context.Context, listID, memberID string) error {
+	role, err := s.Role(ctx, listID, memberID)
+	if err != nil {
+		return err
+	}
+	if !role.CanManage() {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// publish tells everyone viewing the list about a change to the list itself.
+func (s *ListService) publish(ctx context.Context, listID string, event domain.Event) error {
+	if err := s.events.Publish(ctx, domain.ListTopic(listID), event); err != nil {
+		return fmt.Errorf("failed to publish events: %w", err)
+	}
+	return nil
+}
+
+// listName trims a list name and checks its length.
+func listName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxListNameLength {
+		return "", ErrInvalidListName
+	}
+	return name, nil
+}
+
+// memberRole looks up the role of a member on a list.
+func memberRole(ctx context.Context, listRepo domain.ListRepository, listID, memberID string) (domain.ListRole, error) {
+	member, err := listRepo.GetListMember(ctx, queries.GetListMemberParams{
+		ListID:   listID,
+		MemberID: memberID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrListNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get list member: %w", err)
+	}
+	return domain.ListRole(member.Role), nil
+}
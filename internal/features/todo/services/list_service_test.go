@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/store"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+func TestDefaultListStartsWithDefaultTodosOnce(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	list, err := env.lists.DefaultList(ctx, testOwner)
+	if err != nil {
+		t.Fatalf("DefaultList: %v", err)
+	}
+	mvc := env.load(t, list.ID)
+	if got, want := len(mvc.Todos), len(defaultTodos()); got != want {
+		t.Fatalf("new default list has %d todos, want %d", got, want)
+	}
+
+	for _, todo := range snapshot(mvc.Todos) {
+		if err := env.todos.DeleteTodo(ctx, testOwner, mvc, todo.ID); err != nil {
+			t.Fatalf("DeleteTodo: %v", err)
+		}
+	}
+	again, err := env.lists.DefaultList(ctx, testOwner)
+	if err != nil {
+		t.Fatalf("DefaultList: %v", err)
+	}
+	if again.ID != list.ID {
+		t.Fatalf("DefaultList = %q, want the existing list %q", again.ID, list.ID)
+	}
+
+	// Loading the emptied list must not bring the default todos back.
+	if todos := env.load(t, list.ID).Todos; len(todos) != 0 {
+		t.Errorf("emptied default list loaded with %d todos, want none", len(todos))
+	}
+	if stored := env.stored(t, list.ID); len(stored) != 0 {
+		t.Errorf("emptied default list has %d stored todos after loading, want none", len(stored))
+	}
+}
+
+func TestCreateListStartsEmpty(t *testing.T) {
+	env := newTestEnv(t)
+
+	mvc := env.newList(t)
+	if len(mvc.Todos) != 0 {
+		t.Errorf("new list has %d todos, want none", len(mvc.Todos))
+	}
+}
+
+func TestDeleteListLogsDeletedTodos(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	mvc := env.newList(t, "Wash up", "Hoover")
+
+	if err := env.lists.DeleteList(ctx, mvc.ListID, testOwner); err != nil {
+		t.Fatalf("DeleteList: %v", err)
+	}
+
+	events, err := store.NewAuditRepository(env.store).GetTodoEventsByList(ctx, queries.GetTodoEventsByListParams{
+		ListID: mvc.ListID,
+		Limit:  int64(len(mvc.Todos)),
+	})
+	if err != nil {
+		t.Fatalf("GetTodoEventsByList: %v", err)
+	}
+	deleted := make(map[string]bool)
+	for _, event := range events {
+		if event.Deleted == 1 && event.ActorID == testOwner {
+			deleted[event.TodoID] = true
+		}
+	}
+	for _, todo := range mvc.Todos {
+		if !deleted[todo.ID] {
+			t.Errorf("deletion of todo %q not logged", todo.Text)
+		}
+	}
+}
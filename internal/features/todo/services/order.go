@@ -0,0 +1,200 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+const (
+	// positionGap is the distance between the positions of todos added to
+	// the end of a list, and between all positions of a respaced list.
+	positionGap = 1024
+	// minPositionGap is the smallest distance kept between neighbouring
+	// positions. Moving a todo between closer neighbours respaces the list.
+	minPositionGap = 1e-6
+)
+
+// ErrInvalidMove is returned when a todo is moved next to itself, or next to
+// todos that are not neighbours among the todos with the same parent.
+var ErrInvalidMove = errors.New("todos can only be moved between neighbouring todos of the same list and parent")
+
+// MoveTodo moves a todo so that it follows the todo with ID afterID and
+// precedes the todo with ID beforeID, both of which must have the same parent.
+// One of them may be empty, in which case the other neighbour is taken from
+// the current order. The todo takes the position halfway between its new
+// neighbours; when they are too close together, its siblings are respaced in
+// the same transaction and announced as moved as well.
+func (s *TodoService) MoveTodo(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, id, afterID, beforeID string) error {
+	if mvc.ReadOnly() {
+		return domain.ErrForbidden
+	}
+	todo, _ := mvc.Find(id)
+	if todo == nil {
+		return nil
+	}
+	if afterID == id || beforeID == id {
+		return ErrInvalidMove
+	}
+
+	siblings := mvc.Children(todo.ParentID)
+	index := slices.Index(siblings, todo)
+	others := slices.Delete(siblings, index, index+1)
+	at, err := insertionIndex(others, afterID, beforeID)
+	if err != nil {
+		return err
+	}
+	if at == index {
+		return nil
+	}
+	order := slices.Insert(others, at, todo)
+
+	before := snapshot(mvc.Todos)
+	var respaced, recorded []domain.Event
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		position, moved, err := s.place(txCtx, order, at)
+		if err != nil {
+			return err
+		}
+		for _, sibling := range moved {
+			respaced = append(respaced, domain.TodoMoved{TodoID: sibling.ID})
+		}
+
+		rows, err := s.todoRepo.MoveTodo(txCtx, queries.MoveTodoParams{
+			Position: position,
+			ID:       id,
+			Version:  todo.Version,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move todo: %w", err)
+		}
+		if rows == 0 {
+			return &domain.ConflictError{Entity: "todo", ID: id}
+		}
+		todo.Position = position
+		todo.Version++
+		recorded, err = s.record(txCtx, sessionID, mvc, "Todo moved", before)
+		return err
+	}); err != nil {
+		revertTodos(mvc, before)
+		return s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	sortByPosition(mvc.Todos)
+	events := append([]domain.Event{domain.TodoMoved{TodoID: id}}, respaced...)
+	return s.publish(ctx, sessionID, mvc, append(events, recorded...)...)
+}
+
+// sortByPosition restores the order of todos after positions changed. Todos
+// with the same parent keep their relative order, so siblings stay in order.
+func sortByPosition(todos []*todocomponents.Todo) {
+	slices.SortStableFunc(todos, func(a, b *todocomponents.Todo) int {
+		return cmp.Compare(a.Position, b.Position)
+	})
+}
+
+// insertionIndex returns the index in todos at which a todo ends up after the
+// todo with ID afterID and before the todo with ID beforeID.
+func insertionIndex(todos []*todocomponents.Todo, afterID, beforeID string) (int, error) {
+	find := func(id string) int {
+		return slices.IndexFunc(todos, func(todo *todocomponents.Todo) bool { return todo.ID == id })
+	}
+	after, before := find(afterID), find(beforeID)
+	switch {
+	case afterID != "" && beforeID != "":
+		if after < 0 || before != after+1 {
+			return 0, ErrInvalidMove
+		}
+		return before, nil
+	case afterID != "":
+		if after < 0 {
+			return 0, ErrInvalidMove
+		}
+		return after + 1, nil
+	case beforeID != "":
+		if before < 0 {
+			return 0, ErrInvalidMove
+		}
+		return before, nil
+	default:
+		return 0, ErrInvalidMove
+	}
+}
+
+// midpoint returns the position halfway between the neighbours of the todo
+// at index at of order, and whether they are far enough apart to take it.
+// The first and last todo are placed positionGap beyond their neighbour.
+func midpoint(order []*todocomponents.Todo, at int) (float64, bool) {
+	switch {
+	case len(order) == 1:
+		return positionGap, true
+	case at == 0:
+		return order[1].Position - positionGap, true
+	case at == len(order)-1:
+		return order[at-1].Position + positionGap, true
+	}
+	lower, upper := order[at-1].Position, order[at+1].Position
+	if upper-lower < 2*minPositionGap {
+		return 0, false
+	}
+	return lower + (upper-lower)/2, true
+}
+
+// place returns the position for the todo at index at of order, respacing
+// the other todos in order first if its neighbours are too close together.
+// It also returns the todos that were respaced.
+func (s *TodoService) place(ctx context.Context, order []*todocomponents.Todo, at int) (float64, []*todocomponents.Todo, error) {
+	if position, ok := midpoint(order, at); ok {
+		return position, nil, nil
+	}
+	respaced, err := s.respace(ctx, order, at)
+	if err != nil {
+		return 0, nil, err
+	}
+	position, _ := midpoint(order, at)
+	return position, respaced, nil
+}
+
+// respace spreads the todos in order positionGap apart without changing
+// their order, and returns the todos whose position changed. Each of them is
+// moved like any other move, so that it fails with a *domain.ConflictError
+// if the todo was changed since it was loaded. The todo at index skip is
+// left to the caller.
+func (s *TodoService) respace(ctx context.Context, order []*todocomponents.Todo, skip int) ([]*todocomponents.Todo, error) {
+	var respaced []*todocomponents.Todo
+	for i, todo := range order {
+		position := float64(i+1) * positionGap
+		if i == skip || todo.Position == position {
+			continue
+		}
+		rows, err := s.todoRepo.MoveTodo(ctx, queries.MoveTodoParams{
+			Position: position,
+			ID:       todo.ID,
+			Version:  todo.Version,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to respace todos: %w", err)
+		}
+		if rows == 0 {
+			return nil, &domain.ConflictError{Entity: "todo", ID: todo.ID}
+		}
+		todo.Position = position
+		todo.Version++
+		respaced = append(respaced, todo)
+	}
+	return respaced, nil
+}
+
+// nextPosition returns the position of a todo added to the end of todos,
+// which are in order.
+func nextPosition(todos []*todocomponents.Todo) float64 {
+	if len(todos) == 0 {
+		return positionGap
+	}
+	return todos[len(todos)-1].Position + positionGap
+}
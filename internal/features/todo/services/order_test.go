@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+)
+
+func TestMoveTodoRespacesWithVersions(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	mvc := env.newList(t, "Wash up", "Hoover", "Dust")
+
+	// Moving the last todo between the first two halves the gap between
+	// them every time, until they are too close and the list is respaced.
+	for range 40 {
+		seq := env.lastSeq(t)
+		before := snapshot(mvc.Todos)
+		first, second, last := mvc.Todos[0], mvc.Todos[1], mvc.Todos[2]
+		if err := env.todos.MoveTodo(ctx, testOwner, mvc, last.ID, first.ID, second.ID); err != nil {
+			t.Fatalf("MoveTodo: %v", err)
+		}
+
+		batch := env.nextBatch(t, domain.ListTopic(mvc.ListID), seq)
+		var moved []string
+		for _, event := range batch.Events {
+			if e, ok := event.(domain.TodoMoved); ok {
+				moved = append(moved, e.TodoID)
+			}
+		}
+		if len(moved) == 1 {
+			continue
+		}
+
+		for _, todo := range before {
+			current, _ := mvc.Find(todo.ID)
+			if current.Position != todo.Position && !slices.Contains(moved, todo.ID) {
+				t.Errorf("todo %q respaced without being announced as moved", todo.Text)
+			}
+		}
+		stored := env.stored(t, mvc.ListID)
+		for _, todo := range mvc.Todos {
+			if todo.Version != stored[todo.ID].Version || todo.Position != stored[todo.ID].Position {
+				t.Errorf("todo %q at version %d, position %g in memory, stored at %d, %g",
+					todo.Text, todo.Version, todo.Position, stored[todo.ID].Version, stored[todo.ID].Position)
+			}
+		}
+		return
+	}
+	t.Fatal("list never respaced")
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+
+	"github.com/samber/lo"
+)
+
+// schedulerActor is who the audit log names for the occurrences the
+// recurrence scheduler creates.
+const schedulerActor = "scheduler"
+
+// recurrenceHorizon is how far ahead the occurrences of recurring todos are
+// created, so that the upcoming ones show up before they are due.
+const recurrenceHorizon = 7 * 24 * time.Hour
+
+// today returns the current day at midnight UTC, the form due dates take.
+func (s *TodoService) today() time.Time {
+	return s.clock.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// recur creates the next occurrence of each of the recurring todos, created
+// by creatorID and due on the first day of its rule on or after today. The
+// rule moves over to the new occurrence, so that every occurrence recurs only
+// once. It returns the events announcing the changes.
+func (s *TodoService) recur(ctx context.Context, creatorID string, mvc *todocomponents.TodoMVC, todos []*todocomponents.Todo, today time.Time) ([]domain.Event, error) {
+	var events []domain.Event
+	for _, todo := range todos {
+		recurrence, err := domain.ParseRecurrence(todo.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+		if recurrence.IsZero() {
+			continue
+		}
+		from := today
+		if todo.DueAt != nil {
+			from = *todo.DueAt
+		}
+		start := from
+		if todo.RecurrenceStart != nil {
+			start = *todo.RecurrenceStart
+		}
+		due := recurrence.NextOnOrAfter(from, start, today)
+
+		rows, err := s.todoRepo.ClearTodoRecurrence(ctx, queries.ClearTodoRecurrenceParams{
+			ID:      todo.ID,
+			Version: todo.Version,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to clear recurrence: %w", err)
+		}
+		if rows == 0 {
+			return nil, &domain.ConflictError{Entity: "todo", ID: todo.ID}
+		}
+		todo.Recurrence = ""
+		todo.RecurrenceStart = nil
+		todo.Version++
+
+		next, err := s.createTodo(ctx, creatorID, mvc, todo.ParentID, todo.Text, slices.Clone(todo.Tags), TodoDetails{
+			DueAt:      &due,
+			Priority:   todo.Priority,
+			Recurrence: recurrence,
+			start:      &start,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, domain.TodoEdited{TodoID: todo.ID}, domain.TodoCreated{TodoID: next.ID})
+	}
+	return events, nil
+}
+
+// MaterializeRecurrences creates the upcoming occurrences of the open
+// recurring todos of all lists: every recurring todo gets its next
+// occurrences, the first of them on or after the day of now, until the one
+// carrying the rule is due at least recurrenceHorizon ahead. Occurrences that
+// were missed stay open, as nobody did them. Each list is changed in its own
+// transaction, and its new occurrences are published to everyone viewing it.
+// Archived lists are left alone. It returns the number of occurrences created.
+func (s *TodoService) MaterializeRecurrences(ctx context.Context, now time.Time) (int, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	horizon := today.Add(recurrenceHorizon)
+	due, err := s.todoRepo.GetRecurringTodosDueBefore(ctx, sql.NullTime{Time: horizon, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get recurring todos: %w", err)
+	}
+
+	listIDs := lo.Uniq(lo.Map(due, func(todo queries.Todo, _ int) string { return todo.ListID.String }))
+	created := 0
+	var errs []error
+	for _, listID := range listIDs {
+		n, err := s.materializeList(ctx, listID, today, horizon)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("list %s: %w", listID, err))
+		}
+		created += n
+	}
+	return created, errors.Join(errs...)
+}
+
+// materializeList creates the occurrences of the open recurring todos of a
+// list until the ones carrying the rules are due on or after horizon, as the
+// list's owner.
+func (s *TodoService) materializeList(ctx context.Context, listID string, today, horizon time.Time) (int, error) {
+	list, err := s.listRepo.GetList(ctx, listID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get list: %w", err)
+	}
+	if list.ArchivedAt.Valid {
+		return 0, nil
+	}
+
+	mvc := &todocomponents.TodoMVC{ListID: listID, Role: domain.RoleOwner}
+	created := 0
+	var events []domain.Event
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		dbTodos, err := s.todoRepo.GetTodosByList(txCtx, listKey(listID))
+		if err != nil {
+			return fmt.Errorf("failed to get todos: %w", err)
+		}
+		if mvc.Todos, err = todosFromDB(txCtx, s.tagRepo, listID, dbTodos); err != nil {
+			return err
+		}
+
+		before := snapshot(mvc.Todos)
+		// Each round creates the next occurrence of the todos carrying a
+		// rule that are due before the horizon, which take the rule over.
+		for {
+			due := lo.Filter(mvc.Todos, func(todo *todocomponents.Todo, _ int) bool {
+				return todo.Recurrence != "" && !todo.Completed && todo.DueAt != nil && todo.DueAt.Before(horizon)
+			})
+			if len(due) == 0 {
+				break
+			}
+			recurred, err := s.recur(txCtx, list.OwnerID, mvc, due, today)
+			if err != nil {
+				return err
+			}
+			events = append(events, recurred...)
+			created += len(due)
+		}
+		return s.audit(txCtx, schedulerActor, listID, "Upcoming occurrences created", diff(before, snapshot(mvc.Todos)))
+	}); err != nil {
+		return 0, err
+	}
+	return created, s.publish(ctx, schedulerActor, mvc, events...)
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+
+	"github.com/google/uuid"
+)
+
+// ErrReminderInPast is returned when a reminder is set to a time that has passed.
+var ErrReminderInPast = errors.New("reminder must be in the future")
+
+// reminderBatchSize is the most reminders DeliverReminders loads at once.
+const reminderBatchSize = 100
+
+// SetReminder reminds the session's member of the todo with ID id at
+// remindAt, replacing the reminder they set on it before, or removes their
+// reminder if remindAt is zero. Reminders are personal, so members who can
+// only view the list can set them too, but not on archived lists.
+func (s *TodoService) SetReminder(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, id string, remindAt time.Time) error {
+	if mvc.Archived {
+		return domain.ErrForbidden
+	}
+	todo, _ := mvc.Find(id)
+	if todo == nil {
+		return nil
+	}
+
+	if remindAt.IsZero() {
+		if err := s.reminderRepo.DeleteReminder(ctx, queries.DeleteReminderParams{
+			TodoID:   id,
+			MemberID: sessionID,
+		}); err != nil {
+			return fmt.Errorf("failed to delete reminder: %w", err)
+		}
+		todo.RemindAt = nil
+		return s.publish(ctx, sessionID, mvc, domain.ReminderChanged{TodoID: id})
+	}
+
+	if !remindAt.After(s.clock.Now()) {
+		return ErrReminderInPast
+	}
+	remindAt = remindAt.UTC()
+	if err := s.reminderRepo.UpsertReminder(ctx, queries.UpsertReminderParams{
+		ID:       uuid.New().String(),
+		TodoID:   id,
+		ListID:   mvc.ListID,
+		MemberID: sessionID,
+		RemindAt: remindAt,
+	}); err != nil {
+		return fmt.Errorf("failed to set reminder: %w", err)
+	}
+	todo.RemindAt = &remindAt
+	return s.publish(ctx, sessionID, mvc, domain.ReminderChanged{TodoID: id, Set: true})
+}
+
+// SetBrowserNotifications opts the member in to or out of browser
+// notifications for their reminders, on top of the toasts every open view
+// shows.
+func (s *TodoService) SetBrowserNotifications(ctx context.Context, memberID string, enabled bool) error {
+	browser := int64(0)
+	if enabled {
+		browser = 1
+	}
+	if err := s.reminderRepo.UpsertNotificationSetting(ctx, queries.UpsertNotificationSettingParams{
+		MemberID: memberID,
+		Browser:  browser,
+	}); err != nil {
+		return fmt.Errorf("failed to save notification setting: %w", err)
+	}
+	if err := s.events.Publish(ctx, domain.MemberTopic(memberID), domain.NotificationsChanged{Enabled: enabled}); err != nil {
+		return fmt.Errorf("failed to publish events: %w", err)
+	}
+	return nil
+}
+
+// browserNotifications reports whether the member opted in to browser notifications.
+func (s *TodoService) browserNotifications(ctx context.Context, memberID string) (bool, error) {
+	setting, err := s.reminderRepo.GetNotificationSetting(ctx, memberID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get notification setting: %w", err)
+	}
+	return setting.Browser == 1, nil
+}
+
+// loadReminders sets the reminders the session's member has pending on the
+// todos of mvc, and whether they get browser notifications for them.
+func (s *TodoService) loadReminders(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC) error {
+	reminders, err := s.reminderRepo.GetPendingRemindersByList(ctx, queries.GetPendingRemindersByListParams{
+		ListID:   mvc.ListID,
+		MemberID: sessionID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get reminders: %w", err)
+	}
+	for _, reminder := range reminders {
+		if todo, _ := mvc.Find(reminder.TodoID); todo != nil {
+			remindAt := reminder.RemindAt.UTC()
+			todo.RemindAt = &remindAt
+		}
+	}
+	mvc.Notifications, err = s.browserNotifications(ctx, sessionID)
+	return err
+}
+
+// DeliverReminders sends every pending reminder that is due at now to the
+// member who set it, on the topic all of their open views follow. Each
+// reminder is marked sent before it is published, so that it is sent at most
+// once even with several instances delivering. Reminders of todos completed
+// in the meantime are marked sent without being published. It returns the
+// number of reminders sent.
+func (s *TodoService) DeliverReminders(ctx context.Context, now time.Time) (int, error) {
+	now = now.UTC()
+	notify := make(map[string]bool)
+	sent := 0
+	for {
+		due, err := s.reminderRepo.GetDueReminders(ctx, queries.GetDueRemindersParams{
+			RemindAt: now,
+			Limit:    reminderBatchSize,
+		})
+		if err != nil {
+			return sent, fmt.Errorf("failed to get due reminders: %w", err)
+		}
+
+		for _, reminder := range due {
+			rows, err := s.reminderRepo.MarkReminderSent(ctx, queries.MarkReminderSentParams{
+				SentAt: sql.NullTime{Time: now, Valid: true},
+				ID:     reminder.ID,
+			})
+			if err != nil {
+				return sent, fmt.Errorf("failed to mark reminder sent: %w", err)
+			}
+			if rows == 0 || reminder.Completed.Int64 == 1 {
+				continue
+			}
+
+			enabled, ok := notify[reminder.MemberID]
+			if !ok {
+				if enabled, err = s.browserNotifications(ctx, reminder.MemberID); err != nil {
+					return sent, err
+				}
+				notify[reminder.MemberID] = enabled
+			}
+			if err := s.events.Publish(ctx, domain.MemberTopic(reminder.MemberID), domain.ReminderDue{
+				ListID: reminder.ListID,
+				TodoID: reminder.TodoID,
+				Text:   reminder.Task,
+				Notify: enabled,
+			}); err != nil {
+				return sent, fmt.Errorf("failed to publish events: %w", err)
+			}
+			sent++
+		}
+
+		if len(due) < reminderBatchSize {
+			return sent, nil
+		}
+	}
+}
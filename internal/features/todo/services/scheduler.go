@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+)
+
+// schedulerInterval is how often the recurrence scheduler looks for
+// occurrences to create. Occurrences are due on whole days, so the scheduler also wakes
+// up right after midnight UTC.
+const schedulerInterval = 15 * time.Minute
+
+// RecurrenceScheduler materializes the occurrences of recurring todos in the
+// background. It takes the time from its clock, so that a fake clock can
+// drive it through days deterministically.
+type RecurrenceScheduler struct {
+	todoService *TodoService
+	clock       domain.Clock
+	logger      *slog.Logger
+}
+
+// NewRecurrenceScheduler creates a new RecurrenceScheduler that creates
+// occurrences through todoService at the times clock tells.
+func NewRecurrenceScheduler(todoService *TodoService, clock domain.Clock, logger *slog.Logger) *RecurrenceScheduler {
+	return &RecurrenceScheduler{
+		todoService: todoService,
+		clock:       clock,
+		logger:      logger,
+	}
+}
+
+// Run materializes occurrences right away and then on every wake-up until
+// ctx is done. Failures are logged and retried on the next wake-up, so Run
+// only returns once ctx is done, and then without an error.
+func (s *RecurrenceScheduler) Run(ctx context.Context) error {
+	for {
+		s.Tick(ctx)
+
+		now := s.clock.Now()
+		wait := min(schedulerInterval, now.UTC().Truncate(24*time.Hour).Add(24*time.Hour).Sub(now))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.clock.After(wait):
+		}
+	}
+}
+
+// Tick materializes the occurrences that are due at the current time of the
+// clock and returns how many it created.
+func (s *RecurrenceScheduler) Tick(ctx context.Context) int {
+	created, err := s.todoService.MaterializeRecurrences(ctx, s.clock.Now())
+	if err != nil {
+		s.logger.Error("failed to materialize recurring todos", "error", err)
+	}
+	if created > 0 {
+		s.logger.Info("materialized recurring todos", "count", created)
+	}
+	return created
+}
+
+// reminderInterval is how often the reminder scheduler looks for reminders
+// that are due, which bounds how late a reminder is sent.
+const reminderInterval = 30 * time.Second
+
+// ReminderScheduler sends reminders in the background as they become due.
+// Pending reminders are kept in the database, so the ones that became due
+// while the server was down are sent right after it starts again.
+type ReminderScheduler struct {
+	todoService *TodoService
+	clock       domain.Clock
+	logger      *slog.Logger
+}
+
+// NewReminderScheduler creates a new ReminderScheduler that sends reminders
+// through todoService at the times clock tells.
+func NewReminderScheduler(todoService *TodoService, clock domain.Clock, logger *slog.Logger) *ReminderScheduler {
+	return &ReminderScheduler{
+		todoService: todoService,
+		clock:       clock,
+		logger:      logger,
+	}
+}
+
+// Run sends due reminders right away and then every reminderInterval until
+// ctx is done. Failures are logged and retried on the next wake-up, so Run
+// only returns once ctx is done, and then without an error.
+func (s *ReminderScheduler) Run(ctx context.Context) error {
+	for {
+		s.Tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.clock.After(reminderInterval):
+		}
+	}
+}
+
+// Tick sends the reminders that are due at the current time of the clock and
+// returns how many it sent.
+func (s *ReminderScheduler) Tick(ctx context.Context) int {
+	sent, err := s.todoService.DeliverReminders(ctx, s.clock.Now())
+	if err != nil {
+		s.logger.Error("failed to send reminders", "error", err)
+	}
+	if sent > 0 {
+		s.logger.Info("sent reminders", "count", sent)
+	}
+	return sent
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/clock"
+)
+
+// monday is the day the recurrence tests start on.
+var monday = time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+
+// newRecurringList creates a list holding a todo repeating daily from day,
+// and a scheduler for it driven by the clock of e, set to 9:00 on that day.
+func (e *testEnv) newRecurringList(t *testing.T, day time.Time) (*todocomponents.TodoMVC, *RecurrenceScheduler, *clock.Fake) {
+	t.Helper()
+	daily, err := domain.ParseRecurrence("FREQ=DAILY")
+	if err != nil {
+		t.Fatalf("ParseRecurrence: %v", err)
+	}
+	mvc := e.newList(t)
+	if _, err := e.todos.EditTodo(context.Background(), testOwner, mvc, "", TodoDetails{
+		Text:       "Water the plants",
+		DueAt:      &day,
+		Recurrence: daily,
+	}); err != nil {
+		t.Fatalf("EditTodo: %v", err)
+	}
+
+	e.clock.Set(day.Add(9 * time.Hour))
+	return mvc, NewRecurrenceScheduler(e.todos, e.clock, slog.New(slog.DiscardHandler)), e.clock
+}
+
+// openOccurrences returns the due days of the open todos of a list, in
+// order, and the due day of the one carrying the rule.
+func (e *testEnv) openOccurrences(t *testing.T, listID string) (days []time.Time, ruleDay time.Time) {
+	t.Helper()
+	for _, todo := range e.stored(t, listID) {
+		if todo.Completed.Int64 == 1 {
+			continue
+		}
+		days = append(days, todo.DueAt.Time.UTC())
+		if todo.Recurrence != "" {
+			if !ruleDay.IsZero() {
+				t.Errorf("more than one open todo carries the rule")
+			}
+			ruleDay = todo.DueAt.Time.UTC()
+		}
+	}
+	slices.SortFunc(days, time.Time.Compare)
+	return days, ruleDay
+}
+
+// daysFrom returns each day from first through last.
+func daysFrom(first, last time.Time) []time.Time {
+	var days []time.Time
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// assertOccurrences checks that a list holds an open occurrence due on each
+// of want, the last one carrying the rule.
+func assertOccurrences(t *testing.T, days []time.Time, ruleDay time.Time, want []time.Time) {
+	t.Helper()
+	if !slices.EqualFunc(days, want, time.Time.Equal) {
+		t.Errorf("open occurrences due %v, want %v", days, want)
+	}
+	if last := want[len(want)-1]; !ruleDay.Equal(last) {
+		t.Errorf("rule carried by the occurrence due %v, want the one due %v", ruleDay, last)
+	}
+}
+
+func TestRecurrenceSchedulerCreatesOccurrencesAhead(t *testing.T) {
+	env := newTestEnv(t)
+	mvc, scheduler, _ := env.newRecurringList(t, monday)
+
+	if created := scheduler.Tick(context.Background()); created != 7 {
+		t.Errorf("Tick created %d occurrences, want 7", created)
+	}
+	days, ruleDay := env.openOccurrences(t, mvc.ListID)
+	assertOccurrences(t, days, ruleDay, daysFrom(monday, monday.AddDate(0, 0, 7)))
+
+	if created := scheduler.Tick(context.Background()); created != 0 {
+		t.Errorf("second Tick on the same day created %d occurrences, want none", created)
+	}
+}
+
+func TestRecurrenceSchedulerLeavesMissedOccurrencesOpen(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	mvc, scheduler, fake := env.newRecurringList(t, monday)
+	scheduler.Tick(ctx)
+
+	fake.Advance(2 * 24 * time.Hour)
+	if created := scheduler.Tick(ctx); created != 2 {
+		t.Errorf("Tick two days later created %d occurrences, want 2", created)
+	}
+	days, ruleDay := env.openOccurrences(t, mvc.ListID)
+	assertOccurrences(t, days, ruleDay, daysFrom(monday, monday.AddDate(0, 0, 9)))
+
+	// After a month without the scheduler, every occurrence was missed,
+	// including the one carrying the rule. They stay open, and the rule
+	// picks up again from today.
+	fake.Advance(30 * 24 * time.Hour)
+	if created := scheduler.Tick(ctx); created != 8 {
+		t.Errorf("Tick a month later created %d occurrences, want 8", created)
+	}
+	days, ruleDay = env.openOccurrences(t, mvc.ListID)
+	assertOccurrences(t, days, ruleDay, append(daysFrom(monday, monday.AddDate(0, 0, 9)), daysFrom(monday.AddDate(0, 0, 32), monday.AddDate(0, 0, 39))...))
+}
+
+func TestRecurrenceSchedulerRunWakesUpAtMidnight(t *testing.T) {
+	env := newTestEnv(t)
+	mvc, scheduler, fake := env.newRecurringList(t, monday)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- scheduler.Run(ctx) }()
+
+	// waitFor polls until the scheduler went back to sleep with the list
+	// holding occurrences through last.
+	waitFor := func(last time.Time) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			if fake.Waiters() == 1 {
+				if _, ruleDay := env.openOccurrences(t, mvc.ListID); ruleDay.Equal(last) {
+					return
+				}
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("scheduler did not create the occurrences through %v", last)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitFor(monday.AddDate(0, 0, 7))
+	fake.Set(monday.AddDate(0, 0, 1))
+	waitFor(monday.AddDate(0, 0, 8))
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run = %v, want nil", err)
+	}
+}
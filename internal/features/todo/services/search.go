@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+
+	"github.com/samber/lo"
+)
+
+// maxSearchResults is the most todos a search returns.
+const maxSearchResults = 20
+
+// Search finds the todos of a list whose text contains every word of query,
+// each as a prefix, best matches first. It returns ErrListNotFound unless the
+// session is a member of the list, and no results for a blank query.
+func (s *TodoService) Search(ctx context.Context, sessionID, listID, query string) ([]todocomponents.SearchResult, error) {
+	if _, err := memberRole(ctx, s.listRepo, listID, sessionID); err != nil {
+		return nil, err
+	}
+
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := s.todoRepo.SearchTodos(ctx, queries.SearchTodosParams{
+		Query:      match,
+		ListID:     listKey(listID),
+		MaxResults: maxSearchResults,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search todos: %w", err)
+	}
+	return lo.Map(rows, func(row queries.SearchTodosRow, _ int) todocomponents.SearchResult {
+		return todocomponents.SearchResult{
+			ID:          row.ID,
+			Completed:   row.Completed.Int64 == 1,
+			Highlighted: row.Highlighted,
+		}
+	}), nil
+}
+
+// ftsQuery turns what a member typed into an FTS5 query for todos containing
+// every word as a prefix. Each word is quoted, so that FTS5 operators and
+// punctuation are searched for literally instead of failing the query.
+func ftsQuery(query string) string {
+	words := strings.Fields(query)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"*`
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// ErrNestedSubtask is returned when a subtask would get subtasks of its own.
+// Todos are nested one level deep.
+var ErrNestedSubtask = errors.New("subtasks cannot have subtasks of their own")
+
+// AddSubtask creates a todo as the last subtask of the todo with ID parentID,
+// completed in the same transaction if the details ask for it. It returns the
+// created todo, or nil if no todo has the given ID.
+func (s *TodoService) AddSubtask(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, parentID string, details TodoDetails) (*todocomponents.Todo, error) {
+	if mvc.ReadOnly() {
+		return nil, domain.ErrForbidden
+	}
+	if !details.Priority.Valid() {
+		return nil, fmt.Errorf("invalid priority %d", details.Priority)
+	}
+	parent, _ := mvc.Find(parentID)
+	if parent == nil {
+		return nil, nil
+	}
+	if parent.ParentID != "" {
+		return nil, ErrNestedSubtask
+	}
+
+	text, tags := parseTags(details.Text)
+	before := snapshot(mvc.Todos)
+	var todo *todocomponents.Todo
+	var completed, recorded []domain.Event
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		if todo, err = s.createTodo(txCtx, sessionID, mvc, parentID, text, tags, details); err != nil {
+			return err
+		}
+		if completed, err = s.complete(txCtx, sessionID, mvc, todo, details.Completed); err != nil {
+			return err
+		}
+		recorded, err = s.record(txCtx, sessionID, mvc, "Subtask added", before)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	events := append([]domain.Event{domain.TodoCreated{TodoID: todo.ID}}, completed...)
+	return todo, s.publish(ctx, sessionID, mvc, append(events, recorded...)...)
+}
+
+// PromoteTodo turns a subtask into a top-level todo placed right after its
+// former parent.
+func (s *TodoService) PromoteTodo(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, id string) error {
+	if mvc.ReadOnly() {
+		return domain.ErrForbidden
+	}
+	todo, _ := mvc.Find(id)
+	if todo == nil || todo.ParentID == "" {
+		return nil
+	}
+
+	order := mvc.Children("")
+	at := slices.IndexFunc(order, func(t *todocomponents.Todo) bool { return t.ID == todo.ParentID }) + 1
+	order = slices.Insert(order, at, todo)
+	return s.reparent(ctx, sessionID, mvc, todo, "", order, at, "Subtask promoted")
+}
+
+// DemoteTodo turns a top-level todo into the last subtask of the top-level
+// todo before it. Todos that have subtasks of their own cannot be demoted.
+func (s *TodoService) DemoteTodo(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, id string) error {
+	if mvc.ReadOnly() {
+		return domain.ErrForbidden
+	}
+	todo, _ := mvc.Find(id)
+	if todo == nil || todo.ParentID != "" {
+		return nil
+	}
+	if len(mvc.Children(id)) > 0 {
+		return ErrNestedSubtask
+	}
+
+	top := mvc.Children("")
+	index := slices.Index(top, todo)
+	if index < 1 {
+		return ErrInvalidMove
+	}
+	parent := top[index-1]
+	order := append(mvc.Children(parent.ID), todo)
+	return s.reparent(ctx, sessionID, mvc, todo, parent.ID, order, len(order)-1, "Todo demoted")
+}
+
+// reparent moves todo under the todo with ID parentID, or to the top level if
+// parentID is empty, at index at of order, its new siblings including itself.
+// The move is recorded in the session's history under label.
+func (s *TodoService) reparent(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, todo *todocomponents.Todo, parentID string, order []*todocomponents.Todo, at int, label string) error {
+	before := snapshot(mvc.Todos)
+	var respaced, recorded []domain.Event
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		position, moved, err := s.place(txCtx, order, at)
+		if err != nil {
+			return err
+		}
+		for _, sibling := range moved {
+			respaced = append(respaced, domain.TodoMoved{TodoID: sibling.ID})
+		}
+
+		rows, err := s.todoRepo.SetTodoParent(txCtx, queries.SetTodoParentParams{
+			ParentID: parentKey(parentID),
+			Position: position,
+			ID:       todo.ID,
+			Version:  todo.Version,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move todo: %w", err)
+		}
+		if rows == 0 {
+			return &domain.ConflictError{Entity: "todo", ID: todo.ID}
+		}
+		todo.ParentID = parentID
+		todo.Position = position
+		todo.Version++
+		recorded, err = s.record(txCtx, sessionID, mvc, label, before)
+		return err
+	}); err != nil {
+		revertTodos(mvc, before)
+		return s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	sortByPosition(mvc.Todos)
+	events := append([]domain.Event{domain.TodoMoved{TodoID: todo.ID}}, respaced...)
+	return s.publish(ctx, sessionID, mvc, append(events, recorded...)...)
+}
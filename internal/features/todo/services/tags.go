@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+
+	"github.com/google/uuid"
+)
+
+// tagPattern matches a tag name: letters, digits, dashes and underscores.
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// normalizeTag returns the stored form of a tag, lowercase and without the
+// leading #, or "" if tag is not a valid tag.
+func normalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if !tagPattern.MatchString(tag) {
+		return ""
+	}
+	return tag
+}
+
+// ValidTag reports whether tag, with or without its leading #, is a valid
+// tag name: letters, digits, dashes and underscores.
+func ValidTag(tag string) bool {
+	return normalizeTag(tag) != ""
+}
+
+// normalizeTags returns the valid tags among tags in stored form, sorted and
+// without duplicates.
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		if tag = normalizeTag(tag); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// parseTags splits the #tags out of the text of a todo. It returns the text
+// without them and the tags in stored form. Text that consists of tags only
+// is kept as it is and not tagged, so that a todo never ends up empty.
+func parseTags(text string) (string, []string) {
+	var words, tags []string
+	for _, word := range strings.Fields(text) {
+		if tag := normalizeTag(word); strings.HasPrefix(word, "#") && tag != "" {
+			tags = append(tags, tag)
+		} else {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		return text, nil
+	}
+	return strings.Join(words, " "), normalizeTags(tags)
+}
+
+// setTags replaces the tags of a todo in a list, creating tags the list does
+// not have yet.
+func (s *TodoService) setTags(ctx context.Context, listID string, todo *todocomponents.Todo, tags []string) error {
+	if err := s.tagRepo.DeleteTodoTags(ctx, todo.ID); err != nil {
+		return fmt.Errorf("failed to delete todo tags: %w", err)
+	}
+	for _, name := range tags {
+		tagID, err := s.tagRepo.UpsertTag(ctx, queries.UpsertTagParams{
+			ID:     uuid.New().String(),
+			ListID: listID,
+			Name:   name,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save tag: %w", err)
+		}
+		if err := s.tagRepo.AddTodoTag(ctx, queries.AddTodoTagParams{
+			TodoID: todo.ID,
+			TagID:  tagID,
+		}); err != nil {
+			return fmt.Errorf("failed to tag todo: %w", err)
+		}
+	}
+	todo.Tags = tags
+	return nil
+}
@@ -6,112 +6,193 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"net/http"
+	"strings"
+	"time"
 
 	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
 	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
 	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/sessions"
 	"github.com/samber/lo"
 )
 
+// ErrNotPublished is returned, wrapped, by mutations that were committed but
+// whose events could not be published. The change is saved; viewers of the
+// list only see it once they reload.
+var ErrNotPublished = errors.New("failed to publish events")
+
 // TodoService provides business logic for managing todos.
 type TodoService struct {
-	todoRepo    domain.TodoRepository
-	sessionRepo domain.SessionRepository
-	store       sessions.Store
+	uow          domain.UnitOfWork
+	todoRepo     domain.TodoRepository
+	tagRepo      domain.TagRepository
+	historyRepo  domain.HistoryRepository
+	auditRepo    domain.AuditRepository
+	reminderRepo domain.ReminderRepository
+	listRepo     domain.ListRepository
+	sessionRepo  domain.SessionRepository
+	events       domain.EventPublisher
+	clock        domain.Clock
 }
 
 // NewTodoService creates a new TodoService with the given repositories.
-func NewTodoService(todoRepo domain.TodoRepository, sessionRepo domain.SessionRepository, store sessions.Store) *TodoService {
+// Mutations that touch more than one row run inside a uow transaction.
+// Every successful mutation, and every one rejected by a version conflict,
+// is published through events: changes to todos to everyone viewing the
+// list, changes to the UI state only to the session's own views. Changes to
+// todos are recorded in historyRepo so that the session can undo them, and
+// logged to auditRepo in the same transaction. The reminders members set on
+// todos are kept in reminderRepo. The service takes the time from clock, such
+// as the day a recurring todo next falls due after it is completed.
+func NewTodoService(uow domain.UnitOfWork, todoRepo domain.TodoRepository, tagRepo domain.TagRepository, historyRepo domain.HistoryRepository, auditRepo domain.AuditRepository, reminderRepo domain.ReminderRepository, listRepo domain.ListRepository, sessionRepo domain.SessionRepository, events domain.EventPublisher, clock domain.Clock) *TodoService {
 	return &TodoService{
-		todoRepo:    todoRepo,
-		sessionRepo: sessionRepo,
-		store:       store,
+		uow:          uow,
+		todoRepo:     todoRepo,
+		tagRepo:      tagRepo,
+		historyRepo:  historyRepo,
+		auditRepo:    auditRepo,
+		reminderRepo: reminderRepo,
+		listRepo:     listRepo,
+		sessionRepo:  sessionRepo,
+		events:       events,
+		clock:        clock,
 	}
 }
 
-// GetSessionMVC retrieves the TodoMVC state for the current session.
-func (s *TodoService) GetSessionMVC(w http.ResponseWriter, r *http.Request) (string, *todocomponents.TodoMVC, error) {
-	ctx := r.Context()
-	sessionID, err := s.upsertSessionID(r, w)
+// GetMVC gets the TodoMVC state of a list as seen by the given session.
+// The session ID is the owner ID resolved by the session middleware: the
+// user ID when signed in, the anonymous session ID otherwise. It returns
+// ErrListNotFound unless the session is a member of the list.
+func (s *TodoService) GetMVC(ctx context.Context, sessionID, listID string) (*todocomponents.TodoMVC, error) {
+	role, err := memberRole(ctx, s.listRepo, listID, sessionID)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to get session id: %w", err)
+		return nil, err
 	}
-
-	mvc, err := s.GetMVCBySessionID(ctx, sessionID)
+	list, err := s.listRepo.GetList(ctx, listID)
 	if err != nil {
-		return "", nil, err
+		return nil, fmt.Errorf("failed to get list: %w", err)
 	}
 
-	return sessionID, mvc, nil
-}
-
-// GetMVCBySessionID gets the TodoMVC state for a given session ID.
-// This is used by SSE handlers that already have the session ID.
-func (s *TodoService) GetMVCBySessionID(ctx context.Context, sessionID string) (*todocomponents.TodoMVC, error) {
 	// Get todos from database
-	dbTodos, err := s.todoRepo.GetTodosByUser(ctx, sessionID)
+	dbTodos, err := s.todoRepo.GetTodosByList(ctx, listKey(listID))
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("failed to get todos: %w", err)
 	}
 
-	// Get session to load UI state
-	session, err := s.sessionRepo.GetSession(ctx, sessionID)
+	// Get the session's view of the list to load UI state
+	view, err := s.sessionRepo.GetListView(ctx, queries.GetListViewParams{
+		MemberID: sessionID,
+		ListID:   listID,
+	})
 	mode := todocomponents.TodoViewModeAll
-	editingIdx := -1
+	editingID := ""
+	var tagFilter []string
+	version := int64(0)
 
 	if err == nil {
-		// Session exists, load UI state
-		if session.Mode.Valid {
-			mode = todocomponents.TodoViewMode(session.Mode.Int64)
-		}
-		if session.EditingIdx.Valid {
-			editingIdx = int(session.EditingIdx.Int64)
+		version = view.Version
+		mode = todocomponents.TodoViewMode(view.Mode)
+		if view.EditingID.Valid {
+			editingID = view.EditingID.String
 		}
+		tagFilter = strings.Fields(view.TagFilter)
 	}
 
 	mvc := &todocomponents.TodoMVC{
-		Mode:       mode,
-		EditingIdx: editingIdx,
+		ListID:    list.ID,
+		ListName:  list.Name,
+		Role:      role,
+		Archived:  list.ArchivedAt.Valid,
+		Mode:      mode,
+		TagFilter: tagFilter,
+		EditingID: editingID,
+		Version:   version,
 	}
 
-	// Convert database todos to component todos
-	if len(dbTodos) == 0 {
-		// Initialize with default todos
-		s.resetMVC(mvc)
-		// Save defaults to database
-		if err := s.saveMVCToDB(ctx, sessionID, mvc); err != nil {
-			return nil, fmt.Errorf("failed to save default todos: %w", err)
-		}
-	} else {
-		mvc.Todos = make([]*todocomponents.Todo, len(dbTodos))
-		for i, dbTodo := range dbTodos {
-			mvc.Todos[i] = &todocomponents.Todo{
-				Text:      dbTodo.Task,
-				Completed: dbTodo.Completed.Int64 == 1,
-			}
-		}
+	if mvc.Todos, err = todosFromDB(ctx, s.tagRepo, listID, dbTodos); err != nil {
+		return nil, err
 	}
 
+	if err := s.loadReminders(ctx, sessionID, mvc); err != nil {
+		return nil, err
+	}
 	return mvc, nil
 }
 
-// SaveMVC persists the TodoMVC state to the database.
-func (s *TodoService) SaveMVC(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC) error {
-	return s.saveMVCToDB(ctx, sessionID, mvc)
+// todosFromDB converts the stored todos of a list to component todos,
+// together with their tags.
+func todosFromDB(ctx context.Context, tagRepo domain.TagRepository, listID string, dbTodos []queries.Todo) ([]*todocomponents.Todo, error) {
+	dbTags, err := tagRepo.GetTodoTagsByList(ctx, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	tags := make(map[string][]string)
+	for _, dbTag := range dbTags {
+		tags[dbTag.TodoID] = append(tags[dbTag.TodoID], dbTag.Name)
+	}
+
+	todos := make([]*todocomponents.Todo, len(dbTodos))
+	for i, dbTodo := range dbTodos {
+		todos[i] = &todocomponents.Todo{
+			ID:              dbTodo.ID,
+			Text:            dbTodo.Task,
+			Completed:       dbTodo.Completed.Int64 == 1,
+			DueAt:           dueAtFromDB(dbTodo.DueAt),
+			Priority:        domain.Priority(dbTodo.Priority.Int64),
+			Tags:            tags[dbTodo.ID],
+			Position:        dbTodo.Position,
+			ParentID:        dbTodo.ParentID.String,
+			Recurrence:      dbTodo.Recurrence,
+			RecurrenceStart: dueAtFromDB(dbTodo.RecurrenceStart),
+			Version:         dbTodo.Version,
+			CreatedAt:       dbTodo.CreatedAt.Time,
+		}
+	}
+	return todos, nil
 }
 
-// ResetMVC resets the TodoMVC to its initial state.
-func (s *TodoService) ResetMVC(mvc *todocomponents.TodoMVC) {
+// ResetMVC replaces all todos with the defaults and resets the UI state.
+func (s *TodoService) ResetMVC(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC) error {
+	if mvc.ReadOnly() {
+		return domain.ErrForbidden
+	}
+	before := snapshot(mvc.Todos)
 	s.resetMVC(mvc)
+
+	var recorded []domain.Event
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.tagRepo.DeleteTodoTagsByList(txCtx, mvc.ListID); err != nil {
+			return fmt.Errorf("failed to delete existing todo tags: %w", err)
+		}
+		if err := s.todoRepo.DeleteAllTodosByList(txCtx, listKey(mvc.ListID)); err != nil {
+			return fmt.Errorf("failed to delete existing todos: %w", err)
+		}
+		if err := insertTodos(txCtx, s.todoRepo, sessionID, mvc.ListID, mvc.Todos); err != nil {
+			return err
+		}
+		var err error
+		if recorded, err = s.record(txCtx, sessionID, mvc, "Todos reset", before); err != nil {
+			return err
+		}
+		return s.saveUIState(txCtx, sessionID, mvc)
+	}); err != nil {
+		return s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	return s.publish(ctx, sessionID, mvc, append([]domain.Event{domain.TodosReset{}}, recorded...)...)
 }
 
-// ToggleTodo toggles the completion state of a todo by index.
-func (s *TodoService) ToggleTodo(mvc *todocomponents.TodoMVC, index int) {
-	if index < 0 {
+// ToggleTodo toggles the completion state of a todo by ID.
+// An empty ID toggles all todos at once. If withSubtasks is set, completing a
+// todo completes its open subtasks in the same transaction. Completing a
+// recurring todo creates its next occurrence in the same transaction.
+func (s *TodoService) ToggleTodo(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, id string, withSubtasks bool) error {
+	if mvc.ReadOnly() {
+		return domain.ErrForbidden
+	}
+	before := snapshot(mvc.Todos)
+	var recorded []domain.Event
+	if id == "" {
 		setCompletedTo := false
 		for _, todo := range mvc.Todos {
 			if !todo.Completed {
@@ -119,118 +200,564 @@ func (s *TodoService) ToggleTodo(mvc *todocomponents.TodoMVC, index int) {
 				break
 			}
 		}
-		for _, todo := range mvc.Todos {
-			todo.Completed = setCompletedTo
+		toggled := lo.Filter(mvc.Todos, func(todo *todocomponents.Todo, _ int) bool {
+			return todo.Completed != setCompletedTo
+		})
+		events := []domain.Event{domain.TodoToggled{
+			TodoIDs:   lo.Map(toggled, func(todo *todocomponents.Todo, _ int) string { return todo.ID }),
+			Completed: setCompletedTo,
+		}}
+
+		if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+			if err := s.setCompleted(txCtx, toggled, setCompletedTo); err != nil {
+				return err
+			}
+			if setCompletedTo {
+				recurred, err := s.recur(txCtx, sessionID, mvc, lo.Filter(toggled, func(todo *todocomponents.Todo, _ int) bool {
+					return todo.Recurrence != ""
+				}), s.today())
+				if err != nil {
+					return err
+				}
+				events = append(events, recurred...)
+			}
+			var err error
+			recorded, err = s.record(txCtx, sessionID, mvc, "All todos toggled", before)
+			return err
+		}); err != nil {
+			revertTodos(mvc, before)
+			return s.publishConflict(ctx, sessionID, mvc, err)
+		}
+		return s.publish(ctx, sessionID, mvc, append(events, recorded...)...)
+	}
+
+	todo, _ := mvc.Find(id)
+	if todo == nil {
+		return nil
+	}
+	var subtasks []*todocomponents.Todo
+	if withSubtasks && !todo.Completed {
+		subtasks = lo.Reject(mvc.Children(id), func(subtask *todocomponents.Todo, _ int) bool {
+			return subtask.Completed
+		})
+	}
+
+	var recurred []domain.Event
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		rows, err := s.todoRepo.ToggleTodoCompleted(txCtx, queries.ToggleTodoCompletedParams{
+			ID:      id,
+			Version: todo.Version,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to toggle todo: %w", err)
+		}
+		if rows == 0 {
+			return &domain.ConflictError{Entity: "todo", ID: id}
 		}
-	} else if index < len(mvc.Todos) {
-		todo := mvc.Todos[index]
 		todo.Completed = !todo.Completed
+		todo.Version++
+		if err := s.setCompleted(txCtx, subtasks, true); err != nil {
+			return err
+		}
+		completed := lo.Filter(append([]*todocomponents.Todo{todo}, subtasks...), func(t *todocomponents.Todo, _ int) bool {
+			return t.Completed && t.Recurrence != ""
+		})
+		if recurred, err = s.recur(txCtx, sessionID, mvc, completed, s.today()); err != nil {
+			return err
+		}
+		recorded, err = s.record(txCtx, sessionID, mvc, "Todo toggled", before)
+		return err
+	}); err != nil {
+		revertTodos(mvc, before)
+		return s.publishConflict(ctx, sessionID, mvc, err)
 	}
+	ids := []string{id}
+	for _, subtask := range subtasks {
+		ids = append(ids, subtask.ID)
+	}
+	events := append([]domain.Event{domain.TodoToggled{TodoIDs: ids, Completed: todo.Completed}}, recurred...)
+	return s.publish(ctx, sessionID, mvc, append(events, recorded...)...)
 }
 
-// EditTodo updates or creates a todo with the given text.
-func (s *TodoService) EditTodo(mvc *todocomponents.TodoMVC, index int, text string) {
-	if index >= 0 && index < len(mvc.Todos) {
-		mvc.Todos[index].Text = text
-	} else if index < 0 {
-		mvc.Todos = append(mvc.Todos, &todocomponents.Todo{
-			Text:      text,
-			Completed: false,
+// setCompleted sets the completion state of todos, failing with a
+// *domain.ConflictError if one of them was changed since mvc was loaded.
+func (s *TodoService) setCompleted(ctx context.Context, todos []*todocomponents.Todo, completed bool) error {
+	for _, todo := range todos {
+		rows, err := s.todoRepo.SetTodoCompleted(ctx, queries.SetTodoCompletedParams{
+			Completed: completedValue(completed),
+			ID:        todo.ID,
+			Version:   todo.Version,
 		})
+		if err != nil {
+			return fmt.Errorf("failed to toggle todo: %w", err)
+		}
+		if rows == 0 {
+			return &domain.ConflictError{Entity: "todo", ID: todo.ID}
+		}
+		todo.Completed = completed
+		todo.Version++
 	}
-	mvc.EditingIdx = -1
+	return nil
 }
 
-// DeleteTodo removes a todo by index or clears completed todos if index is -1.
-func (s *TodoService) DeleteTodo(mvc *todocomponents.TodoMVC, index int) {
-	if index >= 0 && index < len(mvc.Todos) {
-		mvc.Todos = append(mvc.Todos[:index], mvc.Todos[index+1:]...)
-	} else if index < 0 {
-		mvc.Todos = lo.Filter(mvc.Todos, func(todo *todocomponents.Todo, _ int) bool {
-			return !todo.Completed
-		})
+// TodoDetails are the parts of a todo a member can edit.
+type TodoDetails struct {
+	// Text may contain #tags, which replace the tags of the todo.
+	Text string
+	// DueAt is the day the todo is due at midnight UTC, or nil if it has no due date.
+	DueAt    *time.Time
+	Priority domain.Priority
+	// Recurrence is how the todo repeats. Recurring todos without a due
+	// date are due today.
+	Recurrence domain.Recurrence
+	// Completed, if not nil, completes or reopens the todo with the same
+	// change, as toggling it would.
+	Completed *bool
+
+	// start is the start of the rule a new occurrence of a recurring todo
+	// takes over.
+	start *time.Time
+}
+
+// recurrenceStart returns the start of the rule of a todo saved with details:
+// the start it has if neither its rule nor its due date change, or else its
+// new due date. todo is nil for new todos. It returns nil for details without
+// a rule.
+func recurrenceStart(todo *todocomponents.Todo, details TodoDetails) *time.Time {
+	switch {
+	case details.Recurrence.IsZero():
+		return nil
+	case details.start != nil:
+		return details.start
+	case todo != nil && todo.RecurrenceStart != nil && todo.Recurrence == details.Recurrence.String() &&
+		todo.DueAt != nil && details.DueAt != nil && todo.DueAt.Equal(*details.DueAt):
+		return todo.RecurrenceStart
+	default:
+		return details.DueAt
+	}
+}
+
+// EditTodo updates the details of a todo by ID, or creates a new todo if the ID is empty.
+// The #tags in the text are stored as the tags of the todo, not as part of its text.
+// The todo, its tags, its completion state and the cleared editing state are saved in
+// one transaction.
+// It returns the created or updated todo, or nil if no todo has the given ID.
+func (s *TodoService) EditTodo(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, id string, details TodoDetails) (*todocomponents.Todo, error) {
+	if mvc.ReadOnly() {
+		return nil, domain.ErrForbidden
+	}
+	if !details.Priority.Valid() {
+		return nil, fmt.Errorf("invalid priority %d", details.Priority)
+	}
+	text, tags := parseTags(details.Text)
+	if !details.Recurrence.IsZero() && details.DueAt == nil {
+		today := s.today()
+		details.DueAt = &today
+	}
+	before := snapshot(mvc.Todos)
+	var saved *todocomponents.Todo
+	var events []domain.Event
+	err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if id == "" {
+			todo, err := s.createTodo(txCtx, sessionID, mvc, "", text, tags, details)
+			if err != nil {
+				return err
+			}
+			saved = todo
+			events = append(events, domain.TodoCreated{TodoID: todo.ID})
+		} else if todo, _ := mvc.Find(id); todo != nil {
+			start := recurrenceStart(todo, details)
+			rows, err := s.todoRepo.UpdateTodoTask(txCtx, queries.UpdateTodoTaskParams{
+				Task:            text,
+				DueAt:           dueAtValue(details.DueAt),
+				Priority:        priorityValue(details.Priority),
+				Recurrence:      details.Recurrence.String(),
+				RecurrenceStart: dueAtValue(start),
+				ID:              id,
+				Version:         todo.Version,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update todo: %w", err)
+			}
+			if rows == 0 {
+				return &domain.ConflictError{Entity: "todo", ID: id}
+			}
+			if err := s.setTags(txCtx, mvc.ListID, todo, tags); err != nil {
+				return err
+			}
+			todo.Text = text
+			todo.DueAt = details.DueAt
+			todo.Priority = details.Priority
+			todo.Recurrence = details.Recurrence.String()
+			todo.RecurrenceStart = start
+			todo.Version++
+			saved = todo
+			events = append(events, domain.TodoEdited{TodoID: id})
+		}
+		if saved != nil {
+			completed, err := s.complete(txCtx, sessionID, mvc, saved, details.Completed)
+			if err != nil {
+				return err
+			}
+			events = append(events, completed...)
+			label := "Todo updated"
+			if id == "" {
+				label = "Todo created"
+			}
+			recorded, err := s.record(txCtx, sessionID, mvc, label, before)
+			if err != nil {
+				return err
+			}
+			events = append(events, recorded...)
+		}
+
+		if mvc.EditingID == "" {
+			return nil
+		}
+		mvc.EditingID = ""
+		events = append(events, domain.EditingChanged{})
+		return s.saveUIState(txCtx, sessionID, mvc)
+	})
+	if err != nil {
+		return nil, s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	return saved, s.publish(ctx, sessionID, mvc, events...)
+}
+
+// complete sets the completion state of a todo to completed, unless it is
+// nil, as toggling the todo would: completing a recurring todo creates its
+// next occurrence. It returns the events announcing the changes.
+func (s *TodoService) complete(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, todo *todocomponents.Todo, completed *bool) ([]domain.Event, error) {
+	if completed == nil || *completed == todo.Completed {
+		return nil, nil
+	}
+	if err := s.setCompleted(ctx, []*todocomponents.Todo{todo}, *completed); err != nil {
+		return nil, err
+	}
+	events := []domain.Event{domain.TodoToggled{TodoIDs: []string{todo.ID}, Completed: *completed}}
+	if !*completed || todo.Recurrence == "" {
+		return events, nil
+	}
+	recurred, err := s.recur(ctx, sessionID, mvc, []*todocomponents.Todo{todo}, s.today())
+	if err != nil {
+		return nil, err
 	}
+	return append(events, recurred...), nil
+}
+
+// createTodo stores a new todo at the end of the todos with the given parent,
+// or of the top-level todos if parentID is empty, and adds it to mvc.
+func (s *TodoService) createTodo(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, parentID, text string, tags []string, details TodoDetails) (*todocomponents.Todo, error) {
+	todo := &todocomponents.Todo{
+		ID:              uuid.New().String(),
+		Text:            text,
+		Completed:       false,
+		DueAt:           details.DueAt,
+		Priority:        details.Priority,
+		Position:        nextPosition(mvc.Children(parentID)),
+		ParentID:        parentID,
+		Recurrence:      details.Recurrence.String(),
+		RecurrenceStart: recurrenceStart(nil, details),
+		Version:         1,
+		CreatedAt:       s.clock.Now().UTC(),
+	}
+	mvc.Todos = append(mvc.Todos, todo)
+
+	if err := insertTodos(ctx, s.todoRepo, sessionID, mvc.ListID, []*todocomponents.Todo{todo}); err != nil {
+		return nil, err
+	}
+	if err := s.setTags(ctx, mvc.ListID, todo, tags); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// DeleteTodo removes a todo by ID or clears completed todos if the ID is empty.
+// Subtasks are removed with their parent, both when it is deleted and when it
+// is cleared as completed.
+// If the deleted todo was being edited, the editing state is cleared in the same transaction.
+func (s *TodoService) DeleteTodo(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, id string) error {
+	if mvc.ReadOnly() {
+		return domain.ErrForbidden
+	}
+	before := snapshot(mvc.Todos)
+	var events []domain.Event
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		label := "Todo deleted"
+		if id == "" {
+			label = "Completed todos cleared"
+			completed, active := lo.FilterReject(mvc.Todos, func(todo *todocomponents.Todo, _ int) bool {
+				if todo.Completed {
+					return true
+				}
+				parent, _ := mvc.Find(todo.ParentID)
+				return parent != nil && parent.Completed
+			})
+			mvc.Todos = active
+
+			if err := s.tagRepo.DeleteCompletedTodoTagsByList(txCtx, listKey(mvc.ListID)); err != nil {
+				return fmt.Errorf("failed to clear tags of completed todos: %w", err)
+			}
+			if err := s.todoRepo.DeleteCompletedTodosByList(txCtx, listKey(mvc.ListID)); err != nil {
+				return fmt.Errorf("failed to clear completed todos: %w", err)
+			}
+			events = append(events, domain.TodosCleared{
+				TodoIDs: lo.Map(completed, func(todo *todocomponents.Todo, _ int) string { return todo.ID }),
+			})
+		} else if todo, index := mvc.Find(id); todo != nil {
+			rows, err := s.todoRepo.DeleteTodo(txCtx, queries.DeleteTodoParams{
+				ID:      id,
+				Version: todo.Version,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to delete todo: %w", err)
+			}
+			if rows == 0 {
+				return &domain.ConflictError{Entity: "todo", ID: id}
+			}
+			if err := s.tagRepo.DeleteTodoTags(txCtx, id); err != nil {
+				return fmt.Errorf("failed to delete todo tags: %w", err)
+			}
+			mvc.Todos = append(mvc.Todos[:index], mvc.Todos[index+1:]...)
+			events = append(events, domain.TodoDeleted{TodoID: id})
+
+			if err := s.deleteSubtasks(txCtx, mvc, id); err != nil {
+				return err
+			}
+			for _, subtask := range mvc.Children(id) {
+				events = append(events, domain.TodoDeleted{TodoID: subtask.ID})
+			}
+			mvc.Todos = lo.Reject(mvc.Todos, func(todo *todocomponents.Todo, _ int) bool {
+				return todo.ParentID == id
+			})
+		}
+		recorded, err := s.record(txCtx, sessionID, mvc, label, before)
+		if err != nil {
+			return err
+		}
+		events = append(events, recorded...)
+
+		if _, index := mvc.Find(mvc.EditingID); mvc.EditingID == "" || index >= 0 {
+			return nil
+		}
+		mvc.EditingID = ""
+		events = append(events, domain.EditingChanged{})
+		return s.saveUIState(txCtx, sessionID, mvc)
+	}); err != nil {
+		return s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	return s.publish(ctx, sessionID, mvc, events...)
+}
+
+// deleteSubtasks deletes the subtasks of the todo with the given ID and their tags.
+func (s *TodoService) deleteSubtasks(ctx context.Context, mvc *todocomponents.TodoMVC, id string) error {
+	for _, subtask := range mvc.Children(id) {
+		if err := s.tagRepo.DeleteTodoTags(ctx, subtask.ID); err != nil {
+			return fmt.Errorf("failed to delete todo tags: %w", err)
+		}
+	}
+	if err := s.todoRepo.DeleteTodosByParent(ctx, parentKey(id)); err != nil {
+		return fmt.Errorf("failed to delete subtasks: %w", err)
+	}
+	return nil
 }
 
 // SetMode changes the view filter mode for todos.
-func (s *TodoService) SetMode(mvc *todocomponents.TodoMVC, mode todocomponents.TodoViewMode) {
+func (s *TodoService) SetMode(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, mode todocomponents.TodoViewMode) error {
 	mvc.Mode = mode
+	if err := s.saveUIState(ctx, sessionID, mvc); err != nil {
+		return s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	return s.publish(ctx, sessionID, mvc, domain.ViewModeChanged{Mode: int64(mode)})
 }
 
-// StartEditing puts a todo into edit mode.
-func (s *TodoService) StartEditing(mvc *todocomponents.TodoMVC, index int) {
-	mvc.EditingIdx = index
+// SetTagFilter changes the tags the todos are filtered by, on top of the
+// view mode. Invalid tags are ignored; no tags shows todos regardless of tags.
+func (s *TodoService) SetTagFilter(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, tags []string) error {
+	mvc.TagFilter = normalizeTags(tags)
+	if err := s.saveUIState(ctx, sessionID, mvc); err != nil {
+		return s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	return s.publish(ctx, sessionID, mvc, domain.TagFilterChanged{Tags: mvc.TagFilter})
+}
+
+// StartEditing puts a todo into edit mode by ID.
+func (s *TodoService) StartEditing(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, id string) error {
+	if mvc.ReadOnly() {
+		return domain.ErrForbidden
+	}
+	if todo, _ := mvc.Find(id); todo == nil {
+		return nil
+	}
+	mvc.EditingID = id
+	if err := s.saveUIState(ctx, sessionID, mvc); err != nil {
+		return s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	return s.publish(ctx, sessionID, mvc, domain.EditingChanged{TodoID: id})
 }
 
 // CancelEditing exits edit mode without saving.
-func (s *TodoService) CancelEditing(mvc *todocomponents.TodoMVC) {
-	mvc.EditingIdx = -1
+func (s *TodoService) CancelEditing(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC) error {
+	mvc.EditingID = ""
+	if err := s.saveUIState(ctx, sessionID, mvc); err != nil {
+		return s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	return s.publish(ctx, sessionID, mvc, domain.EditingChanged{})
 }
 
-func (s *TodoService) saveMVCToDB(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC) error {
-	// Delete all existing todos for this user
-	if err := s.todoRepo.DeleteAllTodosByUser(ctx, sessionID); err != nil {
-		return fmt.Errorf("failed to delete existing todos: %w", err)
+// publish sends the events of a committed mutation to their subscribers.
+// Changes to the UI state and to the session's reminders go to the topic of
+// the session's view of the list in mvc, everything else to the topic of the
+// list.
+func (s *TodoService) publish(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, events ...domain.Event) error {
+	var listEvents, sessionEvents []domain.Event
+	for _, event := range events {
+		switch e := event.(type) {
+		case domain.ViewModeChanged, domain.TagFilterChanged, domain.EditingChanged, domain.ReminderChanged:
+			sessionEvents = append(sessionEvents, event)
+		case domain.ConflictDetected:
+			if e.Entity == "session" {
+				sessionEvents = append(sessionEvents, event)
+			} else {
+				listEvents = append(listEvents, event)
+			}
+		default:
+			listEvents = append(listEvents, event)
+		}
 	}
 
-	// Insert all todos
-	for _, todo := range mvc.Todos {
-		completed := int64(0)
-		if todo.Completed {
-			completed = 1
+	if len(listEvents) > 0 {
+		if err := s.events.Publish(ctx, domain.ListTopic(mvc.ListID), listEvents...); err != nil {
+			return fmt.Errorf("%w: %w", ErrNotPublished, err)
 		}
+	}
+	if len(sessionEvents) > 0 {
+		if err := s.events.Publish(ctx, domain.ViewTopic(mvc.ListID, sessionID), sessionEvents...); err != nil {
+			return fmt.Errorf("%w: %w", ErrNotPublished, err)
+		}
+	}
+	return nil
+}
+
+// publishConflict tells the subscribers of the list or session about a
+// version conflict in err, so that every open view re-renders the state that
+// won. It returns err.
+func (s *TodoService) publishConflict(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, err error) error {
+	var conflict *domain.ConflictError
+	if !errors.As(err, &conflict) {
+		return err
+	}
+	return errors.Join(err, s.publish(ctx, sessionID, mvc, domain.ConflictDetected{
+		Entity: conflict.Entity,
+		ID:     conflict.ID,
+	}))
+}
 
-		todoID := uuid.New().String()
-		if err := s.todoRepo.CreateTodo(ctx, queries.CreateTodoParams{
-			ID:        todoID,
-			UserID:    sessionID,
-			Task:      todo.Text,
-			Completed: sql.NullInt64{Int64: completed, Valid: true},
+// insertTodos stores todos in a list, created by the given member. The
+// stored todos start out at version 1.
+func insertTodos(ctx context.Context, todoRepo domain.TodoRepository, creatorID, listID string, todos []*todocomponents.Todo) error {
+	for _, todo := range todos {
+		if todo.ID == "" {
+			todo.ID = uuid.New().String()
+		}
+		if err := todoRepo.CreateTodo(ctx, queries.CreateTodoParams{
+			ID:              todo.ID,
+			UserID:          creatorID,
+			ListID:          listKey(listID),
+			Task:            todo.Text,
+			Completed:       completedValue(todo.Completed),
+			DueAt:           dueAtValue(todo.DueAt),
+			Priority:        priorityValue(todo.Priority),
+			Position:        todo.Position,
+			ParentID:        parentKey(todo.ParentID),
+			Recurrence:      todo.Recurrence,
+			RecurrenceStart: dueAtValue(todo.RecurrenceStart),
 		}); err != nil {
 			return fmt.Errorf("failed to create todo: %w", err)
 		}
+		todo.Version = 1
 	}
+	return nil
+}
 
-	// Save UI state to session
-	if err := s.sessionRepo.UpsertSession(ctx, queries.UpsertSessionParams{
-		ID:         sessionID,
-		Data:       "",
-		Mode:       sql.NullInt64{Int64: int64(mvc.Mode), Valid: true},
-		EditingIdx: sql.NullInt64{Int64: int64(mvc.EditingIdx), Valid: true},
-	}); err != nil {
-		return fmt.Errorf("failed to save session state: %w", err)
+// saveUIState writes the UI state of the session's view of the list, failing
+// with a *domain.ConflictError if the view was saved by another request since
+// mvc was loaded.
+func (s *TodoService) saveUIState(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC) error {
+	rows, err := s.sessionRepo.UpsertListView(ctx, queries.UpsertListViewParams{
+		MemberID:  sessionID,
+		ListID:    mvc.ListID,
+		Mode:      int64(mvc.Mode),
+		EditingID: sql.NullString{String: mvc.EditingID, Valid: mvc.EditingID != ""},
+		TagFilter: strings.Join(mvc.TagFilter, " "),
+		Version:   mvc.Version,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save view state: %w", err)
 	}
-
+	if rows == 0 {
+		return &domain.ConflictError{Entity: "session", ID: sessionID}
+	}
+	mvc.Version++
 	return nil
 }
 
-func (s *TodoService) resetMVC(mvc *todocomponents.TodoMVC) {
-	mvc.Mode = todocomponents.TodoViewModeAll
-	mvc.Todos = []*todocomponents.Todo{
-		{Text: "Learn any backend language", Completed: true},
-		{Text: "Learn Datastar", Completed: false},
-		{Text: "Create Hypermedia", Completed: false},
-		{Text: "???", Completed: false},
-		{Text: "Profit", Completed: false},
-	}
-	mvc.EditingIdx = -1
+// listKey converts a list ID to the nullable todos.list_id column.
+func listKey(listID string) sql.NullString {
+	return sql.NullString{String: listID, Valid: true}
 }
 
-func (s *TodoService) upsertSessionID(r *http.Request, w http.ResponseWriter) (string, error) {
-	sess, err := s.store.Get(r, "connections")
-	if err != nil {
-		return "", fmt.Errorf("failed to get session: %w", err)
+// parentKey converts a parent todo ID to the nullable todos.parent_id
+// column, which is NULL for top-level todos.
+func parentKey(parentID string) sql.NullString {
+	return sql.NullString{String: parentID, Valid: parentID != ""}
+}
+
+func completedValue(completed bool) sql.NullInt64 {
+	if completed {
+		return sql.NullInt64{Int64: 1, Valid: true}
 	}
+	return sql.NullInt64{Int64: 0, Valid: true}
+}
 
-	id, ok := sess.Values["id"].(string)
+func dueAtValue(dueAt *time.Time) sql.NullTime {
+	if dueAt == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *dueAt, Valid: true}
+}
 
-	if !ok {
-		id = uuid.New().String()
-		sess.Values["id"] = id
-		if err := sess.Save(r, w); err != nil {
-			return "", fmt.Errorf("failed to save session: %w", err)
-		}
+func dueAtFromDB(dueAt sql.NullTime) *time.Time {
+	if !dueAt.Valid {
+		return nil
 	}
+	return &dueAt.Time
+}
+
+// priorityValue stores PriorityNone as NULL.
+func priorityValue(priority domain.Priority) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(priority), Valid: priority != domain.PriorityNone}
+}
 
-	return id, nil
+func (s *TodoService) resetMVC(mvc *todocomponents.TodoMVC) {
+	mvc.Mode = todocomponents.TodoViewModeAll
+	mvc.TagFilter = nil
+	mvc.Todos = defaultTodos()
+	mvc.EditingID = ""
+}
+
+// defaultTodos returns the todos a new default list starts with, and that
+// a reset brings back.
+func defaultTodos() []*todocomponents.Todo {
+	todos := []*todocomponents.Todo{
+		{ID: uuid.New().String(), Text: "Learn any backend language", Completed: true},
+		{ID: uuid.New().String(), Text: "Learn Datastar", Completed: false},
+		{ID: uuid.New().String(), Text: "Create Hypermedia", Completed: false},
+		{ID: uuid.New().String(), Text: "???", Completed: false},
+		{ID: uuid.New().String(), Text: "Profit", Completed: false},
+	}
+	for i, todo := range todos {
+		todo.Position = float64(i+1) * positionGap
+	}
+	return todos
 }
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/clock"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/pubsub"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// testOwner is the session that owns the lists of the tests.
+const testOwner = "owner"
+
+// testEnv holds services wired to an in-memory database, event bus and a
+// fake clock starting at 9:00 on monday.
+type testEnv struct {
+	store *store.SQLiteStore
+	todos *TodoService
+	lists *ListService
+	bus   *pubsub.MemoryEventBus
+	clock *clock.Fake
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	st, err := store.Open(":memory:")
+	if err != nil {
+		t.Fatalf("store.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	todoRepo := store.NewTodoRepository(st)
+	tagRepo := store.NewTagRepository(st)
+	historyRepo := store.NewHistoryRepository(st)
+	listRepo := store.NewListRepository(st)
+	bus := pubsub.NewMemoryEventBus()
+	fake := clock.NewFake(monday.Add(9 * time.Hour))
+	return &testEnv{
+		store: st,
+		todos: NewTodoService(st, todoRepo, tagRepo, historyRepo, store.NewAuditRepository(st), store.NewReminderRepository(st), listRepo, store.NewSessionRepository(st), bus, fake),
+		lists: NewListService(st, listRepo, todoRepo, tagRepo, historyRepo, store.NewAuditRepository(st), bus),
+		bus:   bus,
+		clock: fake,
+	}
+}
+
+// newList creates a list of testOwner holding a todo for each text and
+// returns its state.
+func (e *testEnv) newList(t *testing.T, texts ...string) *todocomponents.TodoMVC {
+	t.Helper()
+	ctx := context.Background()
+	list, err := e.lists.CreateList(ctx, testOwner, "Chores")
+	if err != nil {
+		t.Fatalf("CreateList: %v", err)
+	}
+	mvc := e.load(t, list.ID)
+	for _, text := range texts {
+		if _, err := e.todos.EditTodo(ctx, testOwner, mvc, "", TodoDetails{Text: text}); err != nil {
+			t.Fatalf("EditTodo: %v", err)
+		}
+	}
+	return mvc
+}
+
+// load loads the list as testOwner sees it.
+func (e *testEnv) load(t *testing.T, listID string) *todocomponents.TodoMVC {
+	t.Helper()
+	mvc, err := e.todos.GetMVC(context.Background(), testOwner, listID)
+	if err != nil {
+		t.Fatalf("GetMVC: %v", err)
+	}
+	return mvc
+}
+
+// stored returns the todos of a list as they are in the database, by ID.
+func (e *testEnv) stored(t *testing.T, listID string) map[string]queries.Todo {
+	t.Helper()
+	dbTodos, err := store.NewTodoRepository(e.store).GetTodosByList(context.Background(), listKey(listID))
+	if err != nil {
+		t.Fatalf("GetTodosByList: %v", err)
+	}
+	todos := make(map[string]queries.Todo, len(dbTodos))
+	for _, todo := range dbTodos {
+		todos[todo.ID] = todo
+	}
+	return todos
+}
+
+// nextBatch returns the first batch published to topic after seq.
+func (e *testEnv) nextBatch(t *testing.T, topic string, seq uint64) domain.EventBatch {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches, err := e.bus.Subscribe(ctx, []string{topic}, seq)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	select {
+	case batch := <-batches:
+		return batch
+	case <-time.After(time.Second):
+		t.Fatalf("nothing published to %s after %d", topic, seq)
+		return domain.EventBatch{}
+	}
+}
+
+// lastSeq returns the sequence of the last batch published.
+func (e *testEnv) lastSeq(t *testing.T) uint64 {
+	t.Helper()
+	_, last, err := e.bus.Position(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Position: %v", err)
+	}
+	return last
+}
+
+func TestToggleAllComparesVersions(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	mvc := env.newList(t, "Wash up", "Hoover")
+	stale := env.load(t, mvc.ListID)
+
+	washUp := mvc.Todos[len(mvc.Todos)-2]
+	if err := env.todos.ToggleTodo(ctx, testOwner, mvc, washUp.ID, false); err != nil {
+		t.Fatalf("ToggleTodo: %v", err)
+	}
+	want := env.stored(t, mvc.ListID)
+
+	err := env.todos.ToggleTodo(ctx, testOwner, stale, "", false)
+	var conflict *domain.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("ToggleTodo of all on a stale list = %v, want a conflict", err)
+	}
+
+	got := env.stored(t, mvc.ListID)
+	for id, todo := range want {
+		if got[id].Completed != todo.Completed || got[id].Version != todo.Version {
+			t.Errorf("todo %q changed by the failed toggle: %+v, want %+v", todo.Task, got[id], todo)
+		}
+	}
+	for _, todo := range stale.Todos {
+		if todo.Version != want[todo.ID].Version && todo.ID != washUp.ID {
+			t.Errorf("todo %q left at version %d in memory, want %d", todo.Text, todo.Version, want[todo.ID].Version)
+		}
+	}
+}
+
+func TestToggleAllOnlyChangesTodosInTheOtherState(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	mvc := env.newList(t, "Wash up", "Hoover")
+
+	washUp := mvc.Todos[len(mvc.Todos)-2]
+	if err := env.todos.ToggleTodo(ctx, testOwner, mvc, washUp.ID, false); err != nil {
+		t.Fatalf("ToggleTodo: %v", err)
+	}
+	before := env.stored(t, mvc.ListID)
+
+	if err := env.todos.ToggleTodo(ctx, testOwner, mvc, "", false); err != nil {
+		t.Fatalf("ToggleTodo of all: %v", err)
+	}
+
+	after := env.stored(t, mvc.ListID)
+	for _, todo := range mvc.Todos {
+		stored := after[todo.ID]
+		if stored.Completed.Int64 != 1 {
+			t.Errorf("todo %q not completed", todo.Text)
+		}
+		wantVersion := before[todo.ID].Version + 1
+		if before[todo.ID].Completed.Int64 == 1 {
+			wantVersion = before[todo.ID].Version
+		}
+		if stored.Version != wantVersion {
+			t.Errorf("todo %q at version %d, want %d", todo.Text, stored.Version, wantVersion)
+		}
+		if todo.Version != stored.Version {
+			t.Errorf("todo %q at version %d in memory, %d stored", todo.Text, todo.Version, stored.Version)
+		}
+	}
+}
+
+func TestEditTodoCompletesInTheSameChange(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	mvc := env.newList(t)
+	completed, reopened := true, false
+
+	seq := env.lastSeq(t)
+	todo, err := env.todos.EditTodo(ctx, testOwner, mvc, "", TodoDetails{Text: "Wash up", Completed: &completed})
+	if err != nil {
+		t.Fatalf("EditTodo: %v", err)
+	}
+	batch := env.nextBatch(t, domain.ListTopic(mvc.ListID), seq)
+	if !slices.ContainsFunc(batch.Events, func(event domain.Event) bool {
+		toggled, ok := event.(domain.TodoToggled)
+		return ok && toggled.Completed && slices.Equal(toggled.TodoIDs, []string{todo.ID})
+	}) {
+		t.Errorf("creation published %+v, want the todo completed in the same batch", batch.Events)
+	}
+	if stored := env.stored(t, mvc.ListID)[todo.ID]; stored.Completed.Int64 != 1 || stored.Version != todo.Version {
+		t.Errorf("stored todo %+v, want completed at version %d", stored, todo.Version)
+	}
+
+	if _, err := env.todos.EditTodo(ctx, testOwner, mvc, todo.ID, TodoDetails{Text: "Hoover", Completed: &reopened}); err != nil {
+		t.Fatalf("EditTodo: %v", err)
+	}
+	if stored := env.stored(t, mvc.ListID)[todo.ID]; stored.Task != "Hoover" || stored.Completed.Int64 != 0 {
+		t.Fatalf("stored todo %+v, want it renamed and reopened", stored)
+	}
+
+	// Both were one change, which a single undo reverts.
+	if err := env.todos.Undo(ctx, testOwner, mvc); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if stored := env.stored(t, mvc.ListID)[todo.ID]; stored.Task != "Wash up" || stored.Completed.Int64 != 1 {
+		t.Errorf("stored todo %+v after undo, want it named Wash up and completed", stored)
+	}
+}
+
+func TestToggleTodoCreatesNextOccurrence(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	mvc := env.newList(t)
+	monthly, err := domain.ParseRecurrence("FREQ=MONTHLY")
+	if err != nil {
+		t.Fatalf("ParseRecurrence: %v", err)
+	}
+	due := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+	todo, err := env.todos.EditTodo(ctx, testOwner, mvc, "", TodoDetails{Text: "Pay rent", DueAt: &due, Recurrence: monthly})
+	if err != nil {
+		t.Fatalf("EditTodo: %v", err)
+	}
+
+	// Each occurrence is completed on the day it is due, but for the last
+	// one, completed months late: the missed occurrences are skipped, and
+	// the rule keeps falling on the last day of the month.
+	for _, step := range []struct {
+		completedOn time.Time
+		wantDue     time.Time
+	}{
+		{completedOn: due, wantDue: time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		{completedOn: time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC), wantDue: time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC)},
+		{completedOn: time.Date(2026, time.June, 15, 0, 0, 0, 0, time.UTC), wantDue: time.Date(2026, time.June, 30, 0, 0, 0, 0, time.UTC)},
+	} {
+		env.clock.Set(step.completedOn.Add(18 * time.Hour))
+		if err := env.todos.ToggleTodo(ctx, testOwner, mvc, todo.ID, false); err != nil {
+			t.Fatalf("ToggleTodo: %v", err)
+		}
+
+		var next *todocomponents.Todo
+		for _, candidate := range env.load(t, mvc.ListID).Todos {
+			if candidate.Recurrence != "" {
+				if next != nil {
+					t.Fatalf("more than one todo carries the rule")
+				}
+				next = candidate
+			}
+		}
+		if next == nil || next.ID == todo.ID || next.Completed || next.DueAt == nil || !next.DueAt.Equal(step.wantDue) {
+			t.Fatalf("completing the occurrence due %v on %v left %+v carrying the rule, want a new open occurrence due %v", todo.DueAt, step.completedOn, next, step.wantDue)
+		}
+		if stored := env.stored(t, mvc.ListID)[todo.ID]; stored.Completed.Int64 != 1 || stored.Recurrence != "" {
+			t.Errorf("completed occurrence stored as %+v, want completed without the rule", stored)
+		}
+		todo = next
+	}
+}
@@ -0,0 +1,797 @@
+package services
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+
+	"github.com/google/uuid"
+)
+
+// ExportFormat is a file format todos are exported in and imported from.
+type ExportFormat string
+
+const (
+	// FormatJSON is a JSON array of todos.
+	FormatJSON ExportFormat = "json"
+	// FormatCSV is a CSV file with a header row and a todo per row.
+	FormatCSV ExportFormat = "csv"
+	// FormatTodoTxt is the todo.txt format, a todo per line.
+	FormatTodoTxt ExportFormat = "todotxt"
+)
+
+const (
+	// maxImportTodos is the most todos a single import may hold.
+	maxImportTodos = 5000
+	// maxImportErrors is the most errors reported for a file that could not
+	// be imported.
+	maxImportErrors = 10
+	// importBatchSize is the number of todos written between two progress reports.
+	importBatchSize = 100
+)
+
+var (
+	// ErrUnsupportedFormat is returned for export and import formats that are not known.
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	// ErrTooManyTodos is returned when a file holds more todos than can be imported at once.
+	ErrTooManyTodos = errors.New("too many todos to import")
+)
+
+// csvHeader names the columns of exported CSV files. Imported files may
+// order the columns differently and leave out all but "text".
+var csvHeader = []string{"id", "text", "completed", "due_date", "priority", "tags", "parent_id", "recurrence"}
+
+// todoTxtPriority matches the priority a todo.txt line may start with.
+var todoTxtPriority = regexp.MustCompile(`^\([A-Z]\)$`)
+
+// todoTxtEscaper escapes the backslashes and line breaks of todo text, which
+// a todo.txt line cannot hold as they are.
+var todoTxtEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+
+// csvFormulaPrefixes are the characters that make spreadsheets read a cell
+// as a formula.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// ParseExportFormat parses the name of a format, defaulting to JSON if it is empty.
+func ParseExportFormat(format string) (ExportFormat, error) {
+	switch f := ExportFormat(strings.ToLower(format)); f {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatCSV, FormatTodoTxt:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// FileFormat returns the format of a file by the extension of its name.
+func FileFormat(name string) (ExportFormat, error) {
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".json":
+		return FormatJSON, nil
+	case ".csv":
+		return FormatCSV, nil
+	case ".txt":
+		return FormatTodoTxt, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, ext)
+	}
+}
+
+// ContentType returns the media type of files in the format.
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatTodoTxt:
+		return "text/plain; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Extension returns the file name extension of the format.
+func (f ExportFormat) Extension() string {
+	switch f {
+	case FormatCSV:
+		return ".csv"
+	case FormatTodoTxt:
+		return ".txt"
+	default:
+		return ".json"
+	}
+}
+
+// ImportError is a todo of an imported file that could not be read. Row is
+// the position of the todo in the file, counting from 1.
+type ImportError struct {
+	Row int
+	Err error
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("todo %d: %v", e.Row, e.Err)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// addImportError adds the error of a row to errs, unless maxImportErrors
+// have been collected already.
+func addImportError(errs []error, row int, err error) []error {
+	if len(errs) >= maxImportErrors {
+		return errs
+	}
+	return append(errs, &ImportError{Row: row, Err: err})
+}
+
+// transferTodo is a todo as it is exported and imported. Subtasks refer to
+// their parent by its ID, which an import only uses to link them up.
+type transferTodo struct {
+	ID         string          `json:"id,omitempty"`
+	Text       string          `json:"text"`
+	Completed  bool            `json:"completed"`
+	DueDate    string          `json:"dueDate,omitempty"`
+	Priority   domain.Priority `json:"priority,omitempty"`
+	Tags       []string        `json:"tags,omitempty"`
+	ParentID   string          `json:"parentId,omitempty"`
+	Recurrence string          `json:"recurrence,omitempty"`
+}
+
+// ExportTodos writes the todos of mvc to w in format, each top-level todo
+// followed by its subtasks.
+func ExportTodos(w io.Writer, mvc *todocomponents.TodoMVC, format ExportFormat) error {
+	var todos []transferTodo
+	for _, todo := range mvc.Children("") {
+		todos = append(todos, exportedTodo(todo))
+		for _, subtask := range mvc.Children(todo.ID) {
+			todos = append(todos, exportedTodo(subtask))
+		}
+	}
+
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, todos)
+	case FormatCSV:
+		return writeCSV(w, todos)
+	case FormatTodoTxt:
+		return writeTodoTxt(w, todos)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+func exportedTodo(todo *todocomponents.Todo) transferTodo {
+	return transferTodo{
+		ID:         todo.ID,
+		Text:       todo.Text,
+		Completed:  todo.Completed,
+		DueDate:    todo.DueDate(),
+		Priority:   todo.Priority,
+		Tags:       todo.Tags,
+		ParentID:   todo.ParentID,
+		Recurrence: todo.Recurrence,
+	}
+}
+
+// writeJSON writes todos as a JSON array, one todo per line.
+func writeJSON(w io.Writer, todos []transferTodo) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i, todo := range todos {
+		data, err := json.Marshal(todo)
+		if err != nil {
+			return fmt.Errorf("failed to encode todo: %w", err)
+		}
+		separator := ",\n"
+		if i == 0 {
+			separator = "\n"
+		}
+		if _, err := io.WriteString(w, separator+string(data)); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "\n]\n")
+	return err
+}
+
+// writeCSV writes todos as CSV with the columns in csvHeader. Cells that a
+// spreadsheet would read as a formula are escaped by csvCell.
+func writeCSV(w io.Writer, todos []transferTodo) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		priority := ""
+		if todo.Priority != domain.PriorityNone {
+			priority = strings.ToLower(todo.Priority.String())
+		}
+		record := []string{
+			todo.ID,
+			todo.Text,
+			strconv.FormatBool(todo.Completed),
+			todo.DueDate,
+			priority,
+			strings.Join(todo.Tags, " "),
+			todo.ParentID,
+			todo.Recurrence,
+		}
+		for i, value := range record {
+			record[i] = csvCell(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvCell escapes a value that a spreadsheet would read as a formula by
+// prefixing it with a quote, which spreadsheets hide. Values that csvValue
+// would take for escaped get one too, so that it reads back every value as
+// it was.
+func csvCell(value string) string {
+	if startsWithAny(value, csvFormulaPrefixes) || csvValue(value) != value {
+		return "'" + value
+	}
+	return value
+}
+
+// csvValue reads a cell written by csvCell: a quote before a formula
+// character or another quote is dropped.
+func csvValue(cell string) string {
+	rest, quoted := strings.CutPrefix(cell, "'")
+	if quoted && (strings.HasPrefix(rest, "'") || startsWithAny(rest, csvFormulaPrefixes)) {
+		return rest
+	}
+	return cell
+}
+
+// startsWithAny reports whether s starts with one of the characters in chars.
+func startsWithAny(s, chars string) bool {
+	return s != "" && strings.ContainsRune(chars, rune(s[0]))
+}
+
+// writeTodoTxt writes todos in the todo.txt format. Tags are written as
+// +projects, and the due date, recurrence rule and the priority of completed
+// todos as due:, rrule: and pri: extensions. The ID of a todo is written as
+// id:, which its subtasks refer to with parent:. The text is escaped by
+// todoTxtWords.
+func writeTodoTxt(w io.Writer, todos []transferTodo) error {
+	writer := bufio.NewWriter(w)
+	for _, todo := range todos {
+		var words []string
+		letter := priorityLetter(todo.Priority)
+		if todo.Completed {
+			words = append(words, "x")
+		} else if letter != "" {
+			words = append(words, "("+letter+")")
+		}
+		words = append(words, todoTxtWords(todo.Text)...)
+		for _, tag := range todo.Tags {
+			words = append(words, "+"+tag)
+		}
+		if todo.DueDate != "" {
+			words = append(words, "due:"+todo.DueDate)
+		}
+		if todo.Recurrence != "" {
+			words = append(words, "rrule:"+todo.Recurrence)
+		}
+		if todo.Completed && letter != "" {
+			words = append(words, "pri:"+letter)
+		}
+		words = append(words, "id:"+todo.ID)
+		if todo.ParentID != "" {
+			words = append(words, "parent:"+todo.ParentID)
+		}
+		if _, err := writer.WriteString(strings.Join(words, " ") + "\n"); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// todoTxtWords splits text into the words of a todo.txt line, keeping the
+// spaces between them. A backslash escapes what would otherwise be read as
+// something else: backslashes, line breaks, a first word that is a
+// completion mark, priority or date, +projects and the colon of extensions.
+func todoTxtWords(text string) []string {
+	words := strings.Split(text, " ")
+	for i, word := range words {
+		word = todoTxtEscaper.Replace(word)
+		switch {
+		case isTodoTxtExtension(word):
+			word = strings.Replace(word, ":", `\:`, 1)
+		case isTodoTxtProject(word), i == 0 && isTodoTxtMarker(word):
+			word = `\` + word
+		}
+		words[i] = word
+	}
+	return words
+}
+
+// isTodoTxtMarker reports whether word would be read as the completion
+// mark, priority or a date at the start of a todo.txt line.
+func isTodoTxtMarker(word string) bool {
+	_, err := time.Parse(time.DateOnly, word)
+	return word == "x" || todoTxtPriority.MatchString(word) || err == nil
+}
+
+// isTodoTxtProject reports whether word is a todo.txt +project.
+func isTodoTxtProject(word string) bool {
+	return strings.HasPrefix(word, "+") && len(word) > 1
+}
+
+// isTodoTxtExtension reports whether word is one of the key:value
+// extensions parseTodoTxt reads.
+func isTodoTxtExtension(word string) bool {
+	key, value, ok := strings.Cut(word, ":")
+	return ok && value != "" && slices.Contains([]string{"due", "rrule", "id", "parent", "pri"}, key)
+}
+
+// unescapeTodoTxt reads a word of todo text escaped by todoTxtWords.
+func unescapeTodoTxt(word string) string {
+	if !strings.Contains(word, `\`) {
+		return word
+	}
+	var b strings.Builder
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if c == '\\' && i+1 < len(word) {
+			i++
+			switch c = word[i]; c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// priorityLetter returns the todo.txt priority of p: A for high, B for
+// medium and C for low priority.
+func priorityLetter(p domain.Priority) string {
+	switch p {
+	case domain.PriorityHigh:
+		return "A"
+	case domain.PriorityMedium:
+		return "B"
+	case domain.PriorityLow:
+		return "C"
+	default:
+		return ""
+	}
+}
+
+// letterPriority parses a todo.txt priority. Priorities below C are low.
+func letterPriority(letter string) (domain.Priority, error) {
+	switch {
+	case letter == "A":
+		return domain.PriorityHigh, nil
+	case letter == "B":
+		return domain.PriorityMedium, nil
+	case len(letter) == 1 && letter >= "C" && letter <= "Z":
+		return domain.PriorityLow, nil
+	default:
+		return domain.PriorityNone, fmt.Errorf("invalid priority %q", letter)
+	}
+}
+
+// readTodos reads the todos of a file in format. Reading stops at the first
+// error that leaves the rest of the file unreadable; otherwise the returned
+// error joins an *ImportError for each of the first todos that are invalid.
+func readTodos(r io.Reader, format ExportFormat) ([]transferTodo, error) {
+	switch format {
+	case FormatJSON:
+		return readJSON(r)
+	case FormatCSV:
+		return readCSV(r)
+	case FormatTodoTxt:
+		return readTodoTxt(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+func readJSON(r io.Reader) ([]transferTodo, error) {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, errors.New("file is not a JSON array of todos")
+	}
+	var todos []transferTodo
+	for row := 1; decoder.More(); row++ {
+		var todo transferTodo
+		if err := decoder.Decode(&todo); err != nil {
+			return nil, &ImportError{Row: row, Err: err}
+		}
+		todos = append(todos, todo)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+	return todos, nil
+}
+
+func readCSV(r io.Reader) ([]transferTodo, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["text"]; !ok {
+		return nil, errors.New("CSV header has no text column")
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return csvValue(strings.TrimSpace(record[i]))
+	}
+
+	var todos []transferTodo
+	var errs []error
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, &ImportError{Row: row, Err: err}
+		}
+		todo := transferTodo{
+			ID:         field(record, "id"),
+			Text:       field(record, "text"),
+			DueDate:    field(record, "due_date"),
+			Tags:       strings.Fields(field(record, "tags")),
+			ParentID:   field(record, "parent_id"),
+			Recurrence: field(record, "recurrence"),
+		}
+		if completed := field(record, "completed"); completed != "" {
+			if todo.Completed, err = strconv.ParseBool(completed); err != nil {
+				errs = addImportError(errs, row, fmt.Errorf("invalid completed %q", completed))
+			}
+		}
+		if todo.Priority, err = parsePriority(field(record, "priority")); err != nil {
+			errs = addImportError(errs, row, err)
+		}
+		todos = append(todos, todo)
+	}
+	return todos, errors.Join(errs...)
+}
+
+// parsePriority parses a priority by its name or number. The empty string
+// is no priority.
+func parsePriority(value string) (domain.Priority, error) {
+	for p := domain.PriorityNone; p <= domain.PriorityHigh; p++ {
+		if strings.EqualFold(value, p.String()) {
+			return p, nil
+		}
+	}
+	if value == "" {
+		return domain.PriorityNone, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || !domain.Priority(n).Valid() {
+		return domain.PriorityNone, fmt.Errorf("invalid priority %q", value)
+	}
+	return domain.Priority(n), nil
+}
+
+func readTodoTxt(r io.Reader) ([]transferTodo, error) {
+	scanner := bufio.NewScanner(r)
+	var todos []transferTodo
+	var errs []error
+	row := 0
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		row++
+		todo, err := parseTodoTxt(line)
+		if err != nil {
+			errs = addImportError(errs, row, err)
+		}
+		todos = append(todos, todo)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo.txt: %w", err)
+	}
+	return todos, errors.Join(errs...)
+}
+
+// parseTodoTxt parses a line of a todo.txt file, as written by writeTodoTxt.
+// Completion and creation dates are skipped, +projects become tags, and
+// @contexts and unknown extensions stay part of the text. Words are split at
+// single spaces, so that the text keeps the spaces it was written with, and
+// a backslash escapes the character after it.
+func parseTodoTxt(line string) (transferTodo, error) {
+	var todo transferTodo
+	words := strings.Split(line, " ")
+	if len(words) > 0 && words[0] == "x" {
+		todo.Completed = true
+		words = words[1:]
+	}
+	if len(words) > 0 && todoTxtPriority.MatchString(words[0]) {
+		todo.Priority, _ = letterPriority(words[0][1:2])
+		words = words[1:]
+	}
+	for range 2 {
+		if len(words) == 0 {
+			break
+		}
+		if _, err := time.Parse(time.DateOnly, words[0]); err != nil {
+			break
+		}
+		words = words[1:]
+	}
+
+	var text []string
+	for _, word := range words {
+		if isTodoTxtProject(word) {
+			todo.Tags = append(todo.Tags, word[1:])
+			continue
+		}
+		if !isTodoTxtExtension(word) {
+			text = append(text, unescapeTodoTxt(word))
+			continue
+		}
+		switch key, value, _ := strings.Cut(word, ":"); key {
+		case "due":
+			todo.DueDate = value
+		case "rrule":
+			todo.Recurrence = value
+		case "id":
+			todo.ID = value
+		case "parent":
+			todo.ParentID = value
+		case "pri":
+			priority, err := letterPriority(value)
+			if err != nil {
+				return todo, err
+			}
+			todo.Priority = priority
+		}
+	}
+	todo.Text = strings.Join(text, " ")
+	return todo, nil
+}
+
+// ImportTodos reads the todos of a file in format from r into the list of
+// mvc. With replace they replace all todos of the list. Otherwise they are
+// merged into it: an imported todo with the ID of a todo of the list updates
+// its details, keeping its place, and the others are added to the end of the
+// list. Subtasks are linked to their parent by its ID in the file, or by the
+// ID of a todo of the list.
+//
+// Nothing is imported if a todo cannot be read; the returned error then
+// joins an *ImportError for each of the first ones. Otherwise the todos are
+// written in one transaction, calling progress after each batch with the
+// number written so far and the total, and the import is recorded as one
+// change that the session can undo. It returns the number of todos imported,
+// also along with an error wrapping ErrNotPublished if the import was
+// committed but could not be announced.
+func (s *TodoService) ImportTodos(ctx context.Context, sessionID string, mvc *todocomponents.TodoMVC, format ExportFormat, r io.Reader, replace bool, progress func(done, total int)) (int, error) {
+	if mvc.ReadOnly() {
+		return 0, domain.ErrForbidden
+	}
+	records, err := readTodos(r, format)
+	if err != nil {
+		return 0, err
+	}
+	if len(records) > maxImportTodos {
+		return 0, fmt.Errorf("%w: %d, at most %d", ErrTooManyTodos, len(records), maxImportTodos)
+	}
+	from, to, err := s.planImport(mvc, records, replace)
+	if err != nil {
+		return 0, err
+	}
+
+	before := snapshot(mvc.Todos)
+	var recorded []domain.Event
+	if err := s.uow.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var deleted []todocomponents.Todo
+		for _, state := range from {
+			if !containsTodo(to, state.ID) {
+				deleted = append(deleted, state)
+			}
+		}
+		if len(deleted) > 0 {
+			if err := s.restore(txCtx, sessionID, mvc, deleted, nil); err != nil {
+				return err
+			}
+		}
+
+		// Parents are written before their subtasks, batch by batch.
+		slices.SortStableFunc(to, func(a, b todocomponents.Todo) int {
+			return cmp.Compare(depth(a), depth(b))
+		})
+		for start := 0; start < len(to); start += importBatchSize {
+			batch := to[start:min(start+importBatchSize, len(to))]
+			var updated []todocomponents.Todo
+			for _, state := range from {
+				if containsTodo(batch, state.ID) {
+					updated = append(updated, state)
+				}
+			}
+			if err := s.restore(txCtx, sessionID, mvc, updated, batch); err != nil {
+				return err
+			}
+			progress(start+len(batch), len(to))
+		}
+
+		var err error
+		recorded, err = s.record(txCtx, sessionID, mvc, "Todos imported", before)
+		return err
+	}); err != nil {
+		return 0, s.publishConflict(ctx, sessionID, mvc, err)
+	}
+	return len(to), s.publish(ctx, sessionID, mvc, append([]domain.Event{domain.TodosImported{Count: len(to)}}, recorded...)...)
+}
+
+// planImport turns the todos read from a file into the states of the todos
+// of mvc they change, in from, and the states they change them to, in to.
+// It returns the errors of the first invalid todos joined if there are any.
+func (s *TodoService) planImport(mvc *todocomponents.TodoMVC, records []transferTodo, replace bool) (from, to []todocomponents.Todo, err error) {
+	existing := func(id string) *todocomponents.Todo {
+		if replace || id == "" {
+			return nil
+		}
+		todo, _ := mvc.Find(id)
+		return todo
+	}
+
+	// Map the IDs in the file to the IDs the todos get in the list.
+	var errs []error
+	ids := make(map[string]string)
+	for i, record := range records {
+		if record.ID == "" {
+			continue
+		}
+		if _, ok := ids[record.ID]; ok {
+			errs = addImportError(errs, i+1, fmt.Errorf("duplicate id %q", record.ID))
+			continue
+		}
+		ids[record.ID] = uuid.New().String()
+		if todo := existing(record.ID); todo != nil {
+			ids[record.ID] = todo.ID
+		}
+	}
+
+	// New todos go to the end of their parent's todos.
+	last := make(map[string]float64)
+	if replace {
+		from = snapshot(mvc.Todos)
+	} else {
+		for _, todo := range mvc.Todos {
+			last[todo.ParentID] = max(last[todo.ParentID], todo.Position)
+		}
+	}
+
+	parents := make(map[string]string)
+	if !replace {
+		for _, todo := range mvc.Todos {
+			parents[todo.ID] = todo.ParentID
+		}
+	}
+	rows := make(map[string]int)
+	for i, record := range records {
+		state, err := s.importedTodo(record)
+		if err != nil {
+			errs = addImportError(errs, i+1, err)
+			continue
+		}
+		state.ID = ids[record.ID]
+		if state.ID == "" {
+			state.ID = uuid.New().String()
+		}
+
+		if todo := existing(record.ID); todo != nil {
+			from = append(from, *todo)
+			state.Position = todo.Position
+			state.ParentID = todo.ParentID
+			state.Version = todo.Version
+			state.RemindAt = todo.RemindAt
+		} else {
+			if record.ParentID != "" {
+				state.ParentID = ids[record.ParentID]
+				if state.ParentID == "" {
+					if parent := existing(record.ParentID); parent != nil {
+						state.ParentID = parent.ID
+					} else {
+						errs = addImportError(errs, i+1, fmt.Errorf("unknown parent %q", record.ParentID))
+						continue
+					}
+				}
+			}
+			last[state.ParentID] += positionGap
+			state.Position = last[state.ParentID]
+		}
+		parents[state.ID] = state.ParentID
+		rows[state.ID] = i + 1
+		to = append(to, state)
+	}
+
+	// Todos are nested one level deep.
+	for _, state := range to {
+		if state.ParentID != "" && parents[state.ParentID] != "" {
+			errs = addImportError(errs, rows[state.ID], ErrNestedSubtask)
+		}
+	}
+	return from, to, errors.Join(errs...)
+}
+
+// importedTodo validates the details of a todo read from a file. The #tags
+// in its text become tags, as when a todo is entered. Recurring todos
+// without a due date are due today.
+func (s *TodoService) importedTodo(record transferTodo) (todocomponents.Todo, error) {
+	text, tags := parseTags(record.Text)
+	if strings.TrimSpace(text) == "" {
+		return todocomponents.Todo{}, errors.New("text is empty")
+	}
+	if !record.Priority.Valid() {
+		return todocomponents.Todo{}, fmt.Errorf("invalid priority %d", record.Priority)
+	}
+	var dueAt *time.Time
+	if record.DueDate != "" {
+		due, err := time.Parse(todocomponents.DueDateLayout, record.DueDate)
+		if err != nil {
+			return todocomponents.Todo{}, fmt.Errorf("invalid due date %q", record.DueDate)
+		}
+		dueAt = &due
+	}
+	recurrence, err := domain.ParseRecurrence(record.Recurrence)
+	if err != nil {
+		return todocomponents.Todo{}, err
+	}
+	if !recurrence.IsZero() && dueAt == nil {
+		today := s.today()
+		dueAt = &today
+	}
+	return todocomponents.Todo{
+		Text:       text,
+		Completed:  record.Completed,
+		DueAt:      dueAt,
+		Priority:   record.Priority,
+		Tags:       normalizeTags(append(tags, record.Tags...)),
+		Recurrence: recurrence.String(),
+		// The exports have no start of the rule, so the rule of an imported
+		// todo counts from its due date.
+		RecurrenceStart: recurrenceStart(nil, TodoDetails{DueAt: dueAt, Recurrence: recurrence}),
+	}, nil
+}
+
+// containsTodo reports whether states hold a state of the todo with the given ID.
+func containsTodo(states []todocomponents.Todo, id string) bool {
+	return slices.ContainsFunc(states, func(state todocomponents.Todo) bool { return state.ID == id })
+}
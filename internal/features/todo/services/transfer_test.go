@@ -0,0 +1,174 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store"
+)
+
+// awkwardTexts are todo texts that look like the markup of an export format.
+var awkwardTexts = []string{
+	"x marks the spot",
+	"x",
+	"(A) is a grade",
+	"2026-03-02 was a Monday",
+	"2026-03-02 2026-03-01 twice",
+	"Read +1 book",
+	"Ask about due:tomorrow and rrule:daily",
+	"id:7 parent:3 pri:A",
+	`Back\slash and \+escaped`,
+	"Keep  two spaces",
+	" leading and trailing ",
+	"Tab\tand\nline break\r\n",
+	"",
+	"=HYPERLINK(\"http://example.com\")",
+	"+1 for this",
+	"-5 degrees",
+	"@home",
+	"'=already quoted",
+	"'Tis the season",
+	"''",
+	"'",
+}
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	for _, text := range awkwardTexts {
+		for _, todo := range []transferTodo{
+			{ID: "1", Text: text},
+			{ID: "2", Text: text, Completed: true, Priority: domain.PriorityHigh},
+			{ID: "3", Text: text, Priority: domain.PriorityLow, DueDate: "2026-03-02", Tags: []string{"home"}, ParentID: "1"},
+		} {
+			var buf bytes.Buffer
+			if err := writeTodoTxt(&buf, []transferTodo{todo}); err != nil {
+				t.Fatalf("writeTodoTxt: %v", err)
+			}
+			got, err := readTodoTxt(&buf)
+			if err != nil {
+				t.Fatalf("readTodoTxt(%q): %v", buf.String(), err)
+			}
+			if len(got) != 1 || !reflect.DeepEqual(got[0], todo) {
+				t.Errorf("todo %+v written as %q read back as %+v", todo, buf.String(), got)
+			}
+		}
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	var todos []transferTodo
+	for _, text := range awkwardTexts {
+		// The reader trims cells, like the files people edit by hand.
+		todos = append(todos, transferTodo{Text: strings.TrimSpace(text), Tags: []string{"-home"}})
+	}
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, todos); err != nil {
+		t.Fatalf("writeCSV: %v", err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	for _, record := range records[1:] {
+		for _, cell := range record {
+			if startsWithAny(cell, csvFormulaPrefixes) {
+				t.Errorf("cell %q would be read as a formula", cell)
+			}
+		}
+	}
+
+	got, err := readCSV(&buf)
+	if err != nil {
+		t.Fatalf("readCSV: %v", err)
+	}
+	if !reflect.DeepEqual(got, todos) {
+		t.Errorf("todos %+v written as %q read back as %+v", todos, buf.String(), got)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []ExportFormat{FormatJSON, FormatCSV, FormatTodoTxt} {
+		t.Run(string(format), func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			from := env.newList(t, "x marks the spot #home", "(A) is a grade", "=SUM(A1:A2)")
+			due := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+			daily, err := domain.ParseRecurrence("FREQ=DAILY")
+			if err != nil {
+				t.Fatalf("ParseRecurrence: %v", err)
+			}
+			if _, err := env.todos.EditTodo(ctx, testOwner, from, "", TodoDetails{
+				Text:       "Read +1 book due:tomorrow",
+				DueAt:      &due,
+				Priority:   domain.PriorityHigh,
+				Recurrence: daily,
+			}); err != nil {
+				t.Fatalf("EditTodo: %v", err)
+			}
+			if _, err := env.todos.AddSubtask(ctx, testOwner, from, from.Todos[0].ID, TodoDetails{Text: "2026-03-02 was a Monday"}); err != nil {
+				t.Fatalf("AddSubtask: %v", err)
+			}
+			if err := env.todos.ToggleTodo(ctx, testOwner, from, from.Todos[0].ID, true); err != nil {
+				t.Fatalf("ToggleTodo: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := ExportTodos(&buf, from, format); err != nil {
+				t.Fatalf("ExportTodos: %v", err)
+			}
+			to := env.newList(t)
+			if _, err := env.todos.ImportTodos(ctx, testOwner, to, format, &buf, false, func(int, int) {}); err != nil {
+				t.Fatalf("ImportTodos: %v", err)
+			}
+
+			if got, want := transferred(env.load(t, to.ListID)), transferred(env.load(t, from.ListID)); !reflect.DeepEqual(got, want) {
+				t.Errorf("imported todos %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// downPublisher is an event bus that cannot be reached.
+type downPublisher struct{}
+
+func (downPublisher) Publish(context.Context, string, ...domain.Event) error {
+	return errors.New("event bus is down")
+}
+
+func TestImportTodosCommitsWhenPublishingFails(t *testing.T) {
+	env := newTestEnv(t)
+	st := env.store
+	todos := NewTodoService(st, store.NewTodoRepository(st), store.NewTagRepository(st), store.NewHistoryRepository(st), store.NewAuditRepository(st), store.NewReminderRepository(st), store.NewListRepository(st), store.NewSessionRepository(st), downPublisher{}, env.clock)
+	mvc := env.newList(t)
+
+	imported, err := todos.ImportTodos(context.Background(), testOwner, mvc, FormatTodoTxt, strings.NewReader("Wash up\nHoover\n"), false, func(int, int) {})
+	if !errors.Is(err, ErrNotPublished) {
+		t.Errorf("ImportTodos = %v, want %v", err, ErrNotPublished)
+	}
+	if stored := env.stored(t, mvc.ListID); imported != 2 || len(stored) != 2 {
+		t.Errorf("ImportTodos imported %d todos and stored %d, want 2", imported, len(stored))
+	}
+}
+
+// transferred returns the todos of mvc as they are exported, with their
+// parents told by text instead of by ID.
+func transferred(mvc *todocomponents.TodoMVC) []transferTodo {
+	var todos []transferTodo
+	for _, todo := range mvc.Todos {
+		exported := exportedTodo(todo)
+		exported.ID = ""
+		if parent, _ := mvc.Find(todo.ParentID); parent != nil {
+			exported.ParentID = parent.Text
+		}
+		todos = append(todos, exported)
+	}
+	return todos
+}
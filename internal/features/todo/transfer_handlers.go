@@ -0,0 +1,122 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	commoncomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/common/components"
+	todocomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/todo/components"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo/services"
+
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// maxImportSize is the largest request body an import accepts.
+const maxImportSize = 10 << 20
+
+// ExportTodos downloads the todos of the list in the format named by the
+// "format" query parameter: json (the default), csv or todotxt.
+func (h *Handlers) ExportTodos(w http.ResponseWriter, r *http.Request) {
+	format, err := services.ParseExportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
+		return
+	}
+
+	name := mvc.ListName
+	if name == "" {
+		name = "todos"
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": name + format.Extension(),
+	}))
+	if err := services.ExportTodos(w, mvc, format); err != nil {
+		h.logger.Error("failed to export todos", "error", err)
+	}
+}
+
+// ImportTodos imports the todos of the uploaded multipart "file" into the
+// list. The format is taken from the "format" field, or else from the
+// extension of the file name. With "mode" replace the todos replace those of
+// the list, otherwise they are merged into it. Progress is shown in the
+// import form and errors are sent back as toasts; the imported todos reach
+// open views through the event stream.
+func (h *Handlers) ImportTodos(w http.ResponseWriter, r *http.Request) {
+	sessionID, mvc, ok := h.RequireMVC(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	file, header, err := r.FormFile("file")
+	sse := datastar.NewSSE(w, r)
+	toast := func(message string, kind commoncomponents.ToastType) {
+		if err := sse.PatchElementTempl(
+			commoncomponents.Toast(message, kind),
+			datastar.WithSelectorID(commoncomponents.ToastContainerID),
+			datastar.WithModeAppend(),
+		); err != nil {
+			h.logger.Error("failed to send toast", "error", err)
+		}
+	}
+	progress := func(done, total int) {
+		if err := sse.PatchElementTempl(todocomponents.ImportProgress(done, total)); err != nil {
+			h.logger.Error("failed to send import progress", "error", err)
+		}
+	}
+	if err != nil {
+		toast("Choose a file to import", commoncomponents.ToastError)
+		return
+	}
+	defer file.Close()
+
+	var format services.ExportFormat
+	if name := r.FormValue("format"); name != "" {
+		format, err = services.ParseExportFormat(name)
+	} else {
+		format, err = services.FileFormat(header.Filename)
+	}
+	if err != nil {
+		toast(err.Error(), commoncomponents.ToastError)
+		return
+	}
+
+	replace := r.FormValue("mode") == "replace"
+	imported, err := h.todoService.ImportTodos(r.Context(), sessionID, mvc, format, file, replace, progress)
+	if errors.Is(err, services.ErrNotPublished) {
+		// The todos were imported; the other viewers of the list only miss
+		// them until they reload.
+		h.logger.Error("failed to publish imported todos", "list", mvc.ListID, "error", err)
+		err = nil
+	}
+	if err != nil {
+		progress(0, 0)
+	}
+	var conflict *domain.ConflictError
+	switch {
+	case err == nil:
+		toast(fmt.Sprintf("Imported %d todos", imported), commoncomponents.ToastSuccess)
+	case errors.As(err, &conflict):
+		toast("The list changed during the import, nothing was imported", commoncomponents.ToastError)
+	case errors.Is(err, domain.ErrForbidden):
+		toast("You cannot import into this list", commoncomponents.ToastError)
+	default:
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		for _, err := range errs {
+			toast(err.Error(), commoncomponents.ToastError)
+		}
+		toast("Nothing was imported", commoncomponents.ToastError)
+	}
+}
@@ -0,0 +1,191 @@
+package todo
+
+import (
+	"fmt"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	commoncomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/common/components"
+)
+
+// changeKind describes what happened to a single todo.
+type changeKind int
+
+const (
+	todoAdded changeKind = iota
+	todoUpdated
+	todoRemoved
+)
+
+// todoChange identifies a todo and how it changed.
+type todoChange struct {
+	id   string
+	kind changeKind
+}
+
+// viewUpdate describes which parts of the todo view a batch of domain events
+// invalidates. refresh asks for a full re-render; the other fields describe
+// smaller changes that can be patched in place.
+type viewUpdate struct {
+	refresh bool
+	todos   []todoChange
+	footer  bool
+	mode    bool
+	toast   *toast
+	// order is set when todos moved, so the list is re-rendered in its new order.
+	order bool
+	// editing is set when the session started or stopped editing a todo.
+	editing *string
+	// list is set when the list was renamed, archived or restored, which
+	// also changes the sidebar.
+	list bool
+	// deleted is set when the list is gone and the view has to leave it.
+	deleted bool
+	// notification is set when a reminder is due that the member also wants
+	// a browser notification for.
+	notification *domain.ReminderDue
+}
+
+// toast is a notification shown to every open view of the session.
+type toast struct {
+	message string
+	kind    commoncomponents.ToastType
+	// action is the history action the toast offers, if any: "undo" after a
+	// change the session made, "redo" after it undid one.
+	action string
+}
+
+// newViewUpdate maps domain events to the view parts they change, as seen by
+// the given session in its view of the list with ID listID. A batch without
+// events could not be decoded, so it re-renders everything.
+func newViewUpdate(events []domain.Event, listID, sessionID string) viewUpdate {
+	if len(events) == 0 {
+		return viewUpdate{refresh: true}
+	}
+
+	var u viewUpdate
+	action := ""
+	for _, event := range events {
+		switch e := event.(type) {
+		case domain.TodoCreated:
+			u.addChanges(todoAdded, e.TodoID)
+			u.footer = true
+			u.setToast("Todo created", commoncomponents.ToastSuccess)
+		case domain.TodoEdited:
+			u.addChanges(todoUpdated, e.TodoID)
+			u.setToast("Todo updated", commoncomponents.ToastSuccess)
+		case domain.TodoToggled:
+			u.addChanges(todoUpdated, e.TodoIDs...)
+			u.footer = true
+		case domain.TodoMoved:
+			u.addChanges(todoUpdated, e.TodoID)
+			u.order = true
+		case domain.TodoDeleted:
+			u.addChanges(todoRemoved, e.TodoID)
+			u.footer = true
+			u.setToast("Todo deleted", commoncomponents.ToastSuccess)
+		case domain.TodosCleared:
+			u.addChanges(todoRemoved, e.TodoIDs...)
+			u.footer = true
+			u.setToast("Completed todos cleared", commoncomponents.ToastSuccess)
+		case domain.TodosReset:
+			u.refresh = true
+			u.setToast("Todos reset", commoncomponents.ToastSuccess)
+		case domain.TodosImported:
+			u.refresh = true
+			if e.Count == 1 {
+				u.setToast("1 todo imported", commoncomponents.ToastSuccess)
+			} else {
+				u.setToast(fmt.Sprintf("%d todos imported", e.Count), commoncomponents.ToastSuccess)
+			}
+		case domain.ViewModeChanged, domain.TagFilterChanged:
+			u.mode = true
+		case domain.EditingChanged:
+			// Edit mode swaps inputs in the header and the list.
+			u.refresh = true
+			u.editing = &e.TodoID
+		case domain.ListChanged:
+			u.refresh = true
+			u.list = true
+		case domain.ListDeleted:
+			u.deleted = true
+		case domain.ConflictDetected:
+			u.refresh = true
+			u.setToast("Changed elsewhere, showing the latest version", commoncomponents.ToastWarning)
+		case domain.HistoryRecorded:
+			// Only the session that made the change can undo it.
+			if e.MemberID == sessionID {
+				action = "undo"
+			}
+		case domain.HistoryReplayed:
+			u.refresh = true
+			if e.Redo {
+				u.setToast("Redone: "+e.Label, commoncomponents.ToastInfo)
+			} else {
+				u.setToast("Undone: "+e.Label, commoncomponents.ToastInfo)
+			}
+			if e.MemberID == sessionID {
+				action = "redo"
+				if e.Redo {
+					action = "undo"
+				}
+			}
+		case domain.ReminderChanged:
+			u.addChanges(todoUpdated, e.TodoID)
+			if e.Set {
+				u.setToast("Reminder set", commoncomponents.ToastSuccess)
+			} else {
+				u.setToast("Reminder removed", commoncomponents.ToastSuccess)
+			}
+		case domain.ReminderDue:
+			// Reminders reach the member's views of all lists, but only the
+			// view of the todo's own list shows the reminder on its row.
+			if e.ListID == listID {
+				u.addChanges(todoUpdated, e.TodoID)
+			}
+			u.setToast("Reminder: "+e.Text, commoncomponents.ToastWarning)
+			if e.Notify {
+				u.notification = &e
+			}
+		case domain.NotificationsChanged:
+			u.refresh = true
+			if e.Enabled {
+				u.setToast("Browser notifications turned on", commoncomponents.ToastInfo)
+			} else {
+				u.setToast("Browser notifications turned off", commoncomponents.ToastInfo)
+			}
+		}
+	}
+	if u.toast != nil {
+		u.toast.action = action
+	}
+	return u
+}
+
+// hasChanges reports whether any part of the todo list needs to be re-rendered.
+func (u viewUpdate) hasChanges() bool {
+	return u.refresh || len(u.todos) > 0 || u.footer || u.mode
+}
+
+// countChanges returns the number of todo changes of the given kind.
+func (u viewUpdate) countChanges(kind changeKind) int {
+	n := 0
+	for _, change := range u.todos {
+		if change.kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func (u *viewUpdate) addChanges(kind changeKind, ids ...string) {
+	for _, id := range ids {
+		u.todos = append(u.todos, todoChange{id: id, kind: kind})
+	}
+}
+
+// setToast keeps the first toast of a batch.
+func (u *viewUpdate) setToast(message string, kind commoncomponents.ToastType) {
+	if u.toast == nil {
+		u.toast = &toast{message: message, kind: kind}
+	}
+}
@@ -0,0 +1,99 @@
+// Package clock provides the system clock and a fake clock to drive
+// background jobs deterministically.
+package clock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+)
+
+// System is the domain.Clock of the time package.
+type System struct{}
+
+// Ensure System and Fake implement domain.Clock at compile time.
+var (
+	_ domain.Clock = System{}
+	_ domain.Clock = (*Fake)(nil)
+)
+
+// Now returns the current time.
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for d to pass in real time.
+func (System) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Fake is a domain.Clock whose time only moves when Advance or Set is called.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter is a pending After call of a Fake.
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFake creates a Fake clock that starts at now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the time the clock is at.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After delivers the time once the clock has been moved forward by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, fakeWaiter{at: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// Waiters returns the number of After calls still waiting, so that a caller
+// can wait for a background job to go to sleep before advancing the clock.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// Advance moves the clock forward by d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	at := f.now.Add(d)
+	f.mu.Unlock()
+	f.Set(at)
+}
+
+// Set moves the clock to now and wakes the After calls whose time has come.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- now
+	}
+	f.waiters = pending
+}
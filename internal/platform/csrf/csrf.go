@@ -0,0 +1,96 @@
+// Package csrf rejects state-changing requests that do not carry the CSRF
+// token of their session.
+//
+// The token is rendered into the page as the "csrf" Datastar signal. Datastar
+// sends all signals in the JSON body of PUT, POST and DELETE actions, so most
+// actions carry it without further work. Actions that send something other
+// than signals, such as form submissions, put it in the X-CSRF-Token header.
+package csrf
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/session"
+)
+
+const (
+	// HeaderName is the request header that may carry the token.
+	HeaderName = "X-CSRF-Token"
+	// SignalName is the Datastar signal that carries the token.
+	SignalName = "csrf"
+
+	// maxSignalsSize bounds how much of a JSON body is read to find the token.
+	maxSignalsSize = 1 << 20
+)
+
+// Token returns the CSRF token of the session in ctx, or "" outside of
+// session.Middleware.
+func Token(ctx context.Context) string {
+	identity, ok := session.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return identity.CSRFToken
+}
+
+// Middleware checks the token on every request that is not GET, HEAD or
+// OPTIONS, and hands requests that fail the check to failed. It must run
+// after session.Middleware.
+func Middleware(failed http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			expected := Token(r.Context())
+			if expected == "" || !valid(expected, requestToken(r)) {
+				failed.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestToken returns the token sent with r, from the header or else from
+// the signals in a JSON body. The body is restored for the handler.
+func requestToken(r *http.Request) string {
+	if token := r.Header.Get(HeaderName); token != "" {
+		return token
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" || r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignalsSize))
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var signals map[string]json.RawMessage
+	if err := json.Unmarshal(body, &signals); err != nil {
+		return ""
+	}
+	var token string
+	if err := json.Unmarshal(signals[SignalName], &token); err != nil {
+		return ""
+	}
+	return token
+}
+
+func valid(expected, actual string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
+}
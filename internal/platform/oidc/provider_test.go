@@ -0,0 +1,182 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/oidc/oidctest"
+)
+
+const testRedirectURL = "https://app.example/auth/callback"
+
+var testUser = oidctest.User{
+	Subject:       "user-1",
+	Email:         "ada@example.com",
+	EmailVerified: true,
+	Name:          "Ada",
+}
+
+// newTestProvider starts an issuer signing in testUser and returns a
+// provider using it.
+func newTestProvider(t *testing.T) (*oidctest.Issuer, *Provider) {
+	t.Helper()
+	iss, err := oidctest.NewIssuer("client-1", "secret-1", testUser)
+	if err != nil {
+		t.Fatalf("NewIssuer: %v", err)
+	}
+	t.Cleanup(iss.Close)
+	return iss, NewProvider(iss.Config(testRedirectURL), iss.Client())
+}
+
+// login follows the authorization URL of req up to the redirect back to the
+// app and returns the authorization code.
+func login(t *testing.T, iss *oidctest.Issuer, p *Provider, req AuthRequest) string {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), req)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	client := iss.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", authURL, err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse callback: %v", err)
+	}
+	if got := callback.Scheme + "://" + callback.Host + callback.Path; got != testRedirectURL {
+		t.Fatalf("redirected to %q, want %q", got, testRedirectURL)
+	}
+	if got := callback.Query().Get("state"); got != req.State {
+		t.Fatalf("callback state = %q, want %q", got, req.State)
+	}
+	return callback.Query().Get("code")
+}
+
+func newAuthRequest(t *testing.T) AuthRequest {
+	t.Helper()
+	req, err := NewAuthRequest()
+	if err != nil {
+		t.Fatalf("NewAuthRequest: %v", err)
+	}
+	return req
+}
+
+func TestLoginFlow(t *testing.T) {
+	iss, p := newTestProvider(t)
+	req := newAuthRequest(t)
+
+	code := login(t, iss, p, req)
+	claims, err := p.Exchange(context.Background(), code, req)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	if claims.Issuer != iss.URL() || claims.Subject != testUser.Subject {
+		t.Errorf("claims identify %q at %q, want %q at %q", claims.Subject, claims.Issuer, testUser.Subject, iss.URL())
+	}
+	if claims.Email != testUser.Email || !claims.EmailVerified || claims.Name != testUser.Name {
+		t.Errorf("claims = %+v, want the profile of %+v", claims, testUser)
+	}
+	if claims.Nonce != req.Nonce {
+		t.Errorf("claims nonce = %q, want %q", claims.Nonce, req.Nonce)
+	}
+}
+
+func TestExchangeRedeemsCodeOnce(t *testing.T) {
+	iss, p := newTestProvider(t)
+	req := newAuthRequest(t)
+
+	code := login(t, iss, p, req)
+	if _, err := p.Exchange(context.Background(), code, req); err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if _, err := p.Exchange(context.Background(), code, req); err == nil {
+		t.Fatal("second Exchange of the code succeeded")
+	}
+}
+
+func TestExchangeRejectsOtherLogin(t *testing.T) {
+	tests := []struct {
+		name  string
+		alter func(req *AuthRequest)
+	}{
+		{"verifier", func(req *AuthRequest) { req.Verifier = "other" }},
+		{"nonce", func(req *AuthRequest) { req.Nonce = "other" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iss, p := newTestProvider(t)
+			req := newAuthRequest(t)
+
+			code := login(t, iss, p, req)
+			tt.alter(&req)
+			if _, err := p.Exchange(context.Background(), code, req); err == nil {
+				t.Fatalf("Exchange with another %s succeeded", tt.name)
+			}
+		})
+	}
+}
+
+func TestExchangeRejectsWrongClientSecret(t *testing.T) {
+	iss, _ := newTestProvider(t)
+	cfg := iss.Config(testRedirectURL)
+	cfg.ClientSecret = "wrong"
+	p := NewProvider(cfg, iss.Client())
+	req := newAuthRequest(t)
+
+	code := login(t, iss, p, req)
+	if _, err := p.Exchange(context.Background(), code, req); err == nil {
+		t.Fatal("Exchange with a wrong client secret succeeded")
+	}
+}
+
+func TestExchangeRejectsExpiredIDToken(t *testing.T) {
+	iss, p := newTestProvider(t)
+	p.now = func() time.Time { return time.Now().Add(time.Hour) }
+	req := newAuthRequest(t)
+
+	code := login(t, iss, p, req)
+	_, err := p.Exchange(context.Background(), code, req)
+	if !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("Exchange of an expired ID token = %v, want %v", err, ErrInvalidIDToken)
+	}
+}
+
+func TestExchangeRejectsTokenSignedByOtherKey(t *testing.T) {
+	iss, _ := newTestProvider(t)
+	other, err := oidctest.NewIssuer(iss.ClientID, iss.ClientSecret, testUser)
+	if err != nil {
+		t.Fatalf("NewIssuer: %v", err)
+	}
+	defer other.Close()
+	req := newAuthRequest(t)
+
+	// The code is redeemed at the other issuer, whose key has the same ID
+	// as the trusted issuer's but does not match it.
+	p := NewProvider(other.Config(testRedirectURL), other.Client())
+	code := login(t, other, p, req)
+	p.meta = &metadata{
+		Issuer:        iss.URL(),
+		TokenEndpoint: other.URL() + "/token",
+		JWKSURI:       iss.URL() + "/jwks",
+	}
+	_, err = p.Exchange(context.Background(), code, req)
+	if !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("Exchange of a token signed by another key = %v, want %v", err, ErrInvalidIDToken)
+	}
+}
@@ -0,0 +1,119 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+)
+
+// schemaVersion is the version of the encoded event batch. Bump it when an
+// event changes in a way older readers cannot decode, such as renaming a field.
+// Adding event types or fields does not need a new version.
+const schemaVersion = 1
+
+// ErrUnsupportedSchema is returned when a batch was encoded with a schema
+// version this build does not know.
+var ErrUnsupportedSchema = errors.New("unsupported event schema version")
+
+// envelope is the wire format of an event batch.
+type envelope struct {
+	Version int            `json:"v"`
+	Events  []encodedEvent `json:"events"`
+}
+
+type encodedEvent struct {
+	Type domain.EventType `json:"type"`
+	Data json.RawMessage  `json:"data"`
+}
+
+// encodeEvents marshals events into a versioned envelope.
+func encodeEvents(events []domain.Event) ([]byte, error) {
+	env := envelope{Version: schemaVersion, Events: make([]encodedEvent, 0, len(events))}
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s event: %w", event.Type(), err)
+		}
+		env.Events = append(env.Events, encodedEvent{Type: event.Type(), Data: data})
+	}
+	return json.Marshal(env)
+}
+
+// decodeEvents unmarshals an envelope written by encodeEvents. Event types it
+// does not know, published by a newer build, are skipped.
+func decodeEvents(data []byte) ([]domain.Event, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to decode event envelope: %w", err)
+	}
+	if env.Version != schemaVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedSchema, env.Version)
+	}
+
+	events := make([]domain.Event, 0, len(env.Events))
+	for _, encoded := range env.Events {
+		event, ok, err := decodeEvent(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s event: %w", encoded.Type, err)
+		}
+		if ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func decodeEvent(encoded encodedEvent) (domain.Event, bool, error) {
+	switch encoded.Type {
+	case domain.EventTodoCreated:
+		return decodeAs[domain.TodoCreated](encoded.Data)
+	case domain.EventTodoEdited:
+		return decodeAs[domain.TodoEdited](encoded.Data)
+	case domain.EventTodoToggled:
+		return decodeAs[domain.TodoToggled](encoded.Data)
+	case domain.EventTodoMoved:
+		return decodeAs[domain.TodoMoved](encoded.Data)
+	case domain.EventTodoDeleted:
+		return decodeAs[domain.TodoDeleted](encoded.Data)
+	case domain.EventTodosCleared:
+		return decodeAs[domain.TodosCleared](encoded.Data)
+	case domain.EventTodosReset:
+		return decodeAs[domain.TodosReset](encoded.Data)
+	case domain.EventTodosImported:
+		return decodeAs[domain.TodosImported](encoded.Data)
+	case domain.EventViewModeChanged:
+		return decodeAs[domain.ViewModeChanged](encoded.Data)
+	case domain.EventTagFilterChanged:
+		return decodeAs[domain.TagFilterChanged](encoded.Data)
+	case domain.EventEditingChanged:
+		return decodeAs[domain.EditingChanged](encoded.Data)
+	case domain.EventConflictDetected:
+		return decodeAs[domain.ConflictDetected](encoded.Data)
+	case domain.EventListChanged:
+		return decodeAs[domain.ListChanged](encoded.Data)
+	case domain.EventListDeleted:
+		return decodeAs[domain.ListDeleted](encoded.Data)
+	case domain.EventHistoryRecorded:
+		return decodeAs[domain.HistoryRecorded](encoded.Data)
+	case domain.EventHistoryReplayed:
+		return decodeAs[domain.HistoryReplayed](encoded.Data)
+	case domain.EventReminderChanged:
+		return decodeAs[domain.ReminderChanged](encoded.Data)
+	case domain.EventReminderDue:
+		return decodeAs[domain.ReminderDue](encoded.Data)
+	case domain.EventNotificationsChanged:
+		return decodeAs[domain.NotificationsChanged](encoded.Data)
+	default:
+		return nil, false, nil
+	}
+}
+
+func decodeAs[E domain.Event](data json.RawMessage) (domain.Event, bool, error) {
+	var event E
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, false, err
+	}
+	return event, true, nil
+}
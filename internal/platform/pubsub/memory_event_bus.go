@@ -0,0 +1,123 @@
+package pubsub
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+)
+
+// Ensure MemoryEventBus implements domain.EventBus at compile time.
+var _ domain.EventBus = (*MemoryEventBus)(nil)
+
+// MemoryEventBus is an in-process domain.EventBus for tests and tools that
+// run without NATS. It keeps every batch in memory for the life of the bus.
+type MemoryEventBus struct {
+	mu          sync.Mutex
+	log         []memoryBatch
+	subscribers map[*memorySubscriber]struct{}
+}
+
+type memoryBatch struct {
+	topic string
+	batch domain.EventBatch
+}
+
+// memorySubscriber queues batches for one subscription, so that publishing
+// never waits for a slow reader.
+type memorySubscriber struct {
+	topics  []string
+	mu      sync.Mutex
+	pending []domain.EventBatch
+	wake    chan struct{}
+}
+
+// NewMemoryEventBus creates a new, empty MemoryEventBus.
+func NewMemoryEventBus() *MemoryEventBus {
+	return &MemoryEventBus{subscribers: make(map[*memorySubscriber]struct{})}
+}
+
+// Publish implements domain.EventPublisher.
+func (b *MemoryEventBus) Publish(_ context.Context, topic string, events ...domain.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	batch := domain.EventBatch{Seq: uint64(len(b.log)) + 1, Events: events}
+	b.log = append(b.log, memoryBatch{topic: topic, batch: batch})
+	for sub := range b.subscribers {
+		if slices.Contains(sub.topics, topic) {
+			sub.push(batch)
+		}
+	}
+	return nil
+}
+
+// Subscribe implements domain.EventSubscriber.
+func (b *MemoryEventBus) Subscribe(ctx context.Context, topics []string, afterSeq uint64) (<-chan domain.EventBatch, error) {
+	sub := &memorySubscriber{topics: topics, wake: make(chan struct{}, 1)}
+
+	b.mu.Lock()
+	for _, stored := range b.log {
+		if slices.Contains(topics, stored.topic) && stored.batch.Seq > afterSeq {
+			sub.push(stored.batch)
+		}
+	}
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	batches := make(chan domain.EventBatch)
+	go func() {
+		defer func() {
+			b.mu.Lock()
+			delete(b.subscribers, sub)
+			b.mu.Unlock()
+		}()
+		for {
+			for _, batch := range sub.take() {
+				select {
+				case batches <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-sub.wake:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return batches, nil
+}
+
+// Position implements domain.EventSubscriber.
+func (b *MemoryEventBus) Position(_ context.Context, _ []string) (first, last uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.log) == 0 {
+		return 0, 0, nil
+	}
+	return 1, uint64(len(b.log)), nil
+}
+
+func (s *memorySubscriber) push(batch domain.EventBatch) {
+	s.mu.Lock()
+	s.pending = append(s.pending, batch)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *memorySubscriber) take() []domain.EventBatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batches := s.pending
+	s.pending = nil
+	return batches
+}
@@ -0,0 +1,82 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+)
+
+// Ensure NATSEventBus implements domain.EventBus at compile time.
+var _ domain.EventBus = (*NATSEventBus)(nil)
+
+// NATSEventBus implements domain.EventBus on top of the JetStream stream
+// created by EnsureStream. Each batch is stored as one message on the
+// subject of its topic, and its stream sequence is the batch sequence.
+type NATSEventBus struct {
+	js     jetstream.JetStream
+	logger *slog.Logger
+}
+
+// NewNATSEventBus creates a new NATSEventBus.
+func NewNATSEventBus(js jetstream.JetStream, logger *slog.Logger) *NATSEventBus {
+	return &NATSEventBus{js: js, logger: logger}
+}
+
+// Publish implements domain.EventPublisher.
+func (b *NATSEventBus) Publish(ctx context.Context, topic string, events ...domain.Event) error {
+	data, err := encodeEvents(events)
+	if err != nil {
+		return err
+	}
+	if _, err := b.js.Publish(ctx, subject(topic), data); err != nil {
+		return fmt.Errorf("failed to publish events: %w", err)
+	}
+	return nil
+}
+
+// Subscribe implements domain.EventSubscriber.
+func (b *NATSEventBus) Subscribe(ctx context.Context, topics []string, afterSeq uint64) (<-chan domain.EventBatch, error) {
+	subjects := make([]string, len(topics))
+	for i, topic := range topics {
+		subjects[i] = subject(topic)
+	}
+	msgs, stop, err := subscribe(ctx, b.js, subjects, afterSeq)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := make(chan domain.EventBatch)
+	go func() {
+		defer stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-msgs:
+				events, err := decodeEvents(msg.Data)
+				if err != nil {
+					b.logger.Error("failed to decode events", "seq", msg.Seq, "error", err)
+				}
+				select {
+				case batches <- domain.EventBatch{Seq: msg.Seq, Events: events}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return batches, nil
+}
+
+// Position implements domain.EventSubscriber.
+func (b *NATSEventBus) Position(ctx context.Context, topics []string) (first, last uint64, err error) {
+	subjects := make([]string, len(topics))
+	for i, topic := range topics {
+		subjects[i] = subject(topic)
+	}
+	return position(ctx, b.js, subjects)
+}
@@ -0,0 +1,255 @@
+package pubsub
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+)
+
+const (
+	// PresenceBucket is the key-value bucket holding one entry per viewer.
+	PresenceBucket = "PRESENCE"
+
+	// presenceTTL is how long an entry lives without a heartbeat, so that
+	// viewers of a server that died without leaving disappear on their own.
+	presenceTTL = 30 * time.Second
+	// presenceHeartbeat is how often joined viewers rewrite their entry.
+	presenceHeartbeat = 10 * time.Second
+	// presenceLeaveTimeout bounds removing an entry once its viewer is gone.
+	presenceLeaveTimeout = 5 * time.Second
+)
+
+// Ensure NATSPresence implements domain.Presence at compile time.
+var _ domain.Presence = (*NATSPresence)(nil)
+
+// EnsurePresenceBucket creates the presence bucket, or updates its configuration if it exists.
+func EnsurePresenceBucket(ctx context.Context, js jetstream.JetStream) (jetstream.KeyValue, error) {
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:  PresenceBucket,
+		TTL:     presenceTTL,
+		Storage: jetstream.MemoryStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bucket %s: %w", PresenceBucket, err)
+	}
+	return kv, nil
+}
+
+// NATSPresence implements domain.Presence on top of the bucket created by
+// EnsurePresenceBucket. Each viewer is stored under "<list ID>.<connection ID>"
+// and rewritten every presenceHeartbeat while its context lasts.
+type NATSPresence struct {
+	kv     jetstream.KeyValue
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	viewers map[string]*joinedViewer // joined on this server, by key
+}
+
+// joinedViewer is a viewer joined on this server. Its mutex serializes the
+// writes and the delete of its entry, so that no write lands after the delete
+// once the viewer has left.
+type joinedViewer struct {
+	mu     sync.Mutex
+	viewer domain.Viewer
+	left   bool
+}
+
+// NewNATSPresence creates a new NATSPresence.
+func NewNATSPresence(kv jetstream.KeyValue, logger *slog.Logger) *NATSPresence {
+	return &NATSPresence{kv: kv, logger: logger, viewers: make(map[string]*joinedViewer)}
+}
+
+// presenceKey returns the key of a viewer's entry.
+func presenceKey(listID, connectionID string) string {
+	return listID + "." + connectionID
+}
+
+// Join implements domain.Presence.
+func (p *NATSPresence) Join(ctx context.Context, listID string, viewer domain.Viewer) error {
+	key := presenceKey(listID, viewer.ConnectionID)
+	p.mu.Lock()
+	p.viewers[key] = &joinedViewer{viewer: viewer}
+	p.mu.Unlock()
+
+	if err := p.put(ctx, key, nil); err != nil {
+		p.leave(key)
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(presenceHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				p.leave(key)
+				return
+			case <-ticker.C:
+				if err := p.put(ctx, key, nil); err != nil && ctx.Err() == nil {
+					p.logger.Warn("failed to refresh presence", "key", key, "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// SetEditing implements domain.Presence.
+func (p *NATSPresence) SetEditing(ctx context.Context, listID, connectionID, editingID string) error {
+	return p.put(ctx, presenceKey(listID, connectionID), func(viewer *domain.Viewer) bool {
+		if viewer.EditingID == editingID {
+			return false
+		}
+		viewer.EditingID = editingID
+		return true
+	})
+}
+
+// put writes the entry of a joined viewer, after applying change to it if
+// change is not nil. Nothing is written for viewers that have left, or if
+// change reports that it changed nothing.
+func (p *NATSPresence) put(ctx context.Context, key string, change func(viewer *domain.Viewer) bool) error {
+	p.mu.Lock()
+	joined, ok := p.viewers[key]
+	p.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	joined.mu.Lock()
+	defer joined.mu.Unlock()
+	if joined.left || (change != nil && !change(&joined.viewer)) {
+		return nil
+	}
+	data, err := json.Marshal(joined.viewer)
+	if err != nil {
+		return fmt.Errorf("failed to encode viewer: %w", err)
+	}
+	if _, err := p.kv.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("failed to store presence: %w", err)
+	}
+	return nil
+}
+
+// leave forgets a viewer and deletes its entry, once a write of it that is
+// under way is done. The viewer's context is already done at this point, so
+// the delete runs on a fresh one.
+func (p *NATSPresence) leave(key string) {
+	p.mu.Lock()
+	joined, ok := p.viewers[key]
+	delete(p.viewers, key)
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	joined.mu.Lock()
+	defer joined.mu.Unlock()
+	joined.left = true
+	ctx, cancel := context.WithTimeout(context.Background(), presenceLeaveTimeout)
+	defer cancel()
+	if err := p.kv.Delete(ctx, key); err != nil {
+		p.logger.Warn("failed to remove presence", "key", key, "error", err)
+	}
+}
+
+// presenceEntry is a viewer as last seen by a watcher.
+type presenceEntry struct {
+	viewer  domain.Viewer
+	updated time.Time
+}
+
+// Watch implements domain.Presence. Entries that expire through the bucket
+// TTL produce no update, so the watcher drops entries that have not been
+// rewritten within presenceTTL itself.
+func (p *NATSPresence) Watch(ctx context.Context, listID string) (<-chan []domain.Viewer, error) {
+	watcher, err := p.kv.Watch(ctx, presenceKey(listID, "*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch presence: %w", err)
+	}
+
+	snapshots := make(chan []domain.Viewer)
+	go func() {
+		defer func() { _ = watcher.Stop() }()
+		prune := time.NewTicker(presenceHeartbeat)
+		defer prune.Stop()
+
+		entries := make(map[string]presenceEntry)
+		ready := false
+		for {
+			changed := false
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok := <-watcher.Updates():
+				if !ok {
+					return
+				}
+				if update == nil {
+					// All current entries have been delivered.
+					ready, changed = true, true
+				} else {
+					changed = p.apply(entries, update)
+				}
+			case now := <-prune.C:
+				for key, entry := range entries {
+					if now.Sub(entry.updated) > presenceTTL {
+						delete(entries, key)
+						changed = true
+					}
+				}
+			}
+			if !ready || !changed {
+				continue
+			}
+
+			select {
+			case snapshots <- sortedViewers(entries):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return snapshots, nil
+}
+
+// apply records a bucket update and reports whether the viewers changed.
+func (p *NATSPresence) apply(entries map[string]presenceEntry, update jetstream.KeyValueEntry) bool {
+	key := update.Key()
+	prev, had := entries[key]
+	if update.Operation() != jetstream.KeyValuePut {
+		delete(entries, key)
+		return had
+	}
+
+	var viewer domain.Viewer
+	if err := json.Unmarshal(update.Value(), &viewer); err != nil {
+		p.logger.Error("failed to decode viewer", "key", key, "error", err)
+		return false
+	}
+	entries[key] = presenceEntry{viewer: viewer, updated: update.Created()}
+	return !had || prev.viewer != viewer
+}
+
+// sortedViewers returns the viewers of entries in a stable order.
+func sortedViewers(entries map[string]presenceEntry) []domain.Viewer {
+	viewers := make([]domain.Viewer, 0, len(entries))
+	for _, key := range slices.Sorted(maps.Keys(entries)) {
+		viewers = append(viewers, entries[key].viewer)
+	}
+	slices.SortStableFunc(viewers, func(a, b domain.Viewer) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return viewers
+}
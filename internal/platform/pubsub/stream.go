@@ -0,0 +1,116 @@
+// Package pubsub provides the NATS adapters that carry domain events to the
+// real-time UI.
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// StreamName is the JetStream stream holding todo update events.
+	StreamName = "TODOS"
+	// SubjectPrefix is prepended to an event topic to form its subject.
+	SubjectPrefix = "todos.updates."
+
+	// streamMaxAge bounds how far back a reconnecting client can resume.
+	streamMaxAge = time.Hour
+	// streamMaxMsgsPerSubject bounds the backlog kept for a single topic.
+	streamMaxMsgsPerSubject = 1000
+)
+
+// subject returns the NATS subject carrying the events of a topic.
+func subject(topic string) string {
+	return SubjectPrefix + topic
+}
+
+// EnsureStream creates the todo update stream, or updates its configuration if it exists.
+func EnsureStream(ctx context.Context, js jetstream.JetStream) (jetstream.Stream, error) {
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:              StreamName,
+		Subjects:          []string{SubjectPrefix + ">"},
+		Storage:           jetstream.FileStorage,
+		Retention:         jetstream.LimitsPolicy,
+		Discard:           jetstream.DiscardOld,
+		MaxAge:            streamMaxAge,
+		MaxMsgsPerSubject: streamMaxMsgsPerSubject,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream %s: %w", StreamName, err)
+	}
+	return stream, nil
+}
+
+// streamMsg is a raw message together with its position in the stream.
+type streamMsg struct {
+	Seq  uint64
+	Data []byte
+}
+
+// position returns the last sequence stored in the stream and the first one
+// after which nothing published to subjects is gone. Old messages leave the
+// stream as a whole when they expire, but a subject that reached
+// streamMaxMsgsPerSubject also drops its own oldest messages while the rest
+// of the stream keeps older ones. A client that last saw a sequence before
+// first-1 has missed events that are gone.
+func position(ctx context.Context, js jetstream.JetStream, subjects []string) (first, last uint64, err error) {
+	stream, err := js.Stream(ctx, StreamName)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get stream %s: %w", StreamName, err)
+	}
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get stream info: %w", err)
+	}
+	first, last = info.State.FirstSeq, info.State.LastSeq
+
+	for _, subj := range subjects {
+		subjInfo, err := stream.Info(ctx, jetstream.WithSubjectFilter(subj))
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get stream info: %w", err)
+		}
+		if subjInfo.State.Subjects[subj] < streamMaxMsgsPerSubject {
+			continue
+		}
+		oldest, err := stream.GetMsg(ctx, first, jetstream.WithGetMsgSubject(subj))
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get oldest message of %s: %w", subj, err)
+		}
+		first = max(first, oldest.Sequence)
+	}
+	return first, last, nil
+}
+
+// subscribe delivers the events published to any of subjects after sequence
+// afterSeq, first replaying stored ones and then following live updates.
+// Delivery stops when ctx is done or the returned stop function is called.
+func subscribe(ctx context.Context, js jetstream.JetStream, subjects []string, afterSeq uint64) (<-chan streamMsg, func(), error) {
+	consumer, err := js.OrderedConsumer(ctx, StreamName, jetstream.OrderedConsumerConfig{
+		FilterSubjects: subjects,
+		DeliverPolicy:  jetstream.DeliverByStartSequencePolicy,
+		OptStartSeq:    afterSeq + 1,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create consumer: %w", err)
+	}
+
+	events := make(chan streamMsg, 64)
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		meta, err := msg.Metadata()
+		if err != nil {
+			return
+		}
+		select {
+		case events <- streamMsg{Seq: meta.Sequence.Stream, Data: msg.Data()}:
+		case <-ctx.Done():
+		}
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to consume: %w", err)
+	}
+
+	return events, consumeCtx.Stop, nil
+}
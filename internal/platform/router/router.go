@@ -3,12 +3,18 @@ package router
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/yacobolo/datastar-go-blueprint/internal/app"
 	"github.com/yacobolo/datastar-go-blueprint/internal/config"
+	"github.com/yacobolo/datastar-go-blueprint/internal/features/auth"
+	commoncomponents "github.com/yacobolo/datastar-go-blueprint/internal/features/common/components"
 	"github.com/yacobolo/datastar-go-blueprint/internal/features/todo"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/csrf"
+	"github.com/yacobolo/datastar-go-blueprint/internal/platform/session"
 	"github.com/yacobolo/datastar-go-blueprint/web/resources"
 
 	"github.com/go-chi/chi/v5"
@@ -23,13 +29,49 @@ func SetupRoutes(_ context.Context, router chi.Router, application *app.App) err
 	}
 
 	router.Handle("/static/*", resources.Handler())
+	todo.SetupFeedRoutes(router, application)
 
-	// Setup feature routes
-	if err := todo.SetupRoutes(router, application); err != nil {
-		return err
-	}
+	// Setup feature routes behind the session and CSRF middleware
+	var err error
+	router.Group(func(r chi.Router) {
+		r.Use(session.Middleware(application.SessionStore, application.Repositories.Users, application.Logger))
+		r.Use(csrf.Middleware(csrfFailed(application.Logger)))
+
+		if err = auth.SetupRoutes(r, application); err != nil {
+			return
+		}
+		err = todo.SetupRoutes(r, application)
+	})
+
+	return err
+}
+
+// csrfFailed rejects a request with a 403 and tells the user why with a toast.
+// The status is written before the SSE stream starts, so Datastar still
+// applies the patch. Clients of the JSON API get a problem detail instead.
+func csrfFailed(logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Warn("rejected request with invalid CSRF token", "method", r.Method, "path", r.URL.Path)
 
-	return nil
+		if strings.HasPrefix(r.URL.Path, "/api/v1/") {
+			todo.WriteProblem(w, r, http.StatusForbidden, "missing or invalid "+csrf.HeaderName+" header")
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusForbidden)
+
+		sse := datastar.NewSSE(w, r)
+		toast := commoncomponents.Toast("Your session has changed, please reload the page", commoncomponents.ToastError)
+		if err := sse.PatchElementTempl(
+			toast,
+			datastar.WithSelectorID(commoncomponents.ToastContainerID),
+			datastar.WithModeAppend(),
+		); err != nil {
+			logger.Error("failed to send toast", "error", err)
+		}
+	})
 }
 
 func setupReload(router chi.Router) {
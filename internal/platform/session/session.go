@@ -0,0 +1,196 @@
+// Package session resolves who is making a request and keeps it in the request context.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+const (
+	// cookieName is the gorilla session holding the session and user IDs.
+	cookieName = "connections"
+
+	sessionIDKey = "id"
+	userIDKey    = "user_id"
+	csrfTokenKey = "csrf"
+)
+
+type contextKey struct{}
+
+// Identity is who is making a request. Every visitor has an anonymous
+// session ID; User is set once the session has signed in.
+type Identity struct {
+	SessionID string
+	User      *queries.User
+	// CSRFToken must accompany every state-changing request of this session.
+	CSRFToken string
+}
+
+// OwnerID returns the ID that data created by this visitor is stored under:
+// the user ID when signed in, and the anonymous session ID otherwise.
+func (i Identity) OwnerID() string {
+	if i.User != nil {
+		return i.User.ID
+	}
+	return i.SessionID
+}
+
+// DisplayName returns how the visitor is shown to others: the name part of
+// the user's email when signed in, and "Guest" otherwise.
+func (i Identity) DisplayName() string {
+	if i.User == nil {
+		return "Guest"
+	}
+	name, _, _ := strings.Cut(i.User.Email, "@")
+	return name
+}
+
+// Middleware loads the Identity for every request and stores it in the
+// request context. Visitors without a session cookie get a new anonymous one,
+// unless the request comes from another site: a strict cookie is not sent
+// along with those, so the visitor may well have a session already, which a
+// new one would replace. Navigations from another site are loaded again from
+// a page of our own, whose request is same-site, and other requests from
+// another site are refused.
+func Middleware(store sessions.Store, users domain.UserRepository, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sess, err := store.Get(r, cookieName)
+			if err != nil {
+				// A cookie that no longer decodes, e.g. after a secret rotation, starts over.
+				logger.Warn("discarding invalid session cookie", "error", err)
+			}
+
+			identity := Identity{}
+			id, ok := sess.Values[sessionIDKey].(string)
+			if !ok && r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+				if r.Method != http.MethodGet && r.Method != http.MethodHead {
+					http.Error(w, "cross-site request without a session", http.StatusForbidden)
+					return
+				}
+				reloadSameSite(w, r)
+				return
+			}
+			token, hasToken := sess.Values[csrfTokenKey].(string)
+			if !ok || !hasToken {
+				if !ok {
+					id = uuid.New().String()
+					sess.Values[sessionIDKey] = id
+				}
+				// Sessions from before CSRF protection get a token on their next request.
+				if !hasToken {
+					token = rand.Text()
+					sess.Values[csrfTokenKey] = token
+				}
+				if err := sess.Save(r, w); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+			}
+			identity.SessionID = id
+			identity.CSRFToken = token
+
+			if userID, ok := sess.Values[userIDKey].(string); ok {
+				user, err := users.GetUserByID(r.Context(), userID)
+				switch {
+				case err == nil:
+					identity.User = &user
+				case errors.Is(err, sql.ErrNoRows):
+					// The account is gone, so fall back to the anonymous session.
+					delete(sess.Values, userIDKey)
+					if err := sess.Save(r, w); err != nil {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+				default:
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+// reloadSameSite replies with a page that loads the requested URL again. The
+// browser counts that request as same-site, so it carries strict cookies.
+func reloadSameSite(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.RequestURI()
+	if strings.HasPrefix(target, "//") {
+		// A path starting with two slashes would be read as another host.
+		target = "/"
+	}
+	target = html.EscapeString(target)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = fmt.Fprintf(w, `<!DOCTYPE html><html lang="en"><head><meta http-equiv="refresh" content="0;url=%s"></head><body><p><a href="%s">Continue</a></p></body></html>`, target, target)
+}
+
+// WithIdentity returns a copy of ctx carrying identity.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the Identity stored by Middleware, if any.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(contextKey{}).(Identity)
+	return identity, ok
+}
+
+// Require returns the Identity of the request, replying with an error if
+// the request did not pass through Middleware.
+func Require(w http.ResponseWriter, r *http.Request) (Identity, bool) {
+	identity, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "no session", http.StatusInternalServerError)
+		return Identity{}, false
+	}
+	return identity, true
+}
+
+// LogIn marks the session of the request as signed in to the given user and
+// rotates its CSRF token. It must be called before anything is written to w.
+func LogIn(store sessions.Store, w http.ResponseWriter, r *http.Request, userID string) error {
+	sess, err := store.Get(r, cookieName)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	sess.Values[userIDKey] = userID
+	sess.Values[csrfTokenKey] = rand.Text()
+	if err := sess.Save(r, w); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// LogOut signs the session of the request out and gives it a new anonymous
+// session ID and CSRF token, so that nothing from the signed-in session
+// carries over.
+// It must be called before anything is written to w.
+func LogOut(store sessions.Store, w http.ResponseWriter, r *http.Request) error {
+	sess, err := store.Get(r, cookieName)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	delete(sess.Values, userIDKey)
+	sess.Values[sessionIDKey] = uuid.New().String()
+	sess.Values[csrfTokenKey] = rand.Text()
+	if err := sess.Save(r, w); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// AuditRepository is the concrete implementation of domain.AuditRepository.
+// It wraps sqlc-generated queries and acts as a driven adapter in hexagonal architecture.
+type AuditRepository struct {
+	store *SQLiteStore
+}
+
+// Ensure AuditRepository implements domain.AuditRepository at compile time.
+var _ domain.AuditRepository = (*AuditRepository)(nil)
+
+// NewAuditRepository creates a new AuditRepository instance.
+func NewAuditRepository(st *SQLiteStore) *AuditRepository {
+	return &AuditRepository{store: st}
+}
+
+// CreateTodoEvent appends an event to the log of a list.
+func (r *AuditRepository) CreateTodoEvent(ctx context.Context, arg queries.CreateTodoEventParams) error {
+	return r.store.conn(ctx).CreateTodoEvent(ctx, arg)
+}
+
+// GetTodoEventsByList retrieves the newest events of a list, newest first.
+func (r *AuditRepository) GetTodoEventsByList(ctx context.Context, arg queries.GetTodoEventsByListParams) ([]queries.TodoEvent, error) {
+	return r.store.conn(ctx).GetTodoEventsByList(ctx, arg)
+}
+
+// GetTodoEventsByTodo retrieves the newest events of a single todo, newest first.
+func (r *AuditRepository) GetTodoEventsByTodo(ctx context.Context, arg queries.GetTodoEventsByTodoParams) ([]queries.TodoEvent, error) {
+	return r.store.conn(ctx).GetTodoEventsByTodo(ctx, arg)
+}
+
+// GetTodoEventsByListUntil retrieves the events of a list up to a point in
+// time, oldest first.
+func (r *AuditRepository) GetTodoEventsByListUntil(ctx context.Context, arg queries.GetTodoEventsByListUntilParams) ([]queries.TodoEvent, error) {
+	return r.store.conn(ctx).GetTodoEventsByListUntil(ctx, arg)
+}
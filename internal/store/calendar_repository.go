@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// CalendarRepository is the concrete implementation of domain.CalendarRepository.
+// It wraps sqlc-generated queries and acts as a driven adapter in hexagonal architecture.
+type CalendarRepository struct {
+	store *SQLiteStore
+}
+
+// Ensure CalendarRepository implements domain.CalendarRepository at compile time.
+var _ domain.CalendarRepository = (*CalendarRepository)(nil)
+
+// NewCalendarRepository creates a new CalendarRepository instance.
+func NewCalendarRepository(st *SQLiteStore) *CalendarRepository {
+	return &CalendarRepository{store: st}
+}
+
+// GetCalendarFeedByMember retrieves the calendar feed of a member.
+func (r *CalendarRepository) GetCalendarFeedByMember(ctx context.Context, memberID string) (queries.CalendarFeed, error) {
+	return r.store.conn(ctx).GetCalendarFeedByMember(ctx, memberID)
+}
+
+// GetCalendarFeedByToken retrieves the calendar feed with the given secret token.
+func (r *CalendarRepository) GetCalendarFeedByToken(ctx context.Context, token string) (queries.CalendarFeed, error) {
+	return r.store.conn(ctx).GetCalendarFeedByToken(ctx, token)
+}
+
+// UpsertCalendarFeed sets the token of a member's calendar feed, replacing
+// the one they had.
+func (r *CalendarRepository) UpsertCalendarFeed(ctx context.Context, arg queries.UpsertCalendarFeedParams) error {
+	return r.store.conn(ctx).UpsertCalendarFeed(ctx, arg)
+}
+
+// MoveCalendarFeed reassigns the calendar feed of one member ID to another,
+// unless the target has one.
+func (r *CalendarRepository) MoveCalendarFeed(ctx context.Context, arg queries.MoveCalendarFeedParams) error {
+	return r.store.conn(ctx).MoveCalendarFeed(ctx, arg)
+}
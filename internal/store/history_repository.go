@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// HistoryRepository is the concrete implementation of domain.HistoryRepository.
+// It wraps sqlc-generated queries and acts as a driven adapter in hexagonal architecture.
+type HistoryRepository struct {
+	store *SQLiteStore
+}
+
+// Ensure HistoryRepository implements domain.HistoryRepository at compile time.
+var _ domain.HistoryRepository = (*HistoryRepository)(nil)
+
+// NewHistoryRepository creates a new HistoryRepository instance.
+func NewHistoryRepository(st *SQLiteStore) *HistoryRepository {
+	return &HistoryRepository{store: st}
+}
+
+// CreateTodoCommand appends a command to the history of a member's changes to a list.
+func (r *HistoryRepository) CreateTodoCommand(ctx context.Context, arg queries.CreateTodoCommandParams) error {
+	return r.store.conn(ctx).CreateTodoCommand(ctx, arg)
+}
+
+// GetLastTodoCommand retrieves the newest command that has not been undone.
+func (r *HistoryRepository) GetLastTodoCommand(ctx context.Context, arg queries.GetLastTodoCommandParams) (queries.TodoCommand, error) {
+	return r.store.conn(ctx).GetLastTodoCommand(ctx, arg)
+}
+
+// GetNextUndoneTodoCommand retrieves the oldest command that has been undone,
+// which is the next one to redo.
+func (r *HistoryRepository) GetNextUndoneTodoCommand(ctx context.Context, arg queries.GetNextUndoneTodoCommandParams) (queries.TodoCommand, error) {
+	return r.store.conn(ctx).GetNextUndoneTodoCommand(ctx, arg)
+}
+
+// SetTodoCommandUndone marks a command as undone or redone.
+func (r *HistoryRepository) SetTodoCommandUndone(ctx context.Context, arg queries.SetTodoCommandUndoneParams) error {
+	return r.store.conn(ctx).SetTodoCommandUndone(ctx, arg)
+}
+
+// DeleteTodoCommand removes a command from the history.
+func (r *HistoryRepository) DeleteTodoCommand(ctx context.Context, id int64) error {
+	return r.store.conn(ctx).DeleteTodoCommand(ctx, id)
+}
+
+// DeleteUndoneTodoCommands forgets the commands a member could still redo.
+func (r *HistoryRepository) DeleteUndoneTodoCommands(ctx context.Context, arg queries.DeleteUndoneTodoCommandsParams) error {
+	return r.store.conn(ctx).DeleteUndoneTodoCommands(ctx, arg)
+}
+
+// TrimTodoCommands keeps only the newest commands of a member's history of a list.
+func (r *HistoryRepository) TrimTodoCommands(ctx context.Context, arg queries.TrimTodoCommandsParams) error {
+	return r.store.conn(ctx).TrimTodoCommands(ctx, arg)
+}
+
+// DeleteTodoCommandsByList deletes the history every member keeps of a list.
+func (r *HistoryRepository) DeleteTodoCommandsByList(ctx context.Context, listID string) error {
+	return r.store.conn(ctx).DeleteTodoCommandsByList(ctx, listID)
+}
+
+// MoveTodoCommands reassigns the history of one member ID to another.
+func (r *HistoryRepository) MoveTodoCommands(ctx context.Context, arg queries.MoveTodoCommandsParams) error {
+	return r.store.conn(ctx).MoveTodoCommands(ctx, arg)
+}
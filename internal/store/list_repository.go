@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// ListRepository is the concrete implementation of domain.ListRepository.
+// It wraps sqlc-generated queries and acts as a driven adapter in hexagonal architecture.
+type ListRepository struct {
+	store *SQLiteStore
+}
+
+// Ensure ListRepository implements domain.ListRepository at compile time.
+var _ domain.ListRepository = (*ListRepository)(nil)
+
+// NewListRepository creates a new ListRepository instance.
+func NewListRepository(st *SQLiteStore) *ListRepository {
+	return &ListRepository{store: st}
+}
+
+// GetList retrieves a list by its ID.
+func (r *ListRepository) GetList(ctx context.Context, id string) (queries.List, error) {
+	return r.store.conn(ctx).GetList(ctx, id)
+}
+
+// GetFirstListByOwner retrieves the oldest list of an owner.
+func (r *ListRepository) GetFirstListByOwner(ctx context.Context, ownerID string) (queries.List, error) {
+	return r.store.conn(ctx).GetFirstListByOwner(ctx, ownerID)
+}
+
+// CreateList creates a new list in the database.
+func (r *ListRepository) CreateList(ctx context.Context, arg queries.CreateListParams) error {
+	return r.store.conn(ctx).CreateList(ctx, arg)
+}
+
+// CountListsByOwner returns the number of lists an owner has created.
+func (r *ListRepository) CountListsByOwner(ctx context.Context, ownerID string) (int64, error) {
+	return r.store.conn(ctx).CountListsByOwner(ctx, ownerID)
+}
+
+// MoveListsToOwner reassigns all lists of one owner ID to another.
+func (r *ListRepository) MoveListsToOwner(ctx context.Context, arg queries.MoveListsToOwnerParams) error {
+	return r.store.conn(ctx).MoveListsToOwner(ctx, arg)
+}
+
+// GetListMember retrieves the membership of a member in a list.
+func (r *ListRepository) GetListMember(ctx context.Context, arg queries.GetListMemberParams) (queries.ListMember, error) {
+	return r.store.conn(ctx).GetListMember(ctx, arg)
+}
+
+// AddListMember adds a member to a list. An existing viewer joining as an
+// editor is promoted; other existing memberships are left unchanged.
+func (r *ListRepository) AddListMember(ctx context.Context, arg queries.AddListMemberParams) error {
+	return r.store.conn(ctx).AddListMember(ctx, arg)
+}
+
+// MoveListMembers reassigns the memberships of one member ID to another,
+// skipping lists the target is already a member of.
+func (r *ListRepository) MoveListMembers(ctx context.Context, arg queries.MoveListMembersParams) error {
+	return r.store.conn(ctx).MoveListMembers(ctx, arg)
+}
+
+// CreateListInvite stores a new invite link token.
+func (r *ListRepository) CreateListInvite(ctx context.Context, arg queries.CreateListInviteParams) error {
+	return r.store.conn(ctx).CreateListInvite(ctx, arg)
+}
+
+// GetListInvite retrieves an invite by its token.
+func (r *ListRepository) GetListInvite(ctx context.Context, token string) (queries.ListInvite, error) {
+	return r.store.conn(ctx).GetListInvite(ctx, token)
+}
+
+// GetListsByMember retrieves every list a member has joined, oldest first.
+func (r *ListRepository) GetListsByMember(ctx context.Context, memberID string) ([]queries.List, error) {
+	return r.store.conn(ctx).GetListsByMember(ctx, memberID)
+}
+
+// RenameList changes the name of a list.
+func (r *ListRepository) RenameList(ctx context.Context, arg queries.RenameListParams) error {
+	return r.store.conn(ctx).RenameList(ctx, arg)
+}
+
+// SetListArchivedAt archives a list, or restores it when ArchivedAt is null.
+func (r *ListRepository) SetListArchivedAt(ctx context.Context, arg queries.SetListArchivedAtParams) error {
+	return r.store.conn(ctx).SetListArchivedAt(ctx, arg)
+}
+
+// DeleteList deletes a list. Its todos, members, invites and views are deleted separately.
+func (r *ListRepository) DeleteList(ctx context.Context, id string) error {
+	return r.store.conn(ctx).DeleteList(ctx, id)
+}
+
+// DeleteListMembers removes every member of a list.
+func (r *ListRepository) DeleteListMembers(ctx context.Context, listID string) error {
+	return r.store.conn(ctx).DeleteListMembers(ctx, listID)
+}
+
+// DeleteListInvites deletes every invite link of a list.
+func (r *ListRepository) DeleteListInvites(ctx context.Context, listID string) error {
+	return r.store.conn(ctx).DeleteListInvites(ctx, listID)
+}
+
+// DeleteListViews deletes the UI state every session keeps for a list.
+func (r *ListRepository) DeleteListViews(ctx context.Context, listID string) error {
+	return r.store.conn(ctx).DeleteListViews(ctx, listID)
+}
@@ -29,6 +29,13 @@ func runMigrations(ctx context.Context, db *sql.DB) error {
 		return fmt.Errorf("init goose: %w", err)
 	}
 
+	// Migrations that run outside a transaction, such as the ones that turn
+	// foreign keys off while they rebuild a table, need all their statements
+	// on the same connection. goose runs them through the pool, so pin it to
+	// a single connection until they are done.
+	defer db.SetMaxOpenConns(db.Stats().MaxOpenConnections)
+	db.SetMaxOpenConns(1)
+
 	if err := goose.UpContext(ctx, db, "migrations"); err != nil {
 		return fmt.Errorf("run migrations: %w", err)
 	}
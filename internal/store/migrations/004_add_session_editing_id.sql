@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN editing_id TEXT;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN editing_id;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Version counters for optimistic concurrency control
+ALTER TABLE todos ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE sessions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN version;
+ALTER TABLE todos DROP COLUMN version;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Users signing in with OIDC are linked by the issuer and subject of their ID token
+ALTER TABLE users ADD COLUMN oidc_issuer TEXT;
+ALTER TABLE users ADD COLUMN oidc_subject TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc ON users(oidc_issuer, oidc_subject);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_oidc;
+ALTER TABLE users DROP COLUMN oidc_subject;
+ALTER TABLE users DROP COLUMN oidc_issuer;
@@ -0,0 +1,56 @@
+-- +goose Up
+-- Todo lists; owner_id is the user or anonymous session that created the list
+CREATE TABLE IF NOT EXISTS lists (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    owner_id TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_lists_owner_id ON lists(owner_id);
+
+-- Everyone who can open a list, including its owner
+CREATE TABLE IF NOT EXISTS list_members (
+    list_id TEXT NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    member_id TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (list_id, member_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_members_member_id ON list_members(member_id);
+
+-- Invite links; anyone with the token can join the list with the given role
+CREATE TABLE IF NOT EXISTS list_invites (
+    token TEXT PRIMARY KEY,
+    list_id TEXT NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('editor', 'viewer')),
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Todos belong to a list; user_id now records who created them
+ALTER TABLE todos ADD COLUMN list_id TEXT;
+
+-- Every existing owner gets one list holding their todos
+INSERT INTO lists (id, name, owner_id)
+SELECT lower(hex(randomblob(16))), 'Todos', user_id
+FROM (SELECT DISTINCT user_id FROM todos);
+
+INSERT INTO list_members (list_id, member_id, role)
+SELECT id, owner_id, 'owner' FROM lists;
+
+UPDATE todos SET list_id = (SELECT id FROM lists WHERE lists.owner_id = todos.user_id);
+
+CREATE INDEX IF NOT EXISTS idx_todos_list_id ON todos(list_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_todos_list_id;
+ALTER TABLE todos DROP COLUMN list_id;
+DROP TABLE IF EXISTS list_invites;
+DROP INDEX IF EXISTS idx_list_members_member_id;
+DROP TABLE IF EXISTS list_members;
+DROP INDEX IF EXISTS idx_lists_owner_id;
+DROP TABLE IF EXISTS lists;
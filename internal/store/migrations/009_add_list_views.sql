@@ -0,0 +1,36 @@
+-- +goose Up
+-- Archived lists are kept but hidden from the sidebar and read-only
+ALTER TABLE lists ADD COLUMN archived_at TIMESTAMP;
+
+-- UI state of a member's view of a list, which used to be one per session
+CREATE TABLE IF NOT EXISTS list_views (
+    member_id TEXT NOT NULL,
+    list_id TEXT NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    mode INTEGER NOT NULL DEFAULT 0,
+    editing_id TEXT,
+    version INTEGER NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (member_id, list_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_views_list_id ON list_views(list_id);
+
+-- Every list of a session starts out in the mode the session was in; the
+-- todo being edited only carries over to the list it belongs to
+INSERT INTO list_views (member_id, list_id, mode, editing_id)
+SELECT s.id, m.list_id, COALESCE(s.mode, 0),
+       (SELECT t.id FROM todos t WHERE t.id = s.editing_id AND t.list_id = m.list_id)
+FROM sessions s
+JOIN list_members m ON m.member_id = s.id;
+
+ALTER TABLE sessions DROP COLUMN editing_id;
+ALTER TABLE sessions DROP COLUMN editing_idx;
+ALTER TABLE sessions DROP COLUMN mode;
+
+-- +goose Down
+ALTER TABLE sessions ADD COLUMN mode INTEGER DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN editing_idx INTEGER DEFAULT -1;
+ALTER TABLE sessions ADD COLUMN editing_id TEXT;
+DROP INDEX IF EXISTS idx_list_views_list_id;
+DROP TABLE IF EXISTS list_views;
+ALTER TABLE lists DROP COLUMN archived_at;
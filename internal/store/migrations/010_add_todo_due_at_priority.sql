@@ -0,0 +1,8 @@
+-- +goose Up
+-- Optional planning fields; a NULL priority means the todo has none
+ALTER TABLE todos ADD COLUMN due_at TIMESTAMP;
+ALTER TABLE todos ADD COLUMN priority INTEGER CHECK (priority BETWEEN 1 AND 3);
+
+-- +goose Down
+ALTER TABLE todos DROP COLUMN priority;
+ALTER TABLE todos DROP COLUMN due_at;
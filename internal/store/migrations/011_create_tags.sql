@@ -0,0 +1,26 @@
+-- +goose Up
+-- Tags are scoped to a list; names are stored lowercase without the leading #
+CREATE TABLE IF NOT EXISTS tags (
+    id TEXT PRIMARY KEY,
+    list_id TEXT NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (list_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+    todo_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    tag_id TEXT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (todo_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_tags_tag_id ON todo_tags(tag_id);
+
+-- Space-separated tags a view of a list is filtered by, on top of its mode
+ALTER TABLE list_views ADD COLUMN tag_filter TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE list_views DROP COLUMN tag_filter;
+DROP INDEX IF EXISTS idx_todo_tags_tag_id;
+DROP TABLE IF EXISTS todo_tags;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,36 @@
+-- +goose Up
+-- Full-text index over the text of todos, kept in sync by the triggers below
+CREATE VIRTUAL TABLE IF NOT EXISTS todos_fts USING fts5(
+    task,
+    content = 'todos',
+    content_rowid = 'rowid',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todos_fts_insert AFTER INSERT ON todos BEGIN
+    INSERT INTO todos_fts (rowid, task) VALUES (new.rowid, new.task);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todos_fts_delete AFTER DELETE ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, task) VALUES ('delete', old.rowid, old.task);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todos_fts_update AFTER UPDATE OF task ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, task) VALUES ('delete', old.rowid, old.task);
+    INSERT INTO todos_fts (rowid, task) VALUES (new.rowid, new.task);
+END;
+-- +goose StatementEnd
+
+-- Index the todos that already exist
+INSERT INTO todos_fts (todos_fts) VALUES ('rebuild');
+
+-- +goose Down
+DROP TRIGGER IF EXISTS todos_fts_update;
+DROP TRIGGER IF EXISTS todos_fts_delete;
+DROP TRIGGER IF EXISTS todos_fts_insert;
+DROP TABLE IF EXISTS todos_fts;
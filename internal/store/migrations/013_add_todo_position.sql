@@ -0,0 +1,19 @@
+-- +goose Up
+-- Manual order of the todos in a list. Positions start out 1024 apart so
+-- that a moved todo can take the midpoint between its new neighbours.
+ALTER TABLE todos ADD COLUMN position REAL NOT NULL DEFAULT 0;
+
+UPDATE todos SET position = (
+    SELECT ranked.rank * 1024
+    FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY list_id ORDER BY created_at, rowid) AS rank
+        FROM todos
+    ) AS ranked
+    WHERE ranked.id = todos.id
+);
+
+CREATE INDEX IF NOT EXISTS idx_todos_list_id_position ON todos(list_id, position);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_todos_list_id_position;
+ALTER TABLE todos DROP COLUMN position;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Subtasks point at the todo they belong to and go away with it
+ALTER TABLE todos ADD COLUMN parent_id TEXT REFERENCES todos(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_todos_parent_id ON todos(parent_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_todos_parent_id;
+ALTER TABLE todos DROP COLUMN parent_id;
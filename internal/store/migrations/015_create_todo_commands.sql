@@ -0,0 +1,18 @@
+-- +goose Up
+-- History of the todo changes a member made to a list, kept so they can be
+-- undone and redone. The payload holds the changed todos before and after.
+CREATE TABLE IF NOT EXISTS todo_commands (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    member_id TEXT NOT NULL,
+    list_id TEXT NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    label TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    undone INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_commands_member_list ON todo_commands(member_id, list_id, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_todo_commands_member_list;
+DROP TABLE IF EXISTS todo_commands;
@@ -0,0 +1,66 @@
+-- +goose Up
+-- Append-only log of every change to a todo: who made it, when, and the
+-- state of the todo afterwards, from which a list can be rebuilt as it was
+-- at any point in time. Events outlive the todos and lists they describe.
+CREATE TABLE IF NOT EXISTS todo_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    list_id TEXT NOT NULL,
+    todo_id TEXT NOT NULL,
+    actor_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    state TEXT NOT NULL,
+    deleted INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_events_list_id ON todo_events(list_id, id);
+CREATE INDEX IF NOT EXISTS idx_todo_events_todo_id ON todo_events(todo_id, id);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todo_events_no_update BEFORE UPDATE ON todo_events BEGIN
+    SELECT RAISE(ABORT, 'todo_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todo_events_no_delete BEFORE DELETE ON todo_events BEGIN
+    SELECT RAISE(ABORT, 'todo_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- Start the log of the todos that already exist with their creation
+INSERT INTO todo_events (list_id, todo_id, actor_id, action, state, created_at)
+SELECT
+    todos.list_id,
+    todos.id,
+    todos.user_id,
+    'Todo created',
+    json_object(
+        'id', todos.id,
+        'text', todos.task,
+        'completed', json(CASE WHEN todos.completed = 1 THEN 'true' ELSE 'false' END),
+        'dueAt', strftime('%Y-%m-%dT%H:%M:%SZ', todos.due_at),
+        'priority', COALESCE(todos.priority, 0),
+        'tags', (
+            SELECT json_group_array(name) FROM (
+                SELECT tags.name FROM todo_tags
+                JOIN tags ON tags.id = todo_tags.tag_id
+                WHERE todo_tags.todo_id = todos.id
+                ORDER BY tags.name
+            )
+        ),
+        'position', todos.position,
+        'parentId', COALESCE(todos.parent_id, ''),
+        'version', todos.version
+    ),
+    COALESCE(todos.created_at, CURRENT_TIMESTAMP)
+FROM todos
+WHERE todos.list_id IS NOT NULL
+ORDER BY todos.created_at, todos.rowid;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS todo_events_no_delete;
+DROP TRIGGER IF EXISTS todo_events_no_update;
+DROP INDEX IF EXISTS idx_todo_events_todo_id;
+DROP INDEX IF EXISTS idx_todo_events_list_id;
+DROP TABLE IF EXISTS todo_events;
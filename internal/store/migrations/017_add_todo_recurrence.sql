@@ -0,0 +1,10 @@
+-- +goose Up
+-- RRULE-style rule a todo repeats by, or '' for one-off todos. Only the
+-- latest occurrence of a recurring todo carries the rule.
+ALTER TABLE todos ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_todos_recurring_due_at ON todos(due_at) WHERE recurrence != '';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_todos_recurring_due_at;
+ALTER TABLE todos DROP COLUMN recurrence;
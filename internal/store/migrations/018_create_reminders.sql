@@ -0,0 +1,30 @@
+-- +goose Up
+-- Reminders members set on todos, one per member and todo. A reminder is sent
+-- once, which sets sent_at; pending ones survive restarts and are sent late
+-- rather than never.
+CREATE TABLE IF NOT EXISTS reminders (
+    id TEXT PRIMARY KEY,
+    todo_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    list_id TEXT NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    member_id TEXT NOT NULL,
+    remind_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (todo_id, member_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_pending ON reminders(remind_at) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_reminders_list_member ON reminders(list_id, member_id);
+
+-- Members who opted in to browser notifications for their reminders
+CREATE TABLE IF NOT EXISTS notification_settings (
+    member_id TEXT PRIMARY KEY,
+    browser INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_settings;
+DROP INDEX IF EXISTS idx_reminders_list_member;
+DROP INDEX IF EXISTS idx_reminders_pending;
+DROP TABLE IF EXISTS reminders;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Secret tokens of the calendar feeds members subscribe to, one per member.
+-- Anyone with the token can read the member's todos with a due date, so a
+-- new token replaces the old one.
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    member_id TEXT PRIMARY KEY,
+    token TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_todos_due_at ON todos(due_at) WHERE due_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_todos_due_at;
+DROP TABLE IF EXISTS calendar_feeds;
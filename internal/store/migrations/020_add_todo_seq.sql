@@ -0,0 +1,155 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- Give todos an INTEGER PRIMARY KEY alias, so that the full-text index and
+-- the ordering of todos with equal positions rely on a key that VACUUM keeps.
+-- SQLite can only add one by rebuilding the table. Foreign keys are off while
+-- it is rebuilt, so that dropping the old table does not cascade to the tags
+-- and reminders of its todos; that pragma has no effect inside a transaction.
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS todos_fts_update;
+DROP TRIGGER IF EXISTS todos_fts_delete;
+DROP TRIGGER IF EXISTS todos_fts_insert;
+DROP TABLE IF EXISTS todos_fts;
+
+CREATE TABLE todos_new (
+    id TEXT NOT NULL UNIQUE,
+    user_id TEXT NOT NULL,
+    task TEXT NOT NULL,
+    completed INTEGER DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    list_id TEXT,
+    due_at TIMESTAMP,
+    priority INTEGER CHECK (priority BETWEEN 1 AND 3),
+    position REAL NOT NULL DEFAULT 0,
+    parent_id TEXT REFERENCES todos(id) ON DELETE CASCADE,
+    recurrence TEXT NOT NULL DEFAULT '',
+    seq INTEGER PRIMARY KEY
+);
+
+-- Keep the current order of todos with equal positions
+INSERT INTO todos_new (seq, id, user_id, task, completed, created_at, updated_at, version, list_id, due_at, priority, position, parent_id, recurrence)
+SELECT rowid, id, user_id, task, completed, created_at, updated_at, version, list_id, due_at, priority, position, parent_id, recurrence
+FROM todos;
+
+DROP TABLE todos;
+ALTER TABLE todos_new RENAME TO todos;
+
+CREATE INDEX IF NOT EXISTS idx_todos_user_id ON todos(user_id);
+CREATE INDEX IF NOT EXISTS idx_todos_created_at ON todos(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_todos_completed ON todos(completed);
+CREATE INDEX IF NOT EXISTS idx_todos_list_id ON todos(list_id);
+CREATE INDEX IF NOT EXISTS idx_todos_list_id_position ON todos(list_id, position);
+CREATE INDEX IF NOT EXISTS idx_todos_parent_id ON todos(parent_id);
+CREATE INDEX IF NOT EXISTS idx_todos_recurring_due_at ON todos(due_at) WHERE recurrence != '';
+CREATE INDEX IF NOT EXISTS idx_todos_due_at ON todos(due_at) WHERE due_at IS NOT NULL;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS todos_fts USING fts5(
+    task,
+    content = 'todos',
+    content_rowid = 'seq',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todos_fts_insert AFTER INSERT ON todos BEGIN
+    INSERT INTO todos_fts (rowid, task) VALUES (new.seq, new.task);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todos_fts_delete AFTER DELETE ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, task) VALUES ('delete', old.seq, old.task);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todos_fts_update AFTER UPDATE OF task ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, task) VALUES ('delete', old.seq, old.task);
+    INSERT INTO todos_fts (rowid, task) VALUES (new.seq, new.task);
+END;
+-- +goose StatementEnd
+
+INSERT INTO todos_fts (todos_fts) VALUES ('rebuild');
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS todos_fts_update;
+DROP TRIGGER IF EXISTS todos_fts_delete;
+DROP TRIGGER IF EXISTS todos_fts_insert;
+DROP TABLE IF EXISTS todos_fts;
+
+CREATE TABLE todos_old (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    task TEXT NOT NULL,
+    completed INTEGER DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    list_id TEXT,
+    due_at TIMESTAMP,
+    priority INTEGER CHECK (priority BETWEEN 1 AND 3),
+    position REAL NOT NULL DEFAULT 0,
+    parent_id TEXT REFERENCES todos(id) ON DELETE CASCADE,
+    recurrence TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO todos_old (rowid, id, user_id, task, completed, created_at, updated_at, version, list_id, due_at, priority, position, parent_id, recurrence)
+SELECT seq, id, user_id, task, completed, created_at, updated_at, version, list_id, due_at, priority, position, parent_id, recurrence
+FROM todos;
+
+DROP TABLE todos;
+ALTER TABLE todos_old RENAME TO todos;
+
+CREATE INDEX IF NOT EXISTS idx_todos_user_id ON todos(user_id);
+CREATE INDEX IF NOT EXISTS idx_todos_created_at ON todos(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_todos_completed ON todos(completed);
+CREATE INDEX IF NOT EXISTS idx_todos_list_id ON todos(list_id);
+CREATE INDEX IF NOT EXISTS idx_todos_list_id_position ON todos(list_id, position);
+CREATE INDEX IF NOT EXISTS idx_todos_parent_id ON todos(parent_id);
+CREATE INDEX IF NOT EXISTS idx_todos_recurring_due_at ON todos(due_at) WHERE recurrence != '';
+CREATE INDEX IF NOT EXISTS idx_todos_due_at ON todos(due_at) WHERE due_at IS NOT NULL;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS todos_fts USING fts5(
+    task,
+    content = 'todos',
+    content_rowid = 'rowid',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todos_fts_insert AFTER INSERT ON todos BEGIN
+    INSERT INTO todos_fts (rowid, task) VALUES (new.rowid, new.task);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todos_fts_delete AFTER DELETE ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, task) VALUES ('delete', old.rowid, old.task);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS todos_fts_update AFTER UPDATE OF task ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, task) VALUES ('delete', old.rowid, old.task);
+    INSERT INTO todos_fts (rowid, task) VALUES (new.rowid, new.task);
+END;
+-- +goose StatementEnd
+
+INSERT INTO todos_fts (todos_fts) VALUES ('rebuild');
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
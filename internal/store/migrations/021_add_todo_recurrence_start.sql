@@ -0,0 +1,11 @@
+-- +goose Up
+-- The due date of the first occurrence of a recurring todo, which its rule
+-- counts from. Monthly and yearly rules keep its day of the month, so that an
+-- occurrence moved back to the end of a short month does not move the ones
+-- after it. NULL for todos that do not recur.
+ALTER TABLE todos ADD COLUMN recurrence_start TIMESTAMP;
+
+UPDATE todos SET recurrence_start = due_at WHERE recurrence != '';
+
+-- +goose Down
+ALTER TABLE todos DROP COLUMN recurrence_start;
@@ -6,22 +6,131 @@ package queries
 
 import (
 	"database/sql"
+	"time"
 )
 
+type CalendarFeed struct {
+	MemberID  string       `json:"member_id"`
+	Token     string       `json:"token"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type List struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	OwnerID    string       `json:"owner_id"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+	UpdatedAt  sql.NullTime `json:"updated_at"`
+	ArchivedAt sql.NullTime `json:"archived_at"`
+}
+
+type ListInvite struct {
+	Token     string       `json:"token"`
+	ListID    string       `json:"list_id"`
+	Role      string       `json:"role"`
+	CreatedBy string       `json:"created_by"`
+	CreatedAt sql.NullTime `json:"created_at"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+type ListMember struct {
+	ListID    string       `json:"list_id"`
+	MemberID  string       `json:"member_id"`
+	Role      string       `json:"role"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type ListView struct {
+	MemberID  string         `json:"member_id"`
+	ListID    string         `json:"list_id"`
+	Mode      int64          `json:"mode"`
+	EditingID sql.NullString `json:"editing_id"`
+	Version   int64          `json:"version"`
+	UpdatedAt sql.NullTime   `json:"updated_at"`
+	TagFilter string         `json:"tag_filter"`
+}
+
+type NotificationSetting struct {
+	MemberID  string       `json:"member_id"`
+	Browser   int64        `json:"browser"`
+	UpdatedAt sql.NullTime `json:"updated_at"`
+}
+
+type Reminder struct {
+	ID        string       `json:"id"`
+	TodoID    string       `json:"todo_id"`
+	ListID    string       `json:"list_id"`
+	MemberID  string       `json:"member_id"`
+	RemindAt  time.Time    `json:"remind_at"`
+	SentAt    sql.NullTime `json:"sent_at"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
 type Session struct {
-	ID         string        `json:"id"`
-	Data       string        `json:"data"`
-	CreatedAt  sql.NullTime  `json:"created_at"`
-	UpdatedAt  sql.NullTime  `json:"updated_at"`
-	Mode       sql.NullInt64 `json:"mode"`
-	EditingIdx sql.NullInt64 `json:"editing_idx"`
+	ID        string       `json:"id"`
+	Data      string       `json:"data"`
+	CreatedAt sql.NullTime `json:"created_at"`
+	UpdatedAt sql.NullTime `json:"updated_at"`
+	Version   int64        `json:"version"`
+}
+
+type Tag struct {
+	ID        string       `json:"id"`
+	ListID    string       `json:"list_id"`
+	Name      string       `json:"name"`
+	CreatedAt sql.NullTime `json:"created_at"`
 }
 
 type Todo struct {
-	ID        string        `json:"id"`
-	UserID    string        `json:"user_id"`
-	Task      string        `json:"task"`
-	Completed sql.NullInt64 `json:"completed"`
-	CreatedAt sql.NullTime  `json:"created_at"`
-	UpdatedAt sql.NullTime  `json:"updated_at"`
+	ID              string         `json:"id"`
+	UserID          string         `json:"user_id"`
+	Task            string         `json:"task"`
+	Completed       sql.NullInt64  `json:"completed"`
+	CreatedAt       sql.NullTime   `json:"created_at"`
+	UpdatedAt       sql.NullTime   `json:"updated_at"`
+	Version         int64          `json:"version"`
+	ListID          sql.NullString `json:"list_id"`
+	DueAt           sql.NullTime   `json:"due_at"`
+	Priority        sql.NullInt64  `json:"priority"`
+	Position        float64        `json:"position"`
+	ParentID        sql.NullString `json:"parent_id"`
+	Recurrence      string         `json:"recurrence"`
+	Seq             int64          `json:"seq"`
+	RecurrenceStart sql.NullTime   `json:"recurrence_start"`
+}
+
+type TodoCommand struct {
+	ID        int64        `json:"id"`
+	MemberID  string       `json:"member_id"`
+	ListID    string       `json:"list_id"`
+	Label     string       `json:"label"`
+	Payload   string       `json:"payload"`
+	Undone    int64        `json:"undone"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type TodoEvent struct {
+	ID        int64     `json:"id"`
+	ListID    string    `json:"list_id"`
+	TodoID    string    `json:"todo_id"`
+	ActorID   string    `json:"actor_id"`
+	Action    string    `json:"action"`
+	State     string    `json:"state"`
+	Deleted   int64     `json:"deleted"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TodoTag struct {
+	TodoID string `json:"todo_id"`
+	TagID  string `json:"tag_id"`
+}
+
+type User struct {
+	ID           string         `json:"id"`
+	Email        string         `json:"email"`
+	PasswordHash string         `json:"password_hash"`
+	CreatedAt    sql.NullTime   `json:"created_at"`
+	UpdatedAt    sql.NullTime   `json:"updated_at"`
+	OidcIssuer   sql.NullString `json:"oidc_issuer"`
+	OidcSubject  sql.NullString `json:"oidc_subject"`
 }
@@ -1,52 +1,391 @@
--- name: GetTodosByUser :many
+-- name: GetTodosByList :many
 SELECT * FROM todos 
-WHERE user_id = ? 
-ORDER BY created_at DESC;
+WHERE list_id = ? 
+ORDER BY position, created_at, seq;
 
 -- name: GetTodoByID :one
 SELECT * FROM todos 
 WHERE id = ?;
 
 -- name: CreateTodo :exec
-INSERT INTO todos (id, user_id, task, completed) 
-VALUES (?, ?, ?, ?);
+INSERT INTO todos (id, user_id, list_id, task, completed, due_at, priority, position, parent_id, recurrence, recurrence_start) 
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
--- name: UpdateTodoTask :exec
+-- name: UpdateTodoTask :execrows
 UPDATE todos 
-SET task = ?, updated_at = CURRENT_TIMESTAMP 
-WHERE id = ?;
+SET task = ?, due_at = ?, priority = ?, recurrence = ?, recurrence_start = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?;
 
--- name: ToggleTodoCompleted :exec
+-- name: ToggleTodoCompleted :execrows
 UPDATE todos 
 SET completed = CASE WHEN completed = 0 THEN 1 ELSE 0 END, 
+    version = version + 1,
     updated_at = CURRENT_TIMESTAMP 
-WHERE id = ?;
+WHERE id = ? AND version = ?;
 
--- name: DeleteTodo :exec
+-- name: MoveTodo :execrows
+UPDATE todos 
+SET position = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?;
+
+-- name: DeleteTodo :execrows
 DELETE FROM todos 
-WHERE id = ?;
+WHERE id = ? AND version = ?;
+
+-- name: RestoreTodo :execrows
+UPDATE todos 
+SET task = ?, completed = ?, due_at = ?, priority = ?, position = ?, parent_id = ?, recurrence = ?, recurrence_start = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?;
+
+-- name: ClearTodoRecurrence :execrows
+UPDATE todos 
+SET recurrence = '', recurrence_start = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?;
+
+-- name: GetRecurringTodosDueBefore :many
+SELECT * FROM todos 
+WHERE recurrence != '' AND completed = 0 AND list_id IS NOT NULL AND due_at < ? 
+ORDER BY list_id, position;
 
--- name: DeleteAllTodosByUser :exec
+-- name: SetTodoCompleted :execrows
+UPDATE todos 
+SET completed = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?;
+
+-- name: SetTodoParent :execrows
+UPDATE todos 
+SET parent_id = ?, position = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?;
+
+-- name: DeleteTodosByParent :exec
+DELETE FROM todos 
+WHERE parent_id = ?;
+
+-- name: DeleteCompletedTodosByList :exec
 DELETE FROM todos 
-WHERE user_id = ?;
+WHERE list_id = sqlc.arg(list_id) AND (completed = 1 OR parent_id IN (
+    SELECT id FROM todos WHERE list_id = sqlc.arg(list_id) AND completed = 1
+));
+
+-- name: DeleteAllTodosByList :exec
+DELETE FROM todos 
+WHERE list_id = ?;
+
+-- name: MoveTodosToUser :exec
+UPDATE todos 
+SET user_id = sqlc.arg(to_user_id), version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE user_id = sqlc.arg(from_user_id);
+
+-- name: SearchTodos :many
+SELECT todos.id, todos.completed, highlight(todos_fts, 0, char(2), char(3)) AS highlighted 
+FROM todos_fts 
+JOIN todos ON todos.seq = todos_fts.rowid 
+WHERE todos_fts MATCH sqlc.arg(query) AND todos.list_id = sqlc.arg(list_id) 
+ORDER BY rank 
+LIMIT sqlc.arg(max_results);
 
--- name: CountTodosByUser :one
+-- name: GetDueTodosByMember :many
+SELECT todos.id, todos.task, todos.completed, todos.due_at, todos.priority, todos.recurrence, todos.updated_at, lists.name AS list_name FROM todos 
+JOIN lists ON lists.id = todos.list_id 
+JOIN list_members ON list_members.list_id = todos.list_id 
+WHERE list_members.member_id = ? AND lists.archived_at IS NULL AND todos.due_at IS NOT NULL 
+ORDER BY todos.due_at, todos.id;
+
+-- List queries
+-- name: GetList :one
+SELECT * FROM lists WHERE id = ?;
+
+-- name: GetFirstListByOwner :one
+SELECT * FROM lists 
+WHERE owner_id = ? AND archived_at IS NULL 
+ORDER BY created_at, rowid 
+LIMIT 1;
+
+-- name: GetListsByMember :many
+SELECT lists.* FROM lists 
+JOIN list_members ON list_members.list_id = lists.id 
+WHERE list_members.member_id = ? 
+ORDER BY lists.created_at, lists.rowid;
+
+-- name: CreateList :exec
+INSERT INTO lists (id, name, owner_id) 
+VALUES (?, ?, ?);
+
+-- name: RenameList :exec
+UPDATE lists 
+SET name = ?, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ?;
+
+-- name: SetListArchivedAt :exec
+UPDATE lists 
+SET archived_at = ?, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ?;
+
+-- name: DeleteList :exec
+DELETE FROM lists WHERE id = ?;
+
+-- name: CountListsByOwner :one
 SELECT COUNT(*) as count 
-FROM todos 
-WHERE user_id = ?;
+FROM lists 
+WHERE owner_id = ?;
+
+-- name: MoveListsToOwner :exec
+UPDATE lists 
+SET owner_id = sqlc.arg(to_owner_id), updated_at = CURRENT_TIMESTAMP 
+WHERE owner_id = sqlc.arg(from_owner_id);
+
+-- name: GetListMember :one
+SELECT * FROM list_members 
+WHERE list_id = ? AND member_id = ?;
+
+-- name: AddListMember :exec
+INSERT INTO list_members (list_id, member_id, role) 
+VALUES (?, ?, ?)
+ON CONFLICT(list_id, member_id) DO UPDATE SET
+    role = excluded.role
+WHERE list_members.role = 'viewer' AND excluded.role = 'editor';
+
+-- name: MoveListMembers :exec
+UPDATE OR IGNORE list_members 
+SET member_id = sqlc.arg(to_member_id) 
+WHERE member_id = sqlc.arg(from_member_id);
+
+-- name: DeleteListMembers :exec
+DELETE FROM list_members WHERE list_id = ?;
+
+-- name: CreateListInvite :exec
+INSERT INTO list_invites (token, list_id, role, created_by, expires_at) 
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetListInvite :one
+SELECT * FROM list_invites WHERE token = ?;
+
+-- name: DeleteListInvites :exec
+DELETE FROM list_invites WHERE list_id = ?;
 
 -- Session queries
 -- name: GetSession :one
 SELECT * FROM sessions WHERE id = ?;
 
--- name: UpsertSession :exec
-INSERT INTO sessions (id, data, mode, editing_idx, updated_at)
-VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+-- name: UpsertSession :execrows
+INSERT INTO sessions (id, data, updated_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
 ON CONFLICT(id) DO UPDATE SET
     data = excluded.data,
-    mode = excluded.mode,
-    editing_idx = excluded.editing_idx,
-    updated_at = CURRENT_TIMESTAMP;
+    version = sessions.version + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE sessions.version = ?;
 
 -- name: DeleteSession :exec
 DELETE FROM sessions WHERE id = ?;
+
+-- List view queries
+-- name: GetListView :one
+SELECT * FROM list_views 
+WHERE member_id = ? AND list_id = ?;
+
+-- name: UpsertListView :execrows
+INSERT INTO list_views (member_id, list_id, mode, editing_id, tag_filter, updated_at)
+VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(member_id, list_id) DO UPDATE SET
+    mode = excluded.mode,
+    editing_id = excluded.editing_id,
+    tag_filter = excluded.tag_filter,
+    version = list_views.version + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE list_views.version = ?;
+
+-- name: DeleteListViews :exec
+DELETE FROM list_views WHERE list_id = ?;
+
+-- name: MoveListViews :exec
+UPDATE OR IGNORE list_views 
+SET member_id = sqlc.arg(to_member_id) 
+WHERE member_id = sqlc.arg(from_member_id);
+
+-- Tag queries
+-- name: UpsertTag :one
+INSERT INTO tags (id, list_id, name) 
+VALUES (?, ?, ?)
+ON CONFLICT(list_id, name) DO UPDATE SET name = excluded.name
+RETURNING id;
+
+-- name: AddTodoTag :exec
+INSERT OR IGNORE INTO todo_tags (todo_id, tag_id) 
+VALUES (?, ?);
+
+-- name: GetTodoTagsByList :many
+SELECT todo_tags.todo_id, tags.name FROM todo_tags 
+JOIN tags ON tags.id = todo_tags.tag_id 
+WHERE tags.list_id = ? 
+ORDER BY tags.name;
+
+-- name: DeleteTodoTags :exec
+DELETE FROM todo_tags WHERE todo_id = ?;
+
+-- name: DeleteCompletedTodoTagsByList :exec
+DELETE FROM todo_tags 
+WHERE todo_id IN (
+    SELECT id FROM todos 
+    WHERE list_id = sqlc.arg(list_id) AND (completed = 1 OR parent_id IN (
+        SELECT id FROM todos WHERE list_id = sqlc.arg(list_id) AND completed = 1
+    ))
+);
+
+-- name: DeleteTodoTagsByList :exec
+DELETE FROM todo_tags 
+WHERE tag_id IN (SELECT id FROM tags WHERE list_id = ?);
+
+-- name: DeleteTagsByList :exec
+DELETE FROM tags WHERE list_id = ?;
+
+-- Todo command queries
+-- name: CreateTodoCommand :exec
+INSERT INTO todo_commands (member_id, list_id, label, payload) 
+VALUES (?, ?, ?, ?);
+
+-- name: GetLastTodoCommand :one
+SELECT * FROM todo_commands 
+WHERE member_id = ? AND list_id = ? AND undone = 0 
+ORDER BY id DESC 
+LIMIT 1;
+
+-- name: GetNextUndoneTodoCommand :one
+SELECT * FROM todo_commands 
+WHERE member_id = ? AND list_id = ? AND undone = 1 
+ORDER BY id 
+LIMIT 1;
+
+-- name: SetTodoCommandUndone :exec
+UPDATE todo_commands 
+SET undone = ?, payload = ? 
+WHERE id = ?;
+
+-- name: DeleteTodoCommand :exec
+DELETE FROM todo_commands WHERE id = ?;
+
+-- name: DeleteUndoneTodoCommands :exec
+DELETE FROM todo_commands 
+WHERE member_id = ? AND list_id = ? AND undone = 1;
+
+-- name: TrimTodoCommands :exec
+DELETE FROM todo_commands 
+WHERE member_id = sqlc.arg(member_id) AND list_id = sqlc.arg(list_id) AND id NOT IN (
+    SELECT id FROM todo_commands 
+    WHERE member_id = sqlc.arg(member_id) AND list_id = sqlc.arg(list_id) 
+    ORDER BY id DESC 
+    LIMIT sqlc.arg(keep)
+);
+
+-- name: DeleteTodoCommandsByList :exec
+DELETE FROM todo_commands WHERE list_id = ?;
+
+-- name: MoveTodoCommands :exec
+UPDATE todo_commands 
+SET member_id = sqlc.arg(to_member_id) 
+WHERE member_id = sqlc.arg(from_member_id);
+
+-- Todo event queries
+-- name: CreateTodoEvent :exec
+INSERT INTO todo_events (list_id, todo_id, actor_id, action, state, deleted, created_at) 
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetTodoEventsByList :many
+SELECT * FROM todo_events 
+WHERE list_id = ? 
+ORDER BY id DESC 
+LIMIT ?;
+
+-- name: GetTodoEventsByTodo :many
+SELECT * FROM todo_events 
+WHERE list_id = ? AND todo_id = ? 
+ORDER BY id DESC 
+LIMIT ?;
+
+-- name: GetTodoEventsByListUntil :many
+SELECT * FROM todo_events 
+WHERE list_id = ? AND created_at <= ? 
+ORDER BY id;
+
+-- Reminder queries
+-- name: UpsertReminder :exec
+INSERT INTO reminders (id, todo_id, list_id, member_id, remind_at) 
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(todo_id, member_id) DO UPDATE SET remind_at = excluded.remind_at, sent_at = NULL;
+
+-- name: DeleteReminder :exec
+DELETE FROM reminders WHERE todo_id = ? AND member_id = ?;
+
+-- name: GetPendingRemindersByList :many
+SELECT * FROM reminders 
+WHERE list_id = ? AND member_id = ? AND sent_at IS NULL;
+
+-- name: GetDueReminders :many
+SELECT reminders.id, reminders.todo_id, reminders.list_id, reminders.member_id, reminders.remind_at, todos.task, todos.completed FROM reminders 
+JOIN todos ON todos.id = reminders.todo_id 
+WHERE reminders.sent_at IS NULL AND reminders.remind_at <= ? 
+ORDER BY reminders.remind_at 
+LIMIT ?;
+
+-- name: MarkReminderSent :execrows
+UPDATE reminders 
+SET sent_at = ? 
+WHERE id = ? AND sent_at IS NULL;
+
+-- name: GetNotificationSetting :one
+SELECT * FROM notification_settings WHERE member_id = ?;
+
+-- name: UpsertNotificationSetting :exec
+INSERT INTO notification_settings (member_id, browser) 
+VALUES (?, ?)
+ON CONFLICT(member_id) DO UPDATE SET browser = excluded.browser, updated_at = CURRENT_TIMESTAMP;
+
+-- name: MoveReminders :exec
+UPDATE OR IGNORE reminders 
+SET member_id = sqlc.arg(to_member_id) 
+WHERE member_id = sqlc.arg(from_member_id);
+
+-- name: MoveNotificationSetting :exec
+UPDATE OR IGNORE notification_settings 
+SET member_id = sqlc.arg(to_member_id), updated_at = CURRENT_TIMESTAMP 
+WHERE member_id = sqlc.arg(from_member_id);
+
+-- Calendar feed queries
+-- name: GetCalendarFeedByMember :one
+SELECT * FROM calendar_feeds WHERE member_id = ?;
+
+-- name: GetCalendarFeedByToken :one
+SELECT * FROM calendar_feeds WHERE token = ?;
+
+-- name: UpsertCalendarFeed :exec
+INSERT INTO calendar_feeds (member_id, token) 
+VALUES (?, ?)
+ON CONFLICT(member_id) DO UPDATE SET token = excluded.token, created_at = CURRENT_TIMESTAMP;
+
+-- name: MoveCalendarFeed :exec
+UPDATE OR IGNORE calendar_feeds 
+SET member_id = sqlc.arg(to_member_id) 
+WHERE member_id = sqlc.arg(from_member_id);
+
+-- User queries
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = ?;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email = ?;
+
+-- name: CreateUser :exec
+INSERT INTO users (id, email, password_hash) 
+VALUES (?, ?, ?);
+
+-- name: GetUserByOIDCSubject :one
+SELECT * FROM users WHERE oidc_issuer = ? AND oidc_subject = ?;
+
+-- name: CreateOIDCUser :exec
+INSERT INTO users (id, email, password_hash, oidc_issuer, oidc_subject) 
+VALUES (?, ?, '', ?, ?);
+
+-- name: LinkUserOIDCSubject :exec
+UPDATE users 
+SET oidc_issuer = ?, oidc_subject = ?, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ?;
@@ -8,50 +8,325 @@ package queries
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
-const countTodosByUser = `-- name: CountTodosByUser :one
+const addListMember = `-- name: AddListMember :exec
+INSERT INTO list_members (list_id, member_id, role) 
+VALUES (?, ?, ?)
+ON CONFLICT(list_id, member_id) DO UPDATE SET
+    role = excluded.role
+WHERE list_members.role = 'viewer' AND excluded.role = 'editor'
+`
+
+type AddListMemberParams struct {
+	ListID   string `json:"list_id"`
+	MemberID string `json:"member_id"`
+	Role     string `json:"role"`
+}
+
+func (q *Queries) AddListMember(ctx context.Context, arg AddListMemberParams) error {
+	_, err := q.db.ExecContext(ctx, addListMember, arg.ListID, arg.MemberID, arg.Role)
+	return err
+}
+
+const addTodoTag = `-- name: AddTodoTag :exec
+INSERT OR IGNORE INTO todo_tags (todo_id, tag_id) 
+VALUES (?, ?)
+`
+
+type AddTodoTagParams struct {
+	TodoID string `json:"todo_id"`
+	TagID  string `json:"tag_id"`
+}
+
+func (q *Queries) AddTodoTag(ctx context.Context, arg AddTodoTagParams) error {
+	_, err := q.db.ExecContext(ctx, addTodoTag, arg.TodoID, arg.TagID)
+	return err
+}
+
+const clearTodoRecurrence = `-- name: ClearTodoRecurrence :execrows
+UPDATE todos 
+SET recurrence = '', recurrence_start = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?
+`
+
+type ClearTodoRecurrenceParams struct {
+	ID      string `json:"id"`
+	Version int64  `json:"version"`
+}
+
+func (q *Queries) ClearTodoRecurrence(ctx context.Context, arg ClearTodoRecurrenceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, clearTodoRecurrence, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countListsByOwner = `-- name: CountListsByOwner :one
 SELECT COUNT(*) as count 
-FROM todos 
-WHERE user_id = ?
+FROM lists 
+WHERE owner_id = ?
 `
 
-func (q *Queries) CountTodosByUser(ctx context.Context, userID string) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countTodosByUser, userID)
+func (q *Queries) CountListsByOwner(ctx context.Context, ownerID string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countListsByOwner, ownerID)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
+const createList = `-- name: CreateList :exec
+INSERT INTO lists (id, name, owner_id) 
+VALUES (?, ?, ?)
+`
+
+type CreateListParams struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	OwnerID string `json:"owner_id"`
+}
+
+func (q *Queries) CreateList(ctx context.Context, arg CreateListParams) error {
+	_, err := q.db.ExecContext(ctx, createList, arg.ID, arg.Name, arg.OwnerID)
+	return err
+}
+
+const createListInvite = `-- name: CreateListInvite :exec
+INSERT INTO list_invites (token, list_id, role, created_by, expires_at) 
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateListInviteParams struct {
+	Token     string    `json:"token"`
+	ListID    string    `json:"list_id"`
+	Role      string    `json:"role"`
+	CreatedBy string    `json:"created_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateListInvite(ctx context.Context, arg CreateListInviteParams) error {
+	_, err := q.db.ExecContext(ctx, createListInvite,
+		arg.Token,
+		arg.ListID,
+		arg.Role,
+		arg.CreatedBy,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createOIDCUser = `-- name: CreateOIDCUser :exec
+INSERT INTO users (id, email, password_hash, oidc_issuer, oidc_subject) 
+VALUES (?, ?, '', ?, ?)
+`
+
+type CreateOIDCUserParams struct {
+	ID          string         `json:"id"`
+	Email       string         `json:"email"`
+	OidcIssuer  sql.NullString `json:"oidc_issuer"`
+	OidcSubject sql.NullString `json:"oidc_subject"`
+}
+
+func (q *Queries) CreateOIDCUser(ctx context.Context, arg CreateOIDCUserParams) error {
+	_, err := q.db.ExecContext(ctx, createOIDCUser,
+		arg.ID,
+		arg.Email,
+		arg.OidcIssuer,
+		arg.OidcSubject,
+	)
+	return err
+}
+
 const createTodo = `-- name: CreateTodo :exec
-INSERT INTO todos (id, user_id, task, completed) 
-VALUES (?, ?, ?, ?)
+INSERT INTO todos (id, user_id, list_id, task, completed, due_at, priority, position, parent_id, recurrence, recurrence_start) 
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateTodoParams struct {
-	ID        string        `json:"id"`
-	UserID    string        `json:"user_id"`
-	Task      string        `json:"task"`
-	Completed sql.NullInt64 `json:"completed"`
+	ID              string         `json:"id"`
+	UserID          string         `json:"user_id"`
+	ListID          sql.NullString `json:"list_id"`
+	Task            string         `json:"task"`
+	Completed       sql.NullInt64  `json:"completed"`
+	DueAt           sql.NullTime   `json:"due_at"`
+	Priority        sql.NullInt64  `json:"priority"`
+	Position        float64        `json:"position"`
+	ParentID        sql.NullString `json:"parent_id"`
+	Recurrence      string         `json:"recurrence"`
+	RecurrenceStart sql.NullTime   `json:"recurrence_start"`
 }
 
 func (q *Queries) CreateTodo(ctx context.Context, arg CreateTodoParams) error {
 	_, err := q.db.ExecContext(ctx, createTodo,
 		arg.ID,
 		arg.UserID,
+		arg.ListID,
 		arg.Task,
 		arg.Completed,
+		arg.DueAt,
+		arg.Priority,
+		arg.Position,
+		arg.ParentID,
+		arg.Recurrence,
+		arg.RecurrenceStart,
+	)
+	return err
+}
+
+const createTodoCommand = `-- name: CreateTodoCommand :exec
+INSERT INTO todo_commands (member_id, list_id, label, payload) 
+VALUES (?, ?, ?, ?)
+`
+
+type CreateTodoCommandParams struct {
+	MemberID string `json:"member_id"`
+	ListID   string `json:"list_id"`
+	Label    string `json:"label"`
+	Payload  string `json:"payload"`
+}
+
+// Todo command queries
+func (q *Queries) CreateTodoCommand(ctx context.Context, arg CreateTodoCommandParams) error {
+	_, err := q.db.ExecContext(ctx, createTodoCommand,
+		arg.MemberID,
+		arg.ListID,
+		arg.Label,
+		arg.Payload,
+	)
+	return err
+}
+
+const createTodoEvent = `-- name: CreateTodoEvent :exec
+INSERT INTO todo_events (list_id, todo_id, actor_id, action, state, deleted, created_at) 
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateTodoEventParams struct {
+	ListID    string    `json:"list_id"`
+	TodoID    string    `json:"todo_id"`
+	ActorID   string    `json:"actor_id"`
+	Action    string    `json:"action"`
+	State     string    `json:"state"`
+	Deleted   int64     `json:"deleted"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Todo event queries
+func (q *Queries) CreateTodoEvent(ctx context.Context, arg CreateTodoEventParams) error {
+	_, err := q.db.ExecContext(ctx, createTodoEvent,
+		arg.ListID,
+		arg.TodoID,
+		arg.ActorID,
+		arg.Action,
+		arg.State,
+		arg.Deleted,
+		arg.CreatedAt,
 	)
 	return err
 }
 
-const deleteAllTodosByUser = `-- name: DeleteAllTodosByUser :exec
+const createUser = `-- name: CreateUser :exec
+INSERT INTO users (id, email, password_hash) 
+VALUES (?, ?, ?)
+`
+
+type CreateUserParams struct {
+	ID           string `json:"id"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"password_hash"`
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
+	_, err := q.db.ExecContext(ctx, createUser, arg.ID, arg.Email, arg.PasswordHash)
+	return err
+}
+
+const deleteAllTodosByList = `-- name: DeleteAllTodosByList :exec
+DELETE FROM todos 
+WHERE list_id = ?
+`
+
+func (q *Queries) DeleteAllTodosByList(ctx context.Context, listID sql.NullString) error {
+	_, err := q.db.ExecContext(ctx, deleteAllTodosByList, listID)
+	return err
+}
+
+const deleteCompletedTodosByList = `-- name: DeleteCompletedTodosByList :exec
 DELETE FROM todos 
-WHERE user_id = ?
+WHERE list_id = ?1 AND (completed = 1 OR parent_id IN (
+    SELECT id FROM todos WHERE list_id = ?1 AND completed = 1
+))
+`
+
+func (q *Queries) DeleteCompletedTodosByList(ctx context.Context, listID sql.NullString) error {
+	_, err := q.db.ExecContext(ctx, deleteCompletedTodosByList, listID)
+	return err
+}
+
+const deleteCompletedTodoTagsByList = `-- name: DeleteCompletedTodoTagsByList :exec
+DELETE FROM todo_tags 
+WHERE todo_id IN (
+    SELECT id FROM todos 
+    WHERE list_id = ?1 AND (completed = 1 OR parent_id IN (
+        SELECT id FROM todos WHERE list_id = ?1 AND completed = 1
+    ))
+)
+`
+
+func (q *Queries) DeleteCompletedTodoTagsByList(ctx context.Context, listID sql.NullString) error {
+	_, err := q.db.ExecContext(ctx, deleteCompletedTodoTagsByList, listID)
+	return err
+}
+
+const deleteList = `-- name: DeleteList :exec
+DELETE FROM lists WHERE id = ?
+`
+
+func (q *Queries) DeleteList(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteList, id)
+	return err
+}
+
+const deleteListInvites = `-- name: DeleteListInvites :exec
+DELETE FROM list_invites WHERE list_id = ?
+`
+
+func (q *Queries) DeleteListInvites(ctx context.Context, listID string) error {
+	_, err := q.db.ExecContext(ctx, deleteListInvites, listID)
+	return err
+}
+
+const deleteListMembers = `-- name: DeleteListMembers :exec
+DELETE FROM list_members WHERE list_id = ?
+`
+
+func (q *Queries) DeleteListMembers(ctx context.Context, listID string) error {
+	_, err := q.db.ExecContext(ctx, deleteListMembers, listID)
+	return err
+}
+
+const deleteListViews = `-- name: DeleteListViews :exec
+DELETE FROM list_views WHERE list_id = ?
+`
+
+func (q *Queries) DeleteListViews(ctx context.Context, listID string) error {
+	_, err := q.db.ExecContext(ctx, deleteListViews, listID)
+	return err
+}
+
+const deleteReminder = `-- name: DeleteReminder :exec
+DELETE FROM reminders WHERE todo_id = ? AND member_id = ?
 `
 
-func (q *Queries) DeleteAllTodosByUser(ctx context.Context, userID string) error {
-	_, err := q.db.ExecContext(ctx, deleteAllTodosByUser, userID)
+type DeleteReminderParams struct {
+	TodoID   string `json:"todo_id"`
+	MemberID string `json:"member_id"`
+}
+
+func (q *Queries) DeleteReminder(ctx context.Context, arg DeleteReminderParams) error {
+	_, err := q.db.ExecContext(ctx, deleteReminder, arg.TodoID, arg.MemberID)
 	return err
 }
 
@@ -64,76 +339,209 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 	return err
 }
 
-const deleteTodo = `-- name: DeleteTodo :exec
+const deleteTagsByList = `-- name: DeleteTagsByList :exec
+DELETE FROM tags WHERE list_id = ?
+`
+
+func (q *Queries) DeleteTagsByList(ctx context.Context, listID string) error {
+	_, err := q.db.ExecContext(ctx, deleteTagsByList, listID)
+	return err
+}
+
+const deleteTodo = `-- name: DeleteTodo :execrows
 DELETE FROM todos 
-WHERE id = ?
+WHERE id = ? AND version = ?
+`
+
+type DeleteTodoParams struct {
+	ID      string `json:"id"`
+	Version int64  `json:"version"`
+}
+
+func (q *Queries) DeleteTodo(ctx context.Context, arg DeleteTodoParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTodo, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTodoCommand = `-- name: DeleteTodoCommand :exec
+DELETE FROM todo_commands WHERE id = ?
 `
 
-func (q *Queries) DeleteTodo(ctx context.Context, id string) error {
-	_, err := q.db.ExecContext(ctx, deleteTodo, id)
+func (q *Queries) DeleteTodoCommand(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTodoCommand, id)
 	return err
 }
 
-const getSession = `-- name: GetSession :one
-SELECT id, data, created_at, updated_at, mode, editing_idx FROM sessions WHERE id = ?
+const deleteTodoCommandsByList = `-- name: DeleteTodoCommandsByList :exec
+DELETE FROM todo_commands WHERE list_id = ?
 `
 
-// Session queries
-func (q *Queries) GetSession(ctx context.Context, id string) (Session, error) {
-	row := q.db.QueryRowContext(ctx, getSession, id)
-	var i Session
-	err := row.Scan(
-		&i.ID,
-		&i.Data,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Mode,
-		&i.EditingIdx,
-	)
+func (q *Queries) DeleteTodoCommandsByList(ctx context.Context, listID string) error {
+	_, err := q.db.ExecContext(ctx, deleteTodoCommandsByList, listID)
+	return err
+}
+
+const deleteTodosByParent = `-- name: DeleteTodosByParent :exec
+DELETE FROM todos 
+WHERE parent_id = ?
+`
+
+func (q *Queries) DeleteTodosByParent(ctx context.Context, parentID sql.NullString) error {
+	_, err := q.db.ExecContext(ctx, deleteTodosByParent, parentID)
+	return err
+}
+
+const deleteTodoTags = `-- name: DeleteTodoTags :exec
+DELETE FROM todo_tags WHERE todo_id = ?
+`
+
+func (q *Queries) DeleteTodoTags(ctx context.Context, todoID string) error {
+	_, err := q.db.ExecContext(ctx, deleteTodoTags, todoID)
+	return err
+}
+
+const deleteTodoTagsByList = `-- name: DeleteTodoTagsByList :exec
+DELETE FROM todo_tags 
+WHERE tag_id IN (SELECT id FROM tags WHERE list_id = ?)
+`
+
+func (q *Queries) DeleteTodoTagsByList(ctx context.Context, listID string) error {
+	_, err := q.db.ExecContext(ctx, deleteTodoTagsByList, listID)
+	return err
+}
+
+const deleteUndoneTodoCommands = `-- name: DeleteUndoneTodoCommands :exec
+DELETE FROM todo_commands 
+WHERE member_id = ? AND list_id = ? AND undone = 1
+`
+
+type DeleteUndoneTodoCommandsParams struct {
+	MemberID string `json:"member_id"`
+	ListID   string `json:"list_id"`
+}
+
+func (q *Queries) DeleteUndoneTodoCommands(ctx context.Context, arg DeleteUndoneTodoCommandsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUndoneTodoCommands, arg.MemberID, arg.ListID)
+	return err
+}
+
+const getCalendarFeedByMember = `-- name: GetCalendarFeedByMember :one
+SELECT member_id, token, created_at FROM calendar_feeds WHERE member_id = ?
+`
+
+// Calendar feed queries
+func (q *Queries) GetCalendarFeedByMember(ctx context.Context, memberID string) (CalendarFeed, error) {
+	row := q.db.QueryRowContext(ctx, getCalendarFeedByMember, memberID)
+	var i CalendarFeed
+	err := row.Scan(&i.MemberID, &i.Token, &i.CreatedAt)
 	return i, err
 }
 
-const getTodoByID = `-- name: GetTodoByID :one
-SELECT id, user_id, task, completed, created_at, updated_at FROM todos 
-WHERE id = ?
+const getCalendarFeedByToken = `-- name: GetCalendarFeedByToken :one
+SELECT member_id, token, created_at FROM calendar_feeds WHERE token = ?
 `
 
-func (q *Queries) GetTodoByID(ctx context.Context, id string) (Todo, error) {
-	row := q.db.QueryRowContext(ctx, getTodoByID, id)
-	var i Todo
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Task,
-		&i.Completed,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
+func (q *Queries) GetCalendarFeedByToken(ctx context.Context, token string) (CalendarFeed, error) {
+	row := q.db.QueryRowContext(ctx, getCalendarFeedByToken, token)
+	var i CalendarFeed
+	err := row.Scan(&i.MemberID, &i.Token, &i.CreatedAt)
 	return i, err
 }
 
-const getTodosByUser = `-- name: GetTodosByUser :many
-SELECT id, user_id, task, completed, created_at, updated_at FROM todos 
-WHERE user_id = ? 
-ORDER BY created_at DESC
+const getDueReminders = `-- name: GetDueReminders :many
+SELECT reminders.id, reminders.todo_id, reminders.list_id, reminders.member_id, reminders.remind_at, todos.task, todos.completed FROM reminders 
+JOIN todos ON todos.id = reminders.todo_id 
+WHERE reminders.sent_at IS NULL AND reminders.remind_at <= ? 
+ORDER BY reminders.remind_at 
+LIMIT ?
 `
 
-func (q *Queries) GetTodosByUser(ctx context.Context, userID string) ([]Todo, error) {
-	rows, err := q.db.QueryContext(ctx, getTodosByUser, userID)
+type GetDueRemindersParams struct {
+	RemindAt time.Time `json:"remind_at"`
+	Limit    int64     `json:"limit"`
+}
+
+type GetDueRemindersRow struct {
+	ID        string        `json:"id"`
+	TodoID    string        `json:"todo_id"`
+	ListID    string        `json:"list_id"`
+	MemberID  string        `json:"member_id"`
+	RemindAt  time.Time     `json:"remind_at"`
+	Task      string        `json:"task"`
+	Completed sql.NullInt64 `json:"completed"`
+}
+
+func (q *Queries) GetDueReminders(ctx context.Context, arg GetDueRemindersParams) ([]GetDueRemindersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDueReminders, arg.RemindAt, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Todo
+	var items []GetDueRemindersRow
 	for rows.Next() {
-		var i Todo
+		var i GetDueRemindersRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
+			&i.TodoID,
+			&i.ListID,
+			&i.MemberID,
+			&i.RemindAt,
 			&i.Task,
 			&i.Completed,
-			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDueTodosByMember = `-- name: GetDueTodosByMember :many
+SELECT todos.id, todos.task, todos.completed, todos.due_at, todos.priority, todos.recurrence, todos.updated_at, lists.name AS list_name FROM todos 
+JOIN lists ON lists.id = todos.list_id 
+JOIN list_members ON list_members.list_id = todos.list_id 
+WHERE list_members.member_id = ? AND lists.archived_at IS NULL AND todos.due_at IS NOT NULL 
+ORDER BY todos.due_at, todos.id
+`
+
+type GetDueTodosByMemberRow struct {
+	ID         string        `json:"id"`
+	Task       string        `json:"task"`
+	Completed  sql.NullInt64 `json:"completed"`
+	DueAt      sql.NullTime  `json:"due_at"`
+	Priority   sql.NullInt64 `json:"priority"`
+	Recurrence string        `json:"recurrence"`
+	UpdatedAt  sql.NullTime  `json:"updated_at"`
+	ListName   string        `json:"list_name"`
+}
+
+func (q *Queries) GetDueTodosByMember(ctx context.Context, memberID string) ([]GetDueTodosByMemberRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDueTodosByMember, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDueTodosByMemberRow
+	for rows.Next() {
+		var i GetDueTodosByMemberRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Task,
+			&i.Completed,
+			&i.DueAt,
+			&i.Priority,
+			&i.Recurrence,
 			&i.UpdatedAt,
+			&i.ListName,
 		); err != nil {
 			return nil, err
 		}
@@ -148,57 +556,1190 @@ func (q *Queries) GetTodosByUser(ctx context.Context, userID string) ([]Todo, er
 	return items, nil
 }
 
-const toggleTodoCompleted = `-- name: ToggleTodoCompleted :exec
-UPDATE todos 
-SET completed = CASE WHEN completed = 0 THEN 1 ELSE 0 END, 
-    updated_at = CURRENT_TIMESTAMP 
-WHERE id = ?
+const getFirstListByOwner = `-- name: GetFirstListByOwner :one
+SELECT id, name, owner_id, created_at, updated_at, archived_at FROM lists 
+WHERE owner_id = ? AND archived_at IS NULL 
+ORDER BY created_at, rowid 
+LIMIT 1
 `
 
-func (q *Queries) ToggleTodoCompleted(ctx context.Context, id string) error {
-	_, err := q.db.ExecContext(ctx, toggleTodoCompleted, id)
-	return err
+func (q *Queries) GetFirstListByOwner(ctx context.Context, ownerID string) (List, error) {
+	row := q.db.QueryRowContext(ctx, getFirstListByOwner, ownerID)
+	var i List
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.OwnerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ArchivedAt,
+	)
+	return i, err
 }
 
-const updateTodoTask = `-- name: UpdateTodoTask :exec
-UPDATE todos 
-SET task = ?, updated_at = CURRENT_TIMESTAMP 
-WHERE id = ?
+const getLastTodoCommand = `-- name: GetLastTodoCommand :one
+SELECT id, member_id, list_id, label, payload, undone, created_at FROM todo_commands 
+WHERE member_id = ? AND list_id = ? AND undone = 0 
+ORDER BY id DESC 
+LIMIT 1
 `
 
-type UpdateTodoTaskParams struct {
-	Task string `json:"task"`
-	ID   string `json:"id"`
+type GetLastTodoCommandParams struct {
+	MemberID string `json:"member_id"`
+	ListID   string `json:"list_id"`
 }
 
-func (q *Queries) UpdateTodoTask(ctx context.Context, arg UpdateTodoTaskParams) error {
-	_, err := q.db.ExecContext(ctx, updateTodoTask, arg.Task, arg.ID)
-	return err
+func (q *Queries) GetLastTodoCommand(ctx context.Context, arg GetLastTodoCommandParams) (TodoCommand, error) {
+	row := q.db.QueryRowContext(ctx, getLastTodoCommand, arg.MemberID, arg.ListID)
+	var i TodoCommand
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.ListID,
+		&i.Label,
+		&i.Payload,
+		&i.Undone,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-const upsertSession = `-- name: UpsertSession :exec
-INSERT INTO sessions (id, data, mode, editing_idx, updated_at)
-VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
-ON CONFLICT(id) DO UPDATE SET
-    data = excluded.data,
-    mode = excluded.mode,
-    editing_idx = excluded.editing_idx,
-    updated_at = CURRENT_TIMESTAMP
+const getList = `-- name: GetList :one
+SELECT id, name, owner_id, created_at, updated_at, archived_at FROM lists WHERE id = ?
 `
 
-type UpsertSessionParams struct {
-	ID         string        `json:"id"`
-	Data       string        `json:"data"`
-	Mode       sql.NullInt64 `json:"mode"`
-	EditingIdx sql.NullInt64 `json:"editing_idx"`
+// List queries
+func (q *Queries) GetList(ctx context.Context, id string) (List, error) {
+	row := q.db.QueryRowContext(ctx, getList, id)
+	var i List
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.OwnerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ArchivedAt,
+	)
+	return i, err
 }
 
-func (q *Queries) UpsertSession(ctx context.Context, arg UpsertSessionParams) error {
-	_, err := q.db.ExecContext(ctx, upsertSession,
-		arg.ID,
-		arg.Data,
-		arg.Mode,
-		arg.EditingIdx,
-	)
+const getListInvite = `-- name: GetListInvite :one
+SELECT token, list_id, role, created_by, created_at, expires_at FROM list_invites WHERE token = ?
+`
+
+func (q *Queries) GetListInvite(ctx context.Context, token string) (ListInvite, error) {
+	row := q.db.QueryRowContext(ctx, getListInvite, token)
+	var i ListInvite
+	err := row.Scan(
+		&i.Token,
+		&i.ListID,
+		&i.Role,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getListMember = `-- name: GetListMember :one
+SELECT list_id, member_id, role, created_at FROM list_members 
+WHERE list_id = ? AND member_id = ?
+`
+
+type GetListMemberParams struct {
+	ListID   string `json:"list_id"`
+	MemberID string `json:"member_id"`
+}
+
+func (q *Queries) GetListMember(ctx context.Context, arg GetListMemberParams) (ListMember, error) {
+	row := q.db.QueryRowContext(ctx, getListMember, arg.ListID, arg.MemberID)
+	var i ListMember
+	err := row.Scan(
+		&i.ListID,
+		&i.MemberID,
+		&i.Role,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getListsByMember = `-- name: GetListsByMember :many
+SELECT lists.id, lists.name, lists.owner_id, lists.created_at, lists.updated_at, lists.archived_at FROM lists 
+JOIN list_members ON list_members.list_id = lists.id 
+WHERE list_members.member_id = ? 
+ORDER BY lists.created_at, lists.rowid
+`
+
+func (q *Queries) GetListsByMember(ctx context.Context, memberID string) ([]List, error) {
+	rows, err := q.db.QueryContext(ctx, getListsByMember, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []List
+	for rows.Next() {
+		var i List
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListView = `-- name: GetListView :one
+SELECT member_id, list_id, mode, editing_id, version, updated_at, tag_filter FROM list_views 
+WHERE member_id = ? AND list_id = ?
+`
+
+type GetListViewParams struct {
+	MemberID string `json:"member_id"`
+	ListID   string `json:"list_id"`
+}
+
+// List view queries
+func (q *Queries) GetListView(ctx context.Context, arg GetListViewParams) (ListView, error) {
+	row := q.db.QueryRowContext(ctx, getListView, arg.MemberID, arg.ListID)
+	var i ListView
+	err := row.Scan(
+		&i.MemberID,
+		&i.ListID,
+		&i.Mode,
+		&i.EditingID,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.TagFilter,
+	)
+	return i, err
+}
+
+const getNextUndoneTodoCommand = `-- name: GetNextUndoneTodoCommand :one
+SELECT id, member_id, list_id, label, payload, undone, created_at FROM todo_commands 
+WHERE member_id = ? AND list_id = ? AND undone = 1 
+ORDER BY id 
+LIMIT 1
+`
+
+type GetNextUndoneTodoCommandParams struct {
+	MemberID string `json:"member_id"`
+	ListID   string `json:"list_id"`
+}
+
+func (q *Queries) GetNextUndoneTodoCommand(ctx context.Context, arg GetNextUndoneTodoCommandParams) (TodoCommand, error) {
+	row := q.db.QueryRowContext(ctx, getNextUndoneTodoCommand, arg.MemberID, arg.ListID)
+	var i TodoCommand
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.ListID,
+		&i.Label,
+		&i.Payload,
+		&i.Undone,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getNotificationSetting = `-- name: GetNotificationSetting :one
+SELECT member_id, browser, updated_at FROM notification_settings WHERE member_id = ?
+`
+
+func (q *Queries) GetNotificationSetting(ctx context.Context, memberID string) (NotificationSetting, error) {
+	row := q.db.QueryRowContext(ctx, getNotificationSetting, memberID)
+	var i NotificationSetting
+	err := row.Scan(&i.MemberID, &i.Browser, &i.UpdatedAt)
+	return i, err
+}
+
+const getPendingRemindersByList = `-- name: GetPendingRemindersByList :many
+SELECT id, todo_id, list_id, member_id, remind_at, sent_at, created_at FROM reminders 
+WHERE list_id = ? AND member_id = ? AND sent_at IS NULL
+`
+
+type GetPendingRemindersByListParams struct {
+	ListID   string `json:"list_id"`
+	MemberID string `json:"member_id"`
+}
+
+func (q *Queries) GetPendingRemindersByList(ctx context.Context, arg GetPendingRemindersByListParams) ([]Reminder, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingRemindersByList, arg.ListID, arg.MemberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Reminder
+	for rows.Next() {
+		var i Reminder
+		if err := rows.Scan(
+			&i.ID,
+			&i.TodoID,
+			&i.ListID,
+			&i.MemberID,
+			&i.RemindAt,
+			&i.SentAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecurringTodosDueBefore = `-- name: GetRecurringTodosDueBefore :many
+SELECT id, user_id, task, completed, created_at, updated_at, version, list_id, due_at, priority, position, parent_id, recurrence, seq, recurrence_start FROM todos 
+WHERE recurrence != '' AND completed = 0 AND list_id IS NOT NULL AND due_at < ? 
+ORDER BY list_id, position
+`
+
+func (q *Queries) GetRecurringTodosDueBefore(ctx context.Context, dueAt sql.NullTime) ([]Todo, error) {
+	rows, err := q.db.QueryContext(ctx, getRecurringTodosDueBefore, dueAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Todo
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Task,
+			&i.Completed,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.ListID,
+			&i.DueAt,
+			&i.Priority,
+			&i.Position,
+			&i.ParentID,
+			&i.Recurrence,
+			&i.Seq,
+			&i.RecurrenceStart,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSession = `-- name: GetSession :one
+SELECT id, data, created_at, updated_at, version FROM sessions WHERE id = ?
+`
+
+// Session queries
+func (q *Queries) GetSession(ctx context.Context, id string) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Data,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getTodoByID = `-- name: GetTodoByID :one
+SELECT id, user_id, task, completed, created_at, updated_at, version, list_id, due_at, priority, position, parent_id, recurrence, seq, recurrence_start FROM todos 
+WHERE id = ?
+`
+
+func (q *Queries) GetTodoByID(ctx context.Context, id string) (Todo, error) {
+	row := q.db.QueryRowContext(ctx, getTodoByID, id)
+	var i Todo
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Task,
+		&i.Completed,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.ListID,
+		&i.DueAt,
+		&i.Priority,
+		&i.Position,
+		&i.ParentID,
+		&i.Recurrence,
+		&i.Seq,
+		&i.RecurrenceStart,
+	)
+	return i, err
+}
+
+const getTodoEventsByList = `-- name: GetTodoEventsByList :many
+SELECT id, list_id, todo_id, actor_id, action, state, deleted, created_at FROM todo_events 
+WHERE list_id = ? 
+ORDER BY id DESC 
+LIMIT ?
+`
+
+type GetTodoEventsByListParams struct {
+	ListID string `json:"list_id"`
+	Limit  int64  `json:"limit"`
+}
+
+func (q *Queries) GetTodoEventsByList(ctx context.Context, arg GetTodoEventsByListParams) ([]TodoEvent, error) {
+	rows, err := q.db.QueryContext(ctx, getTodoEventsByList, arg.ListID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TodoEvent
+	for rows.Next() {
+		var i TodoEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListID,
+			&i.TodoID,
+			&i.ActorID,
+			&i.Action,
+			&i.State,
+			&i.Deleted,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTodoEventsByListUntil = `-- name: GetTodoEventsByListUntil :many
+SELECT id, list_id, todo_id, actor_id, action, state, deleted, created_at FROM todo_events 
+WHERE list_id = ? AND created_at <= ? 
+ORDER BY id
+`
+
+type GetTodoEventsByListUntilParams struct {
+	ListID    string    `json:"list_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) GetTodoEventsByListUntil(ctx context.Context, arg GetTodoEventsByListUntilParams) ([]TodoEvent, error) {
+	rows, err := q.db.QueryContext(ctx, getTodoEventsByListUntil, arg.ListID, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TodoEvent
+	for rows.Next() {
+		var i TodoEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListID,
+			&i.TodoID,
+			&i.ActorID,
+			&i.Action,
+			&i.State,
+			&i.Deleted,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTodoEventsByTodo = `-- name: GetTodoEventsByTodo :many
+SELECT id, list_id, todo_id, actor_id, action, state, deleted, created_at FROM todo_events 
+WHERE list_id = ? AND todo_id = ? 
+ORDER BY id DESC 
+LIMIT ?
+`
+
+type GetTodoEventsByTodoParams struct {
+	ListID string `json:"list_id"`
+	TodoID string `json:"todo_id"`
+	Limit  int64  `json:"limit"`
+}
+
+func (q *Queries) GetTodoEventsByTodo(ctx context.Context, arg GetTodoEventsByTodoParams) ([]TodoEvent, error) {
+	rows, err := q.db.QueryContext(ctx, getTodoEventsByTodo, arg.ListID, arg.TodoID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TodoEvent
+	for rows.Next() {
+		var i TodoEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListID,
+			&i.TodoID,
+			&i.ActorID,
+			&i.Action,
+			&i.State,
+			&i.Deleted,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTodosByList = `-- name: GetTodosByList :many
+SELECT id, user_id, task, completed, created_at, updated_at, version, list_id, due_at, priority, position, parent_id, recurrence, seq, recurrence_start FROM todos 
+WHERE list_id = ? 
+ORDER BY position, created_at, seq
+`
+
+func (q *Queries) GetTodosByList(ctx context.Context, listID sql.NullString) ([]Todo, error) {
+	rows, err := q.db.QueryContext(ctx, getTodosByList, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Todo
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Task,
+			&i.Completed,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.ListID,
+			&i.DueAt,
+			&i.Priority,
+			&i.Position,
+			&i.ParentID,
+			&i.Recurrence,
+			&i.Seq,
+			&i.RecurrenceStart,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTodoTagsByList = `-- name: GetTodoTagsByList :many
+SELECT todo_tags.todo_id, tags.name FROM todo_tags 
+JOIN tags ON tags.id = todo_tags.tag_id 
+WHERE tags.list_id = ? 
+ORDER BY tags.name
+`
+
+type GetTodoTagsByListRow struct {
+	TodoID string `json:"todo_id"`
+	Name   string `json:"name"`
+}
+
+func (q *Queries) GetTodoTagsByList(ctx context.Context, listID string) ([]GetTodoTagsByListRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTodoTagsByList, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTodoTagsByListRow
+	for rows.Next() {
+		var i GetTodoTagsByListRow
+		if err := rows.Scan(&i.TodoID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, email, password_hash, created_at, updated_at, oidc_issuer, oidc_subject FROM users WHERE email = ?
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OidcIssuer,
+		&i.OidcSubject,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, email, password_hash, created_at, updated_at, oidc_issuer, oidc_subject FROM users WHERE id = ?
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OidcIssuer,
+		&i.OidcSubject,
+	)
+	return i, err
+}
+
+const getUserByOIDCSubject = `-- name: GetUserByOIDCSubject :one
+SELECT id, email, password_hash, created_at, updated_at, oidc_issuer, oidc_subject FROM users WHERE oidc_issuer = ? AND oidc_subject = ?
+`
+
+type GetUserByOIDCSubjectParams struct {
+	OidcIssuer  sql.NullString `json:"oidc_issuer"`
+	OidcSubject sql.NullString `json:"oidc_subject"`
+}
+
+func (q *Queries) GetUserByOIDCSubject(ctx context.Context, arg GetUserByOIDCSubjectParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByOIDCSubject, arg.OidcIssuer, arg.OidcSubject)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OidcIssuer,
+		&i.OidcSubject,
+	)
+	return i, err
+}
+
+const linkUserOIDCSubject = `-- name: LinkUserOIDCSubject :exec
+UPDATE users 
+SET oidc_issuer = ?, oidc_subject = ?, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ?
+`
+
+type LinkUserOIDCSubjectParams struct {
+	OidcIssuer  sql.NullString `json:"oidc_issuer"`
+	OidcSubject sql.NullString `json:"oidc_subject"`
+	ID          string         `json:"id"`
+}
+
+func (q *Queries) LinkUserOIDCSubject(ctx context.Context, arg LinkUserOIDCSubjectParams) error {
+	_, err := q.db.ExecContext(ctx, linkUserOIDCSubject, arg.OidcIssuer, arg.OidcSubject, arg.ID)
+	return err
+}
+
+const markReminderSent = `-- name: MarkReminderSent :execrows
+UPDATE reminders 
+SET sent_at = ? 
+WHERE id = ? AND sent_at IS NULL
+`
+
+type MarkReminderSentParams struct {
+	SentAt sql.NullTime `json:"sent_at"`
+	ID     string       `json:"id"`
+}
+
+func (q *Queries) MarkReminderSent(ctx context.Context, arg MarkReminderSentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markReminderSent, arg.SentAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveCalendarFeed = `-- name: MoveCalendarFeed :exec
+UPDATE OR IGNORE calendar_feeds 
+SET member_id = ?1 
+WHERE member_id = ?2
+`
+
+type MoveCalendarFeedParams struct {
+	ToMemberID   string `json:"to_member_id"`
+	FromMemberID string `json:"from_member_id"`
+}
+
+func (q *Queries) MoveCalendarFeed(ctx context.Context, arg MoveCalendarFeedParams) error {
+	_, err := q.db.ExecContext(ctx, moveCalendarFeed, arg.ToMemberID, arg.FromMemberID)
+	return err
+}
+
+const moveListMembers = `-- name: MoveListMembers :exec
+UPDATE OR IGNORE list_members 
+SET member_id = ?1 
+WHERE member_id = ?2
+`
+
+type MoveListMembersParams struct {
+	ToMemberID   string `json:"to_member_id"`
+	FromMemberID string `json:"from_member_id"`
+}
+
+func (q *Queries) MoveListMembers(ctx context.Context, arg MoveListMembersParams) error {
+	_, err := q.db.ExecContext(ctx, moveListMembers, arg.ToMemberID, arg.FromMemberID)
+	return err
+}
+
+const moveListsToOwner = `-- name: MoveListsToOwner :exec
+UPDATE lists 
+SET owner_id = ?1, updated_at = CURRENT_TIMESTAMP 
+WHERE owner_id = ?2
+`
+
+type MoveListsToOwnerParams struct {
+	ToOwnerID   string `json:"to_owner_id"`
+	FromOwnerID string `json:"from_owner_id"`
+}
+
+func (q *Queries) MoveListsToOwner(ctx context.Context, arg MoveListsToOwnerParams) error {
+	_, err := q.db.ExecContext(ctx, moveListsToOwner, arg.ToOwnerID, arg.FromOwnerID)
+	return err
+}
+
+const moveListViews = `-- name: MoveListViews :exec
+UPDATE OR IGNORE list_views 
+SET member_id = ?1 
+WHERE member_id = ?2
+`
+
+type MoveListViewsParams struct {
+	ToMemberID   string `json:"to_member_id"`
+	FromMemberID string `json:"from_member_id"`
+}
+
+func (q *Queries) MoveListViews(ctx context.Context, arg MoveListViewsParams) error {
+	_, err := q.db.ExecContext(ctx, moveListViews, arg.ToMemberID, arg.FromMemberID)
+	return err
+}
+
+const moveNotificationSetting = `-- name: MoveNotificationSetting :exec
+UPDATE OR IGNORE notification_settings 
+SET member_id = ?1, updated_at = CURRENT_TIMESTAMP 
+WHERE member_id = ?2
+`
+
+type MoveNotificationSettingParams struct {
+	ToMemberID   string `json:"to_member_id"`
+	FromMemberID string `json:"from_member_id"`
+}
+
+func (q *Queries) MoveNotificationSetting(ctx context.Context, arg MoveNotificationSettingParams) error {
+	_, err := q.db.ExecContext(ctx, moveNotificationSetting, arg.ToMemberID, arg.FromMemberID)
+	return err
+}
+
+const moveReminders = `-- name: MoveReminders :exec
+UPDATE OR IGNORE reminders 
+SET member_id = ?1 
+WHERE member_id = ?2
+`
+
+type MoveRemindersParams struct {
+	ToMemberID   string `json:"to_member_id"`
+	FromMemberID string `json:"from_member_id"`
+}
+
+func (q *Queries) MoveReminders(ctx context.Context, arg MoveRemindersParams) error {
+	_, err := q.db.ExecContext(ctx, moveReminders, arg.ToMemberID, arg.FromMemberID)
+	return err
+}
+
+const moveTodo = `-- name: MoveTodo :execrows
+UPDATE todos 
+SET position = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?
+`
+
+type MoveTodoParams struct {
+	Position float64 `json:"position"`
+	ID       string  `json:"id"`
+	Version  int64   `json:"version"`
+}
+
+func (q *Queries) MoveTodo(ctx context.Context, arg MoveTodoParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveTodo, arg.Position, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveTodoCommands = `-- name: MoveTodoCommands :exec
+UPDATE todo_commands 
+SET member_id = ?1 
+WHERE member_id = ?2
+`
+
+type MoveTodoCommandsParams struct {
+	ToMemberID   string `json:"to_member_id"`
+	FromMemberID string `json:"from_member_id"`
+}
+
+func (q *Queries) MoveTodoCommands(ctx context.Context, arg MoveTodoCommandsParams) error {
+	_, err := q.db.ExecContext(ctx, moveTodoCommands, arg.ToMemberID, arg.FromMemberID)
+	return err
+}
+
+const moveTodosToUser = `-- name: MoveTodosToUser :exec
+UPDATE todos 
+SET user_id = ?1, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE user_id = ?2
+`
+
+type MoveTodosToUserParams struct {
+	ToUserID   string `json:"to_user_id"`
+	FromUserID string `json:"from_user_id"`
+}
+
+func (q *Queries) MoveTodosToUser(ctx context.Context, arg MoveTodosToUserParams) error {
+	_, err := q.db.ExecContext(ctx, moveTodosToUser, arg.ToUserID, arg.FromUserID)
+	return err
+}
+
+const renameList = `-- name: RenameList :exec
+UPDATE lists 
+SET name = ?, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ?
+`
+
+type RenameListParams struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+func (q *Queries) RenameList(ctx context.Context, arg RenameListParams) error {
+	_, err := q.db.ExecContext(ctx, renameList, arg.Name, arg.ID)
+	return err
+}
+
+const restoreTodo = `-- name: RestoreTodo :execrows
+UPDATE todos 
+SET task = ?, completed = ?, due_at = ?, priority = ?, position = ?, parent_id = ?, recurrence = ?, recurrence_start = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?
+`
+
+type RestoreTodoParams struct {
+	Task            string         `json:"task"`
+	Completed       sql.NullInt64  `json:"completed"`
+	DueAt           sql.NullTime   `json:"due_at"`
+	Priority        sql.NullInt64  `json:"priority"`
+	Position        float64        `json:"position"`
+	ParentID        sql.NullString `json:"parent_id"`
+	Recurrence      string         `json:"recurrence"`
+	RecurrenceStart sql.NullTime   `json:"recurrence_start"`
+	ID              string         `json:"id"`
+	Version         int64          `json:"version"`
+}
+
+func (q *Queries) RestoreTodo(ctx context.Context, arg RestoreTodoParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreTodo,
+		arg.Task,
+		arg.Completed,
+		arg.DueAt,
+		arg.Priority,
+		arg.Position,
+		arg.ParentID,
+		arg.Recurrence,
+		arg.RecurrenceStart,
+		arg.ID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchTodos = `-- name: SearchTodos :many
+SELECT todos.id, todos.completed, highlight(todos_fts, 0, char(2), char(3)) AS highlighted 
+FROM todos_fts 
+JOIN todos ON todos.seq = todos_fts.rowid 
+WHERE todos_fts MATCH ? AND todos.list_id = ? 
+ORDER BY rank 
+LIMIT ?
+`
+
+type SearchTodosParams struct {
+	Query      string         `json:"query"`
+	ListID     sql.NullString `json:"list_id"`
+	MaxResults int64          `json:"max_results"`
+}
+
+type SearchTodosRow struct {
+	ID          string        `json:"id"`
+	Completed   sql.NullInt64 `json:"completed"`
+	Highlighted string        `json:"highlighted"`
+}
+
+func (q *Queries) SearchTodos(ctx context.Context, arg SearchTodosParams) ([]SearchTodosRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchTodos, arg.Query, arg.ListID, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTodosRow
+	for rows.Next() {
+		var i SearchTodosRow
+		if err := rows.Scan(&i.ID, &i.Completed, &i.Highlighted); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setListArchivedAt = `-- name: SetListArchivedAt :exec
+UPDATE lists 
+SET archived_at = ?, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ?
+`
+
+type SetListArchivedAtParams struct {
+	ArchivedAt sql.NullTime `json:"archived_at"`
+	ID         string       `json:"id"`
+}
+
+func (q *Queries) SetListArchivedAt(ctx context.Context, arg SetListArchivedAtParams) error {
+	_, err := q.db.ExecContext(ctx, setListArchivedAt, arg.ArchivedAt, arg.ID)
+	return err
+}
+
+const setTodoCommandUndone = `-- name: SetTodoCommandUndone :exec
+UPDATE todo_commands 
+SET undone = ?, payload = ? 
+WHERE id = ?
+`
+
+type SetTodoCommandUndoneParams struct {
+	Undone  int64  `json:"undone"`
+	Payload string `json:"payload"`
+	ID      int64  `json:"id"`
+}
+
+func (q *Queries) SetTodoCommandUndone(ctx context.Context, arg SetTodoCommandUndoneParams) error {
+	_, err := q.db.ExecContext(ctx, setTodoCommandUndone, arg.Undone, arg.Payload, arg.ID)
+	return err
+}
+
+const setTodoCompleted = `-- name: SetTodoCompleted :execrows
+UPDATE todos 
+SET completed = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?
+`
+
+type SetTodoCompletedParams struct {
+	Completed sql.NullInt64 `json:"completed"`
+	ID        string        `json:"id"`
+	Version   int64         `json:"version"`
+}
+
+func (q *Queries) SetTodoCompleted(ctx context.Context, arg SetTodoCompletedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setTodoCompleted, arg.Completed, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setTodoParent = `-- name: SetTodoParent :execrows
+UPDATE todos 
+SET parent_id = ?, position = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?
+`
+
+type SetTodoParentParams struct {
+	ParentID sql.NullString `json:"parent_id"`
+	Position float64        `json:"position"`
+	ID       string         `json:"id"`
+	Version  int64          `json:"version"`
+}
+
+func (q *Queries) SetTodoParent(ctx context.Context, arg SetTodoParentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setTodoParent,
+		arg.ParentID,
+		arg.Position,
+		arg.ID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const toggleTodoCompleted = `-- name: ToggleTodoCompleted :execrows
+UPDATE todos 
+SET completed = CASE WHEN completed = 0 THEN 1 ELSE 0 END, 
+    version = version + 1,
+    updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?
+`
+
+type ToggleTodoCompletedParams struct {
+	ID      string `json:"id"`
+	Version int64  `json:"version"`
+}
+
+func (q *Queries) ToggleTodoCompleted(ctx context.Context, arg ToggleTodoCompletedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, toggleTodoCompleted, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const trimTodoCommands = `-- name: TrimTodoCommands :exec
+DELETE FROM todo_commands 
+WHERE member_id = ?1 AND list_id = ?2 AND id NOT IN (
+    SELECT id FROM todo_commands 
+    WHERE member_id = ?1 AND list_id = ?2 
+    ORDER BY id DESC 
+    LIMIT ?3
+)
+`
+
+type TrimTodoCommandsParams struct {
+	MemberID string `json:"member_id"`
+	ListID   string `json:"list_id"`
+	Keep     int64  `json:"keep"`
+}
+
+func (q *Queries) TrimTodoCommands(ctx context.Context, arg TrimTodoCommandsParams) error {
+	_, err := q.db.ExecContext(ctx, trimTodoCommands, arg.MemberID, arg.ListID, arg.Keep)
+	return err
+}
+
+const updateTodoTask = `-- name: UpdateTodoTask :execrows
+UPDATE todos 
+SET task = ?, due_at = ?, priority = ?, recurrence = ?, recurrence_start = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP 
+WHERE id = ? AND version = ?
+`
+
+type UpdateTodoTaskParams struct {
+	Task            string        `json:"task"`
+	DueAt           sql.NullTime  `json:"due_at"`
+	Priority        sql.NullInt64 `json:"priority"`
+	Recurrence      string        `json:"recurrence"`
+	RecurrenceStart sql.NullTime  `json:"recurrence_start"`
+	ID              string        `json:"id"`
+	Version         int64         `json:"version"`
+}
+
+func (q *Queries) UpdateTodoTask(ctx context.Context, arg UpdateTodoTaskParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateTodoTask,
+		arg.Task,
+		arg.DueAt,
+		arg.Priority,
+		arg.Recurrence,
+		arg.RecurrenceStart,
+		arg.ID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertCalendarFeed = `-- name: UpsertCalendarFeed :exec
+INSERT INTO calendar_feeds (member_id, token) 
+VALUES (?, ?)
+ON CONFLICT(member_id) DO UPDATE SET token = excluded.token, created_at = CURRENT_TIMESTAMP
+`
+
+type UpsertCalendarFeedParams struct {
+	MemberID string `json:"member_id"`
+	Token    string `json:"token"`
+}
+
+func (q *Queries) UpsertCalendarFeed(ctx context.Context, arg UpsertCalendarFeedParams) error {
+	_, err := q.db.ExecContext(ctx, upsertCalendarFeed, arg.MemberID, arg.Token)
+	return err
+}
+
+const upsertListView = `-- name: UpsertListView :execrows
+INSERT INTO list_views (member_id, list_id, mode, editing_id, tag_filter, updated_at)
+VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(member_id, list_id) DO UPDATE SET
+    mode = excluded.mode,
+    editing_id = excluded.editing_id,
+    tag_filter = excluded.tag_filter,
+    version = list_views.version + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE list_views.version = ?
+`
+
+type UpsertListViewParams struct {
+	MemberID  string         `json:"member_id"`
+	ListID    string         `json:"list_id"`
+	Mode      int64          `json:"mode"`
+	EditingID sql.NullString `json:"editing_id"`
+	TagFilter string         `json:"tag_filter"`
+	Version   int64          `json:"version"`
+}
+
+func (q *Queries) UpsertListView(ctx context.Context, arg UpsertListViewParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upsertListView,
+		arg.MemberID,
+		arg.ListID,
+		arg.Mode,
+		arg.EditingID,
+		arg.TagFilter,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertNotificationSetting = `-- name: UpsertNotificationSetting :exec
+INSERT INTO notification_settings (member_id, browser) 
+VALUES (?, ?)
+ON CONFLICT(member_id) DO UPDATE SET browser = excluded.browser, updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertNotificationSettingParams struct {
+	MemberID string `json:"member_id"`
+	Browser  int64  `json:"browser"`
+}
+
+func (q *Queries) UpsertNotificationSetting(ctx context.Context, arg UpsertNotificationSettingParams) error {
+	_, err := q.db.ExecContext(ctx, upsertNotificationSetting, arg.MemberID, arg.Browser)
+	return err
+}
+
+const upsertReminder = `-- name: UpsertReminder :exec
+INSERT INTO reminders (id, todo_id, list_id, member_id, remind_at) 
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(todo_id, member_id) DO UPDATE SET remind_at = excluded.remind_at, sent_at = NULL
+`
+
+type UpsertReminderParams struct {
+	ID       string    `json:"id"`
+	TodoID   string    `json:"todo_id"`
+	ListID   string    `json:"list_id"`
+	MemberID string    `json:"member_id"`
+	RemindAt time.Time `json:"remind_at"`
+}
+
+// Reminder queries
+func (q *Queries) UpsertReminder(ctx context.Context, arg UpsertReminderParams) error {
+	_, err := q.db.ExecContext(ctx, upsertReminder,
+		arg.ID,
+		arg.TodoID,
+		arg.ListID,
+		arg.MemberID,
+		arg.RemindAt,
+	)
 	return err
 }
+
+const upsertSession = `-- name: UpsertSession :execrows
+INSERT INTO sessions (id, data, updated_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(id) DO UPDATE SET
+    data = excluded.data,
+    version = sessions.version + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE sessions.version = ?
+`
+
+type UpsertSessionParams struct {
+	ID      string `json:"id"`
+	Data    string `json:"data"`
+	Version int64  `json:"version"`
+}
+
+func (q *Queries) UpsertSession(ctx context.Context, arg UpsertSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upsertSession, arg.ID, arg.Data, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertTag = `-- name: UpsertTag :one
+INSERT INTO tags (id, list_id, name) 
+VALUES (?, ?, ?)
+ON CONFLICT(list_id, name) DO UPDATE SET name = excluded.name
+RETURNING id
+`
+
+type UpsertTagParams struct {
+	ID     string `json:"id"`
+	ListID string `json:"list_id"`
+	Name   string `json:"name"`
+}
+
+// Tag queries
+func (q *Queries) UpsertTag(ctx context.Context, arg UpsertTagParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, upsertTag, arg.ID, arg.ListID, arg.Name)
+	var id string
+	err := row.Scan(&id)
+	return id, err
+}
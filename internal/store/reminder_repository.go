@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// ReminderRepository is the concrete implementation of domain.ReminderRepository.
+// It wraps sqlc-generated queries and acts as a driven adapter in hexagonal architecture.
+type ReminderRepository struct {
+	store *SQLiteStore
+}
+
+// Ensure ReminderRepository implements domain.ReminderRepository at compile time.
+var _ domain.ReminderRepository = (*ReminderRepository)(nil)
+
+// NewReminderRepository creates a new ReminderRepository instance.
+func NewReminderRepository(st *SQLiteStore) *ReminderRepository {
+	return &ReminderRepository{store: st}
+}
+
+// UpsertReminder sets the reminder of a member on a todo, making it pending
+// again if it was already sent.
+func (r *ReminderRepository) UpsertReminder(ctx context.Context, arg queries.UpsertReminderParams) error {
+	return r.store.conn(ctx).UpsertReminder(ctx, arg)
+}
+
+// DeleteReminder removes the reminder of a member on a todo.
+func (r *ReminderRepository) DeleteReminder(ctx context.Context, arg queries.DeleteReminderParams) error {
+	return r.store.conn(ctx).DeleteReminder(ctx, arg)
+}
+
+// GetPendingRemindersByList retrieves the reminders a member has yet to get
+// for the todos of a list.
+func (r *ReminderRepository) GetPendingRemindersByList(ctx context.Context, arg queries.GetPendingRemindersByListParams) ([]queries.Reminder, error) {
+	return r.store.conn(ctx).GetPendingRemindersByList(ctx, arg)
+}
+
+// GetDueReminders retrieves the oldest pending reminders that are due,
+// together with the todos they are for.
+func (r *ReminderRepository) GetDueReminders(ctx context.Context, arg queries.GetDueRemindersParams) ([]queries.GetDueRemindersRow, error) {
+	return r.store.conn(ctx).GetDueReminders(ctx, arg)
+}
+
+// MarkReminderSent marks a pending reminder sent. It affects no rows if the
+// reminder was sent already.
+func (r *ReminderRepository) MarkReminderSent(ctx context.Context, arg queries.MarkReminderSentParams) (int64, error) {
+	return r.store.conn(ctx).MarkReminderSent(ctx, arg)
+}
+
+// GetNotificationSetting retrieves whether a member opted in to browser notifications.
+func (r *ReminderRepository) GetNotificationSetting(ctx context.Context, memberID string) (queries.NotificationSetting, error) {
+	return r.store.conn(ctx).GetNotificationSetting(ctx, memberID)
+}
+
+// UpsertNotificationSetting sets whether a member gets browser notifications.
+func (r *ReminderRepository) UpsertNotificationSetting(ctx context.Context, arg queries.UpsertNotificationSettingParams) error {
+	return r.store.conn(ctx).UpsertNotificationSetting(ctx, arg)
+}
+
+// MoveReminders reassigns the reminders of one member ID to another,
+// skipping todos the target already has a reminder on.
+func (r *ReminderRepository) MoveReminders(ctx context.Context, arg queries.MoveRemindersParams) error {
+	return r.store.conn(ctx).MoveReminders(ctx, arg)
+}
+
+// MoveNotificationSetting reassigns the notification setting of one member ID
+// to another, unless the target has one.
+func (r *ReminderRepository) MoveNotificationSetting(ctx context.Context, arg queries.MoveNotificationSettingParams) error {
+	return r.store.conn(ctx).MoveNotificationSetting(ctx, arg)
+}
@@ -23,10 +23,26 @@ func NewSessionRepository(st *SQLiteStore) *SessionRepository {
 
 // GetSession retrieves a session by its ID.
 func (r *SessionRepository) GetSession(ctx context.Context, sessionID string) (queries.Session, error) {
-	return r.store.Queries().GetSession(ctx, sessionID)
+	return r.store.conn(ctx).GetSession(ctx, sessionID)
 }
 
-// UpsertSession inserts or updates a session.
-func (r *SessionRepository) UpsertSession(ctx context.Context, arg queries.UpsertSessionParams) error {
-	return r.store.Queries().UpsertSession(ctx, arg)
+// UpsertSession inserts a session, or updates it if its version matches.
+func (r *SessionRepository) UpsertSession(ctx context.Context, arg queries.UpsertSessionParams) (int64, error) {
+	return r.store.conn(ctx).UpsertSession(ctx, arg)
+}
+
+// GetListView retrieves the UI state of a session's view of a list.
+func (r *SessionRepository) GetListView(ctx context.Context, arg queries.GetListViewParams) (queries.ListView, error) {
+	return r.store.conn(ctx).GetListView(ctx, arg)
+}
+
+// UpsertListView inserts the UI state of a list view, or updates it if its version matches.
+func (r *SessionRepository) UpsertListView(ctx context.Context, arg queries.UpsertListViewParams) (int64, error) {
+	return r.store.conn(ctx).UpsertListView(ctx, arg)
+}
+
+// MoveListViews reassigns the list views of one member ID to another,
+// skipping lists the target already has a view of.
+func (r *SessionRepository) MoveListViews(ctx context.Context, arg queries.MoveListViewsParams) error {
+	return r.store.conn(ctx).MoveListViews(ctx, arg)
 }
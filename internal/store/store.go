@@ -11,6 +11,7 @@ import (
 
 	_ "modernc.org/sqlite" // SQLite driver registration
 
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
 	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
 )
 
@@ -23,6 +24,9 @@ type SQLiteStore struct {
 	queries *queries.Queries
 }
 
+// Ensure SQLiteStore implements domain.UnitOfWork at compile time.
+var _ domain.UnitOfWork = (*SQLiteStore)(nil)
+
 // Open creates a new SQLiteStore with the given DSN.
 // DSN examples: ":memory:", "file:todos.db", "./data/todos.db"
 func Open(dsn string) (*SQLiteStore, error) {
@@ -37,6 +41,12 @@ func Open(dsn string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	// Every connection to ":memory:" gets its own empty database,
+	// so pin the pool to a single connection.
+	if dsn == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+
 	// Enable foreign keys and WAL mode for better concurrency
 	ctx := context.Background()
 	pragmas := []string{
@@ -85,7 +95,12 @@ func (s *SQLiteStore) DB() *sql.DB {
 // If fn returns an error, the transaction is rolled back.
 // If fn returns nil, the transaction is committed.
 // The txCtx carries the transaction state for repositories to use.
+// If ctx already carries a transaction, fn joins it instead of starting a new one.
 func (s *SQLiteStore) WithinTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin transaction: %w", err)
@@ -110,8 +125,6 @@ func (s *SQLiteStore) WithinTransaction(ctx context.Context, fn func(txCtx conte
 // conn returns the appropriate queries.Queries instance for the given context.
 // If the context contains a transaction, it returns queries bound to that transaction.
 // Otherwise, it returns queries bound to the main database connection.
-//
-//nolint:unused // Reserved for future transaction support
 func (s *SQLiteStore) conn(ctx context.Context) *queries.Queries {
 	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
 		return s.queries.WithTx(tx)
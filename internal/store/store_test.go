@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+var errAbort = errors.New("abort")
+
+// openTestStore opens an in-memory store holding the list "list-1" of
+// "session-1".
+func openTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	st, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	if err := NewListRepository(st).CreateList(context.Background(), queries.CreateListParams{
+		ID:      "list-1",
+		Name:    "Todos",
+		OwnerID: "session-1",
+	}); err != nil {
+		t.Fatalf("CreateList: %v", err)
+	}
+	return st
+}
+
+// saveTodoAndView writes a todo and a list view of the session, the two
+// writes TodoService makes atomically.
+func saveTodoAndView(ctx context.Context, todos *TodoRepository, sessions *SessionRepository) error {
+	if err := todos.CreateTodo(ctx, queries.CreateTodoParams{
+		ID:     "todo-1",
+		UserID: "session-1",
+		ListID: sql.NullString{String: "list-1", Valid: true},
+		Task:   "Buy milk",
+	}); err != nil {
+		return err
+	}
+	rows, err := sessions.UpsertListView(ctx, queries.UpsertListViewParams{
+		MemberID: "session-1",
+		ListID:   "list-1",
+	})
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return errors.New("list view not saved")
+	}
+	return nil
+}
+
+// assertSaved checks whether the writes of saveTodoAndView are in the database.
+func assertSaved(t *testing.T, todos *TodoRepository, sessions *SessionRepository, want bool) {
+	t.Helper()
+	ctx := context.Background()
+
+	stored, err := todos.GetTodosByList(ctx, sql.NullString{String: "list-1", Valid: true})
+	if err != nil {
+		t.Fatalf("GetTodosByList: %v", err)
+	}
+	if got := len(stored) == 1; got != want {
+		t.Errorf("todo saved = %v, want %v", got, want)
+	}
+
+	_, err = sessions.GetListView(ctx, queries.GetListViewParams{MemberID: "session-1", ListID: "list-1"})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetListView: %v", err)
+	}
+	if got := err == nil; got != want {
+		t.Errorf("list view saved = %v, want %v", got, want)
+	}
+}
+
+func TestWithinTransactionCommits(t *testing.T) {
+	st := openTestStore(t)
+	todos, sessions := NewTodoRepository(st), NewSessionRepository(st)
+
+	if err := st.WithinTransaction(context.Background(), func(txCtx context.Context) error {
+		return saveTodoAndView(txCtx, todos, sessions)
+	}); err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+
+	assertSaved(t, todos, sessions, true)
+}
+
+func TestWithinTransactionRollsBackOnError(t *testing.T) {
+	st := openTestStore(t)
+	todos, sessions := NewTodoRepository(st), NewSessionRepository(st)
+
+	err := st.WithinTransaction(context.Background(), func(txCtx context.Context) error {
+		if err := saveTodoAndView(txCtx, todos, sessions); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTransaction error = %v, want %v", err, errAbort)
+	}
+
+	assertSaved(t, todos, sessions, false)
+}
+
+func TestWithinTransactionRollsBackFailedWrite(t *testing.T) {
+	st := openTestStore(t)
+	todos, sessions := NewTodoRepository(st), NewSessionRepository(st)
+
+	// The second insert of the same ID violates the primary key, which
+	// must take the list view written in between with it.
+	err := st.WithinTransaction(context.Background(), func(txCtx context.Context) error {
+		if err := saveTodoAndView(txCtx, todos, sessions); err != nil {
+			return err
+		}
+		return todos.CreateTodo(txCtx, queries.CreateTodoParams{ID: "todo-1", UserID: "session-1", Task: "Again"})
+	})
+	if err == nil {
+		t.Fatal("WithinTransaction succeeded, want a constraint error")
+	}
+
+	assertSaved(t, todos, sessions, false)
+}
+
+func TestWithinTransactionJoinsOuterTransaction(t *testing.T) {
+	st := openTestStore(t)
+	todos, sessions := NewTodoRepository(st), NewSessionRepository(st)
+
+	err := st.WithinTransaction(context.Background(), func(txCtx context.Context) error {
+		if err := st.WithinTransaction(txCtx, func(innerCtx context.Context) error {
+			return saveTodoAndView(innerCtx, todos, sessions)
+		}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTransaction error = %v, want %v", err, errAbort)
+	}
+
+	// The inner call returned without committing, so the outer rollback
+	// discards its writes too.
+	assertSaved(t, todos, sessions, false)
+}
+
+func TestOpenMigratesFileDatabase(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "todos.db")
+	for range 2 {
+		st, err := Open(dsn)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		// The pool is only pinned to one connection while migrating.
+		if got := st.DB().Stats().MaxOpenConnections; got != 0 {
+			t.Errorf("MaxOpenConnections = %d after Open, want no limit", got)
+		}
+		var violations int
+		if err := st.DB().QueryRow("SELECT count(*) FROM pragma_foreign_key_check").Scan(&violations); err != nil {
+			t.Fatalf("foreign_key_check: %v", err)
+		}
+		if violations != 0 {
+			t.Errorf("%d foreign key violations after migrating", violations)
+		}
+		if err := st.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+}
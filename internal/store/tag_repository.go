@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// TagRepository is the concrete implementation of domain.TagRepository.
+// It wraps sqlc-generated queries and acts as a driven adapter in hexagonal architecture.
+type TagRepository struct {
+	store *SQLiteStore
+}
+
+// Ensure TagRepository implements domain.TagRepository at compile time.
+var _ domain.TagRepository = (*TagRepository)(nil)
+
+// NewTagRepository creates a new TagRepository instance.
+func NewTagRepository(st *SQLiteStore) *TagRepository {
+	return &TagRepository{store: st}
+}
+
+// UpsertTag creates a tag unless the list already has one with the name,
+// and returns the ID of the tag.
+func (r *TagRepository) UpsertTag(ctx context.Context, arg queries.UpsertTagParams) (string, error) {
+	return r.store.conn(ctx).UpsertTag(ctx, arg)
+}
+
+// AddTodoTag attaches a tag to a todo.
+func (r *TagRepository) AddTodoTag(ctx context.Context, arg queries.AddTodoTagParams) error {
+	return r.store.conn(ctx).AddTodoTag(ctx, arg)
+}
+
+// GetTodoTagsByList retrieves the tag names of every tagged todo in a list.
+func (r *TagRepository) GetTodoTagsByList(ctx context.Context, listID string) ([]queries.GetTodoTagsByListRow, error) {
+	return r.store.conn(ctx).GetTodoTagsByList(ctx, listID)
+}
+
+// DeleteTodoTags detaches all tags from a todo.
+func (r *TagRepository) DeleteTodoTags(ctx context.Context, todoID string) error {
+	return r.store.conn(ctx).DeleteTodoTags(ctx, todoID)
+}
+
+// DeleteCompletedTodoTagsByList detaches all tags from the completed todos of a list.
+func (r *TagRepository) DeleteCompletedTodoTagsByList(ctx context.Context, listID sql.NullString) error {
+	return r.store.conn(ctx).DeleteCompletedTodoTagsByList(ctx, listID)
+}
+
+// DeleteTodoTagsByList detaches all tags from the todos of a list.
+func (r *TagRepository) DeleteTodoTagsByList(ctx context.Context, listID string) error {
+	return r.store.conn(ctx).DeleteTodoTagsByList(ctx, listID)
+}
+
+// DeleteTagsByList deletes all tags of a list.
+func (r *TagRepository) DeleteTagsByList(ctx context.Context, listID string) error {
+	return r.store.conn(ctx).DeleteTagsByList(ctx, listID)
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
 	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
@@ -21,17 +22,96 @@ func NewTodoRepository(st *SQLiteStore) *TodoRepository {
 	return &TodoRepository{store: st}
 }
 
-// GetTodosByUser retrieves all todos for a given user ID.
-func (r *TodoRepository) GetTodosByUser(ctx context.Context, userID string) ([]queries.Todo, error) {
-	return r.store.Queries().GetTodosByUser(ctx, userID)
+// GetTodosByList retrieves all todos of a list.
+func (r *TodoRepository) GetTodosByList(ctx context.Context, listID sql.NullString) ([]queries.Todo, error) {
+	return r.store.conn(ctx).GetTodosByList(ctx, listID)
+}
+
+// GetTodoByID retrieves a single todo by its ID.
+func (r *TodoRepository) GetTodoByID(ctx context.Context, id string) (queries.Todo, error) {
+	return r.store.conn(ctx).GetTodoByID(ctx, id)
 }
 
 // CreateTodo creates a new todo in the database.
 func (r *TodoRepository) CreateTodo(ctx context.Context, arg queries.CreateTodoParams) error {
-	return r.store.Queries().CreateTodo(ctx, arg)
+	return r.store.conn(ctx).CreateTodo(ctx, arg)
+}
+
+// UpdateTodoTask updates the task text of a todo if its version matches.
+func (r *TodoRepository) UpdateTodoTask(ctx context.Context, arg queries.UpdateTodoTaskParams) (int64, error) {
+	return r.store.conn(ctx).UpdateTodoTask(ctx, arg)
+}
+
+// ToggleTodoCompleted flips the completion state of a todo if its version matches.
+func (r *TodoRepository) ToggleTodoCompleted(ctx context.Context, arg queries.ToggleTodoCompletedParams) (int64, error) {
+	return r.store.conn(ctx).ToggleTodoCompleted(ctx, arg)
+}
+
+// SetTodoCompleted sets the completion state of a todo if its version matches.
+func (r *TodoRepository) SetTodoCompleted(ctx context.Context, arg queries.SetTodoCompletedParams) (int64, error) {
+	return r.store.conn(ctx).SetTodoCompleted(ctx, arg)
+}
+
+// SetTodoParent moves a todo under another todo, or to the top level, if its version matches.
+func (r *TodoRepository) SetTodoParent(ctx context.Context, arg queries.SetTodoParentParams) (int64, error) {
+	return r.store.conn(ctx).SetTodoParent(ctx, arg)
+}
+
+// DeleteTodo deletes a single todo by its ID if its version matches.
+func (r *TodoRepository) DeleteTodo(ctx context.Context, arg queries.DeleteTodoParams) (int64, error) {
+	return r.store.conn(ctx).DeleteTodo(ctx, arg)
+}
+
+// DeleteTodosByParent deletes all subtasks of a todo.
+func (r *TodoRepository) DeleteTodosByParent(ctx context.Context, parentID sql.NullString) error {
+	return r.store.conn(ctx).DeleteTodosByParent(ctx, parentID)
+}
+
+// DeleteCompletedTodosByList deletes all completed todos of a list.
+func (r *TodoRepository) DeleteCompletedTodosByList(ctx context.Context, listID sql.NullString) error {
+	return r.store.conn(ctx).DeleteCompletedTodosByList(ctx, listID)
+}
+
+// DeleteAllTodosByList deletes all todos of a list.
+func (r *TodoRepository) DeleteAllTodosByList(ctx context.Context, listID sql.NullString) error {
+	return r.store.conn(ctx).DeleteAllTodosByList(ctx, listID)
+}
+
+// MoveTodosToUser reassigns all todos of one user ID to another.
+func (r *TodoRepository) MoveTodosToUser(ctx context.Context, arg queries.MoveTodosToUserParams) error {
+	return r.store.conn(ctx).MoveTodosToUser(ctx, arg)
+}
+
+// MoveTodo changes the position of a todo if its version matches.
+func (r *TodoRepository) MoveTodo(ctx context.Context, arg queries.MoveTodoParams) (int64, error) {
+	return r.store.conn(ctx).MoveTodo(ctx, arg)
+}
+
+// ClearTodoRecurrence removes the recurrence rule from a todo, once its next
+// occurrence has taken the rule over.
+func (r *TodoRepository) ClearTodoRecurrence(ctx context.Context, arg queries.ClearTodoRecurrenceParams) (int64, error) {
+	return r.store.conn(ctx).ClearTodoRecurrence(ctx, arg)
+}
+
+// GetRecurringTodosDueBefore retrieves the open recurring todos of all lists
+// that were due before the given time.
+func (r *TodoRepository) GetRecurringTodosDueBefore(ctx context.Context, dueAt sql.NullTime) ([]queries.Todo, error) {
+	return r.store.conn(ctx).GetRecurringTodosDueBefore(ctx, dueAt)
+}
+
+// RestoreTodo overwrites every field of a todo a member can change, to put
+// back the state recorded in its history.
+func (r *TodoRepository) RestoreTodo(ctx context.Context, arg queries.RestoreTodoParams) (int64, error) {
+	return r.store.conn(ctx).RestoreTodo(ctx, arg)
+}
+
+// SearchTodos runs a full-text query against the todos of a list, best matches first.
+func (r *TodoRepository) SearchTodos(ctx context.Context, arg queries.SearchTodosParams) ([]queries.SearchTodosRow, error) {
+	return r.store.conn(ctx).SearchTodos(ctx, arg)
 }
 
-// DeleteAllTodosByUser deletes all todos for a given user ID.
-func (r *TodoRepository) DeleteAllTodosByUser(ctx context.Context, userID string) error {
-	return r.store.Queries().DeleteAllTodosByUser(ctx, userID)
+// GetDueTodosByMember retrieves the todos with a due date of the lists a
+// member belongs to, leaving out archived lists, soonest due first.
+func (r *TodoRepository) GetDueTodosByMember(ctx context.Context, memberID string) ([]queries.GetDueTodosByMemberRow, error) {
+	return r.store.conn(ctx).GetDueTodosByMember(ctx, memberID)
 }
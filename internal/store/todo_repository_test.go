@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+func TestSearchTodosSurvivesVacuum(t *testing.T) {
+	st := openTestStore(t)
+	todos := NewTodoRepository(st)
+	ctx := context.Background()
+	list := sql.NullString{String: "list-1", Valid: true}
+
+	for _, todo := range []queries.CreateTodoParams{
+		{ID: "todo-1", Task: "Buy milk"},
+		{ID: "todo-2", Task: "Walk the dog"},
+		{ID: "todo-3", Task: "Water the plants"},
+	} {
+		todo.UserID = "session-1"
+		todo.ListID = list
+		if err := todos.CreateTodo(ctx, todo); err != nil {
+			t.Fatalf("CreateTodo: %v", err)
+		}
+	}
+	if _, err := todos.DeleteTodo(ctx, queries.DeleteTodoParams{ID: "todo-1", Version: 1}); err != nil {
+		t.Fatalf("DeleteTodo: %v", err)
+	}
+
+	// VACUUM may renumber the rowids of tables without an INTEGER PRIMARY
+	// KEY, which would point the index at the wrong todos.
+	if _, err := st.DB().ExecContext(ctx, "VACUUM"); err != nil {
+		t.Fatalf("VACUUM: %v", err)
+	}
+
+	for query, want := range map[string]string{"dog": "todo-2", "plants": "todo-3"} {
+		found, err := todos.SearchTodos(ctx, queries.SearchTodosParams{Query: query, ListID: list, MaxResults: 10})
+		if err != nil {
+			t.Fatalf("SearchTodos(%q): %v", query, err)
+		}
+		if len(found) != 1 || found[0].ID != want {
+			t.Errorf("SearchTodos(%q) = %+v, want %s", query, found, want)
+		}
+	}
+
+	stored, err := todos.GetTodosByList(ctx, list)
+	if err != nil {
+		t.Fatalf("GetTodosByList: %v", err)
+	}
+	if len(stored) != 2 || stored[0].ID != "todo-2" || stored[1].ID != "todo-3" {
+		t.Errorf("GetTodosByList = %+v, want todo-2 then todo-3", stored)
+	}
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+
+	"github.com/yacobolo/datastar-go-blueprint/internal/domain"
+	"github.com/yacobolo/datastar-go-blueprint/internal/store/queries"
+)
+
+// UserRepository is the concrete implementation of domain.UserRepository.
+// It wraps sqlc-generated queries and acts as a driven adapter in hexagonal architecture.
+type UserRepository struct {
+	store *SQLiteStore
+}
+
+// Ensure UserRepository implements domain.UserRepository at compile time.
+var _ domain.UserRepository = (*UserRepository)(nil)
+
+// NewUserRepository creates a new UserRepository instance.
+func NewUserRepository(st *SQLiteStore) *UserRepository {
+	return &UserRepository{store: st}
+}
+
+// GetUserByID retrieves a user by its ID.
+func (r *UserRepository) GetUserByID(ctx context.Context, id string) (queries.User, error) {
+	return r.store.conn(ctx).GetUserByID(ctx, id)
+}
+
+// GetUserByEmail retrieves a user by email address, ignoring case.
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (queries.User, error) {
+	return r.store.conn(ctx).GetUserByEmail(ctx, email)
+}
+
+// CreateUser creates a new user in the database.
+func (r *UserRepository) CreateUser(ctx context.Context, arg queries.CreateUserParams) error {
+	return r.store.conn(ctx).CreateUser(ctx, arg)
+}
+
+// GetUserByOIDCSubject retrieves the user linked to an OIDC issuer and subject.
+func (r *UserRepository) GetUserByOIDCSubject(ctx context.Context, arg queries.GetUserByOIDCSubjectParams) (queries.User, error) {
+	return r.store.conn(ctx).GetUserByOIDCSubject(ctx, arg)
+}
+
+// CreateOIDCUser creates a user without a password that signs in with OIDC.
+func (r *UserRepository) CreateOIDCUser(ctx context.Context, arg queries.CreateOIDCUserParams) error {
+	return r.store.conn(ctx).CreateOIDCUser(ctx, arg)
+}
+
+// LinkUserOIDCSubject links an existing user to an OIDC issuer and subject.
+func (r *UserRepository) LinkUserOIDCSubject(ctx context.Context, arg queries.LinkUserOIDCSubjectParams) error {
+	return r.store.conn(ctx).LinkUserOIDCSubject(ctx, arg)
+}
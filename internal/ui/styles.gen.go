@@ -2,7 +2,7 @@
 //
 // Source: web/ui/styles
 // Files scanned: 10
-// Classes generated: 134
+// Classes generated: 154
 // Generated: 2026-02-05 10:33:12
 //
 // This file provides type-safe constants for CSS classes.
@@ -137,10 +137,18 @@ var AllCSSClasses = map[string]bool{
 	"toast-error": true,
 	"toast-info": true,
 	"toast-success": true,
+	"toast-warning": true,
+	"todo-activity": true,
+	"todo-activity-entry": true,
+	"todo-avatar": true,
 	"todo-checkbox-label": true,
 	"todo-container": true,
 	"todo-content": true,
 	"todo-description": true,
+	"todo-drag-handle": true,
+	"todo-due": true,
+	"todo-due-overdue": true,
+	"todo-editing-badge": true,
 	"todo-footer": true,
 	"todo-footer-actions": true,
 	"todo-footer-count": true,
@@ -150,9 +158,22 @@ var AllCSSClasses = map[string]bool{
 	"todo-input-controls": true,
 	"todo-item": true,
 	"todo-item-completed": true,
+	"todo-item-overdue": true,
+	"todo-item-subtask": true,
 	"todo-list": true,
 	"todo-list-container": true,
 	"todo-loading": true,
+	"todo-presence": true,
+	"todo-priority": true,
+	"todo-priority-high": true,
+	"todo-progress": true,
+	"todo-recurrence": true,
+	"todo-reminder": true,
+	"todo-search": true,
+	"todo-search-result": true,
+	"todo-search-results": true,
+	"todo-tag": true,
+	"todo-tag-active": true,
 	"todo-task-completed": true,
 	"todo-text-label": true,
 	"todo-title": true,
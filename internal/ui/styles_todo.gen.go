@@ -58,6 +58,58 @@ const ToastInfo = "toast-info"
 // - color: `var(--ui-color-primary-on)` 🎨
 const ToastSuccess = "toast-success"
 
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-error-container)` 🎨
+// - color: `var(--ui-color-error-container-on)` 🎨
+const ToastWarning = "toast-warning"
+
+// @layer components
+//
+//
+// **Layout:**
+// - display: `flex`
+// - flex-direction: `column`
+// - list-style: `none`
+// - margin: `0`
+// - padding: `0`
+const TodoActivity = "todo-activity"
+
+// @layer components
+//
+//
+// **Visual:**
+// - border-bottom: `var(--ui-border-thin) solid var(--ui-color-outline-variant)` 🎨
+// **Layout:**
+// - align-items: `baseline`
+// - display: `flex`
+// - flex-wrap: `wrap`
+// - gap: `var(--ui-space-xs)` 🎨
+// - padding: `var(--ui-space-sm) 0` 🎨
+// **Typography:**
+// - font-size: `var(--ui-type-size-sm)` 🎨
+const TodoActivityEntry = "todo-activity-entry"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-secondary-container)` 🎨
+// - border-radius: `var(--ui-radius-full)` 🎨
+// - color: `var(--ui-color-secondary-container-on)` 🎨
+// **Layout:**
+// - align-items: `center`
+// - display: `inline-flex`
+// - height: `2rem`
+// - justify-content: `center`
+// - width: `2rem`
+// **Typography:**
+// - font-size: `var(--ui-type-size-sm)` 🎨
+// - font-weight: `var(--ui-weight-bold)` 🎨
+const TodoAvatar = "todo-avatar"
+
 // @layer components
 //
 //
@@ -121,6 +173,57 @@ const TodoContent = "todo-content"
 // - `:hover`: Changes color to `var(--ui-color-primary-container-on)`
 const TodoDescription = "todo-description"
 
+// @layer components
+//
+//
+// **Visual:**
+// - color: `var(--ui-color-surface-variant-on)` 🎨
+// - opacity: `0.5`
+// **Layout:**
+// - align-items: `center`
+// - cursor: `grab`
+// - display: `flex`
+//
+// **Interactions:**
+// - `:hover`: Changes opacity to `1`
+const TodoDragHandle = "todo-drag-handle"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-surface-container-high)` 🎨
+// - border-radius: `var(--ui-radius-full)` 🎨
+// - color: `var(--ui-color-surface-variant-on)` 🎨
+// **Layout:**
+// - padding: `var(--ui-space-2xs) var(--ui-space-xs)` 🎨
+// **Typography:**
+// - font-size: `var(--ui-type-size-xs)` 🎨
+// - white-space: `nowrap`
+const TodoDue = "todo-due"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-error-container)` 🎨
+// - color: `var(--ui-color-error-container-on)` 🎨
+const TodoDueOverdue = "todo-due-overdue"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-tertiary-container)` 🎨
+// - border-radius: `var(--ui-radius-full)` 🎨
+// - color: `var(--ui-color-tertiary-container-on)` 🎨
+// **Layout:**
+// - padding: `var(--ui-space-2xs) var(--ui-space-xs)` 🎨
+// **Typography:**
+// - font-size: `var(--ui-type-size-xs)` 🎨
+// - white-space: `nowrap`
+const TodoEditingBadge = "todo-editing-badge"
+
 // @layer components
 //
 //
@@ -225,6 +328,20 @@ const TodoItem = "todo-item"
 // - opacity: `0.65`
 const TodoItemCompleted = "todo-item-completed"
 
+// @layer components
+//
+//
+// **Visual:**
+// - border-color: `var(--ui-color-error)` 🎨
+const TodoItemOverdue = "todo-item-overdue"
+
+// @layer components
+//
+//
+// **Layout:**
+// - margin-left: `var(--ui-space-xl)` 🎨
+const TodoItemSubtask = "todo-item-subtask"
+
 // @layer components
 //
 //
@@ -259,6 +376,153 @@ const TodoListContainer = "todo-list-container"
 // - font-size: `var(--ui-type-size-base)` 🎨
 const TodoLoading = "todo-loading"
 
+// @layer components
+//
+//
+// **Layout:**
+// - display: `flex`
+// - gap: `var(--ui-space-xs)` 🎨
+// - justify-content: `center`
+const TodoPresence = "todo-presence"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-surface-container-high)` 🎨
+// - border-radius: `var(--ui-radius-full)` 🎨
+// - color: `var(--ui-color-surface-variant-on)` 🎨
+// **Layout:**
+// - padding: `var(--ui-space-2xs) var(--ui-space-xs)` 🎨
+// **Typography:**
+// - font-size: `var(--ui-type-size-xs)` 🎨
+// - white-space: `nowrap`
+const TodoPriority = "todo-priority"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-warning-container)` 🎨
+// - color: `var(--ui-color-warning-container-on)` 🎨
+const TodoPriorityHigh = "todo-priority-high"
+
+// @layer components
+//
+//
+// **Visual:**
+// - color: `var(--ui-color-surface-variant-on)` 🎨
+// **Layout:**
+// - align-items: `center`
+// - display: `inline-flex`
+// - gap: `var(--ui-space-2xs)` 🎨
+// **Typography:**
+// - font-size: `var(--ui-type-size-xs)` 🎨
+// - white-space: `nowrap`
+const TodoProgress = "todo-progress"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-surface-container-high)` 🎨
+// - border-radius: `var(--ui-radius-full)` 🎨
+// - color: `var(--ui-color-surface-variant-on)` 🎨
+// **Layout:**
+// - align-items: `center`
+// - display: `inline-flex`
+// - gap: `var(--ui-space-2xs)` 🎨
+// - padding: `var(--ui-space-2xs) var(--ui-space-xs)` 🎨
+// **Typography:**
+// - font-size: `var(--ui-type-size-xs)` 🎨
+// - white-space: `nowrap`
+const TodoRecurrence = "todo-recurrence"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-surface-container-high)` 🎨
+// - border-radius: `var(--ui-radius-full)` 🎨
+// - color: `var(--ui-color-surface-variant-on)` 🎨
+// **Layout:**
+// - align-items: `center`
+// - display: `inline-flex`
+// - gap: `var(--ui-space-2xs)` 🎨
+// - padding: `var(--ui-space-2xs) var(--ui-space-xs)` 🎨
+// **Typography:**
+// - font-size: `var(--ui-type-size-xs)` 🎨
+// - white-space: `nowrap`
+const TodoReminder = "todo-reminder"
+
+// @layer components
+//
+//
+// **Layout:**
+// - display: `flex`
+// - flex-direction: `column`
+// - gap: `var(--ui-space-xs)` 🎨
+const TodoSearch = "todo-search"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `transparent`
+// - border: `none`
+// - border-radius: `var(--ui-radius-sm)` 🎨
+// - color: `var(--ui-color-surface-on)` 🎨
+// **Layout:**
+// - cursor: `pointer`
+// - padding: `var(--ui-space-xs) var(--ui-space-sm)` 🎨
+// - width: `100%`
+// **Typography:**
+// - font-size: `var(--ui-type-size-sm)` 🎨
+// - text-align: `left`
+//
+// **Interactions:**
+// - `:hover`: Changes background to `var(--ui-color-surface-container)`
+const TodoSearchResult = "todo-search-result"
+
+// @layer components
+//
+//
+// **Layout:**
+// - display: `flex`
+// - flex-direction: `column`
+// - gap: `var(--ui-space-2xs)` 🎨
+// - list-style: `none`
+// - margin: `0`
+// - [+1 more layout properties]
+const TodoSearchResults = "todo-search-results"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-secondary-container)` 🎨
+// - border: `none`
+// - border-radius: `var(--ui-radius-full)` 🎨
+// - color: `var(--ui-color-secondary-container-on)` 🎨
+// **Layout:**
+// - align-items: `center`
+// - cursor: `pointer`
+// - display: `inline-flex`
+// - gap: `var(--ui-space-2xs)` 🎨
+// - padding: `var(--ui-space-2xs) var(--ui-space-xs)` 🎨
+// **Typography:**
+// - font-size: `var(--ui-type-size-xs)` 🎨
+// - white-space: `nowrap`
+const TodoTag = "todo-tag"
+
+// @layer components
+//
+//
+// **Visual:**
+// - background: `var(--ui-color-secondary)` 🎨
+// - color: `var(--ui-color-secondary-on)` 🎨
+const TodoTagActive = "todo-tag-active"
+
 // @layer components
 //
 //